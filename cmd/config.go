@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"go-cqrs/internal/infrastructure/config"
+)
+
+const configUsage = `Usage: go-cqrs config print [flags]
+
+Prints the configuration the server would run with, after layering the config file, the environment and the flags.
+`
+
+// runConfig implements the config subcommand
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return errors.New("expected print")
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), configUsage)
+		flags.PrintDefaults()
+	}
+	redact := flags.Bool("redacted", false, "replace the values of secrets such as the database password")
+	format := flags.String("format", "yaml", "output format: yaml or toml")
+	loader := config.NewLoader(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	if *redact {
+		cfg = cfg.Redacted()
+	}
+
+	switch *format {
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(cfg); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(os.Stdout).Encode(cfg)
+	default:
+		return fmt.Errorf("unknown format %q, expected yaml or toml", *format)
+	}
+}
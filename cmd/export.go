@@ -0,0 +1,143 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"go-cqrs/internal/adapters/exports"
+	"go-cqrs/internal/infrastructure/config"
+	"go-cqrs/internal/infrastructure/container"
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+const exportUsage = `Usage: go-cqrs export customers|orders [flags]
+
+Writes all customers or orders matching the filters to a file, read from a single database snapshot.
+`
+
+// runExport implements the export subcommand
+func runExport(args []string) error {
+	if len(args) == 0 || (args[0] != "customers" && args[0] != "orders") {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return errors.New("expected customers or orders")
+	}
+	entity := args[0]
+
+	flags := flag.NewFlagSet("export "+entity, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), exportUsage)
+		flags.PrintDefaults()
+	}
+	formatName := flags.String("format", "csv", "export format: csv, ndjson or parquet")
+	output := flags.String("output", "", "file to write, <entity>.<format> by default")
+	compress := flags.Bool("gzip", false, "gzip the output")
+	createdFrom := flags.String("created-from", "", "only rows created at or after this RFC 3339 timestamp or date")
+	createdTo := flags.String("created-to", "", "only rows created before this RFC 3339 timestamp or date")
+	includeMerged := flags.Bool("include-merged", false, "customers: include customers merged into another one")
+	customerID := flags.Int("customer-id", 0, "orders: only orders of this customer")
+	product := flags.String("product", "", "orders: only orders with a line for this SKU")
+	currency := flags.String("currency", "", "orders: only orders in this currency")
+	tenant := flags.String("tenant", requestctx.DefaultTenant, "tenant whose rows are exported")
+	loader := config.NewLoader(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	format, err := exports.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	// The filters are read the same way as the query parameters of the export endpoints
+	values := url.Values{}
+	setIf := func(name, value string) {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	setIf("createdFrom", *createdFrom)
+	setIf("createdTo", *createdTo)
+	setIf("includeMerged", strconv.FormatBool(*includeMerged))
+	setIf("product", *product)
+	setIf("currency", *currency)
+	if *customerID != 0 {
+		values.Set("customerId", strconv.Itoa(*customerID))
+	}
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("%s.%s", entity, format)
+		if *compress {
+			path += ".gz"
+		}
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+
+	app, err := container.NewContainer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize application: %w", err)
+	}
+	defer app.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if *compress {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+
+	ctx := requestctx.WithTenant(context.Background(), *tenant)
+	count, err := export(ctx, app.Exporter, entity, out, format, values)
+	if err != nil {
+		return err
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d %s to %s\n", count, entity, path)
+	return nil
+}
+
+// export writes the customers or orders matching the filter values
+func export(ctx context.Context, exporter *exports.Exporter, entity string, out io.Writer, format exports.Format, values url.Values) (int, error) {
+	if entity == "customers" {
+		filter, err := exports.ParseCustomerFilter(values)
+		if err != nil {
+			return 0, err
+		}
+		return exporter.ExportCustomers(ctx, out, format, filter)
+	}
+
+	filter, err := exports.ParseOrderFilter(values)
+	if err != nil {
+		return 0, err
+	}
+	return exporter.ExportOrders(ctx, out, format, filter)
+}
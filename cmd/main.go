@@ -2,20 +2,55 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"go-cqrs/internal/infrastructure/config"
 	"go-cqrs/internal/infrastructure/container"
 	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/requestctx"
 )
 
 func main() {
+	// Run a subcommand instead of the server when one is given
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Printf("Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Printf("Config failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load the configuration, reporting every invalid setting before anything starts
+	loader, err := config.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2) // The flag package has printed the error and the usage
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create application container
-	app, err := container.NewContainer()
+	app, err := container.NewContainer(cfg)
 	if err != nil {
 		fmt.Printf("Failed to initialize application: %v\n", err)
 		os.Exit(1)
@@ -31,6 +66,48 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Apply the log level, CORS origins and GraphQL limits of the config reloaded on SIGHUP or
+	// when the config file changes, rejecting changes to the settings that need a restart
+	watcher := config.NewWatcher(loader, cfg, app.Logger, 2*time.Second)
+	watcher.OnReload(app.ApplyConfig)
+	watcherCtx, stopWatcher := context.WithCancel(context.Background())
+	go watcher.Run(watcherCtx)
+	srv.RegisterOnShutdown(stopWatcher)
+
+	// Tail the events table for the event stream until the server shuts down, which ends the open streams.
+	// Like the webhook worker and the relay, the feed reads the rows of every tenant.
+	feedCtx, stopFeed := context.WithCancel(requestctx.WithAllTenants(context.Background()))
+	go app.EventFeed.Run(feedCtx)
+	srv.RegisterOnShutdown(stopFeed)
+
+	// Deliver stored events to webhooks until shutdown; deliveries cut short are attempted again later
+	webhookCtx, stopWebhooks := context.WithCancel(requestctx.WithAllTenants(context.Background()))
+	go app.WebhookWorker.Run(webhookCtx)
+	srv.RegisterOnShutdown(stopWebhooks)
+
+	// Publish stored events to the message broker, when one is configured, until shutdown
+	if app.EventRelay != nil {
+		relayCtx, stopRelay := context.WithCancel(requestctx.WithAllTenants(context.Background()))
+		go app.EventRelay.Run(relayCtx)
+		srv.RegisterOnShutdown(stopRelay)
+	}
+
+	// Drive order commands from the checkout messages, when an inbound broker is configured, until shutdown
+	if app.InboundSubscriber != nil {
+		consumerCtx, stopConsumer := context.WithCancel(context.Background())
+		go app.CheckoutConsumer.Run(consumerCtx, app.InboundSubscriber, app.Config.Inbound.CheckoutTopic)
+		srv.RegisterOnShutdown(stopConsumer)
+	}
+
+	// Run the background imports until shutdown, which cancels them and fails the ones still queued
+	importCtx, stopImports := context.WithCancel(context.Background())
+	importsStopped := make(chan struct{})
+	go func() {
+		app.ImportRunner.Run(importCtx)
+		close(importsStopped)
+	}()
+	srv.RegisterOnShutdown(stopImports)
+
 	// Start server in a goroutine
 	go func() {
 		app.Logger.Info("Server is running", logger.String("address", app.Config.ServerAddress()))
@@ -39,6 +116,18 @@ func main() {
 		}
 	}()
 
+	// Start the gRPC server alongside the HTTP one
+	listener, err := net.Listen("tcp", app.Config.GRPCAddress())
+	if err != nil {
+		app.Logger.Fatal("Failed to listen for gRPC", logger.Error(err))
+	}
+	go func() {
+		app.Logger.Info("gRPC server is running", logger.String("address", app.Config.GRPCAddress()))
+		if err := app.GRPCServer.Serve(listener); err != nil {
+			app.Logger.Fatal("Failed to start gRPC server", logger.Error(err))
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -49,11 +138,31 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Shutdown server
+	// Shutdown servers, stopping the gRPC calls still running when the timeout expires
+	grpcStopped := make(chan struct{})
+	go func() {
+		app.GRPCServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		app.Logger.Fatal("Server forced to shutdown", logger.Error(err))
 	}
 
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		app.Logger.Warn("gRPC server forced to shutdown")
+		app.GRPCServer.Stop()
+	}
+
+	// Wait for the cancelled imports to record their outcome before the database closes
+	select {
+	case <-importsStopped:
+	case <-ctx.Done():
+		app.Logger.Warn("Background imports did not stop in time")
+	}
+
 	app.Logger.Info("Server shutdown complete")
 	app.Logger.Sync()
 }
@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/http/dto"
+)
+
+// RegisterCommands makes the customer, order, product and inventory commands available to batches,
+// dispatching them through the command bus
+func RegisterCommands(e *Executor, commandBus *bus.CommandBus) {
+	dispatch := func(ctx context.Context, cmd interface{}) error {
+		_, err := commandBus.Dispatch(ctx, cmd)
+		return err
+	}
+
+	// Customer commands
+	e.Register("CreateCustomerCommand", Command(func(ctx context.Context, cmd commands.CreateCustomerCommand) (Result, error) {
+		id, err := bus.Result[int](commandBus.Dispatch(ctx, cmd))
+		return Result{ID: id}, err
+	}))
+	e.Register("UpdateCustomerCommand", Command(func(ctx context.Context, cmd commands.UpdateCustomerCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("DeleteCustomerCommand", Command(func(ctx context.Context, cmd commands.DeleteCustomerCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("MergeCustomersCommand", Command(func(ctx context.Context, cmd commands.MergeCustomersCommand) (Result, error) {
+		report, err := bus.Result[*dto.MergeReportDTO](commandBus.Dispatch(ctx, cmd))
+		return Result{ID: cmd.SurvivorID, Data: report}, err
+	}))
+	e.Register("AddCustomerAddressCommand", Command(func(ctx context.Context, cmd commands.AddCustomerAddressCommand) (Result, error) {
+		id, err := bus.Result[int](commandBus.Dispatch(ctx, cmd))
+		return Result{ID: id}, err
+	}))
+	e.Register("UpdateCustomerAddressCommand", Command(func(ctx context.Context, cmd commands.UpdateCustomerAddressCommand) (Result, error) {
+		return Result{ID: cmd.AddressID}, dispatch(ctx, cmd)
+	}))
+	e.Register("RemoveCustomerAddressCommand", Command(func(ctx context.Context, cmd commands.RemoveCustomerAddressCommand) (Result, error) {
+		return Result{ID: cmd.AddressID}, dispatch(ctx, cmd)
+	}))
+
+	// Order commands
+	e.Register("CreateOrderCommand", Command(func(ctx context.Context, cmd commands.CreateOrderCommand) (Result, error) {
+		id, err := bus.Result[int](commandBus.Dispatch(ctx, cmd))
+		return Result{ID: id}, err
+	}))
+	e.Register("UpdateOrderCommand", Command(func(ctx context.Context, cmd commands.UpdateOrderCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("DeleteOrderCommand", Command(func(ctx context.Context, cmd commands.DeleteOrderCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("AssignCustomerCommand", Command(func(ctx context.Context, cmd commands.AssignCustomerCommand) (Result, error) {
+		return Result{ID: cmd.OrderID}, dispatch(ctx, cmd)
+	}))
+	e.Register("AddOrderLineCommand", Command(func(ctx context.Context, cmd commands.AddOrderLineCommand) (Result, error) {
+		return Result{ID: cmd.OrderID}, dispatch(ctx, cmd)
+	}))
+	e.Register("ChangeOrderLineCommand", Command(func(ctx context.Context, cmd commands.ChangeOrderLineCommand) (Result, error) {
+		return Result{ID: cmd.OrderID}, dispatch(ctx, cmd)
+	}))
+	e.Register("RemoveOrderLineCommand", Command(func(ctx context.Context, cmd commands.RemoveOrderLineCommand) (Result, error) {
+		return Result{ID: cmd.OrderID}, dispatch(ctx, cmd)
+	}))
+
+	// Product and inventory commands
+	e.Register("CreateProductCommand", Command(func(ctx context.Context, cmd commands.CreateProductCommand) (Result, error) {
+		id, err := bus.Result[int](commandBus.Dispatch(ctx, cmd))
+		return Result{ID: id}, err
+	}))
+	e.Register("UpdateProductCommand", Command(func(ctx context.Context, cmd commands.UpdateProductCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("DeactivateProductCommand", Command(func(ctx context.Context, cmd commands.DeactivateProductCommand) (Result, error) {
+		return Result{ID: cmd.ID}, dispatch(ctx, cmd)
+	}))
+	e.Register("SetStockLevelCommand", Command(func(ctx context.Context, cmd commands.SetStockLevelCommand) (Result, error) {
+		level, err := bus.Result[*dto.StockLevelDTO](commandBus.Dispatch(ctx, cmd))
+		return Result{Data: level}, err
+	}))
+}
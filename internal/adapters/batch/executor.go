@@ -0,0 +1,197 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"strings"
+)
+
+// MaxCommands bounds the number of commands in one batch
+const MaxCommands = 100
+
+// Result is the outcome of a successful command. ID is the ID of the entity it created,
+// which later commands can refer to, and Data any other result it returns.
+type Result struct {
+	ID   int
+	Data interface{}
+}
+
+// CommandFunc decodes a command from its JSON payload and runs it
+type CommandFunc func(ctx context.Context, payload json.RawMessage) (Result, error)
+
+// Command adapts a command handler method to a CommandFunc decoding its command type
+func Command[C any](run func(ctx context.Context, cmd C) (Result, error)) CommandFunc {
+	return func(ctx context.Context, payload json.RawMessage) (Result, error) {
+		var cmd C
+		if len(payload) > 0 {
+			if err := json.Unmarshal(payload, &cmd); err != nil {
+				return Result{}, fmt.Errorf("invalid payload: %w", err)
+			}
+		}
+		return run(ctx, cmd)
+	}
+}
+
+// errBatchAborted rolls back an atomic batch once one of its commands failed
+var errBatchAborted = errors.New("batch aborted")
+
+// Executor runs batches of commands in order, either atomically in one transaction
+// or best-effort with every command in its own transaction
+type Executor struct {
+	txManager ports.TransactionManager
+	commands  map[string]CommandFunc
+}
+
+// NewExecutor creates an executor without any commands
+func NewExecutor(txManager ports.TransactionManager) *Executor {
+	return &Executor{txManager: txManager, commands: make(map[string]CommandFunc)}
+}
+
+// Register makes a command available under a type name. The name may be given with or without its Command suffix.
+func (e *Executor) Register(name string, command CommandFunc) {
+	e.commands[strings.TrimSuffix(name, "Command")] = command
+}
+
+func (e *Executor) command(name string) (CommandFunc, bool) {
+	command, ok := e.commands[strings.TrimSuffix(name, "Command")]
+	return command, ok
+}
+
+// Execute runs a batch and reports the outcome of every command. An error is only returned
+// when the batch itself is invalid, in which case none of its commands ran.
+func (e *Executor) Execute(ctx context.Context, request dto.BatchRequest) (*dto.BatchResponseDTO, error) {
+	if err := e.validate(request); err != nil {
+		return nil, err
+	}
+
+	response := &dto.BatchResponseDTO{
+		Atomic:  request.IsAtomic(),
+		Results: make([]dto.BatchResultDTO, len(request.Commands)),
+	}
+	for i, command := range request.Commands {
+		response.Results[i] = dto.BatchResultDTO{Index: i, ID: command.ID, Type: command.Type, Status: dto.BatchCommandSkipped}
+	}
+
+	if response.Atomic {
+		e.executeAtomic(ctx, request.Commands, response.Results)
+	} else {
+		e.executeBestEffort(ctx, request.Commands, response.Results)
+	}
+
+	response.Succeeded = true
+	for _, result := range response.Results {
+		if result.Status != dto.BatchCommandSucceeded {
+			response.Succeeded = false
+		}
+	}
+	return response, nil
+}
+
+// executeAtomic runs the commands in one transaction, stopping at the first failure
+func (e *Executor) executeAtomic(ctx context.Context, commands []dto.BatchCommandDTO, results []dto.BatchResultDTO) {
+	ids := make(map[string]int)
+
+	err := e.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, command := range commands {
+			if !e.run(ctx, command, ids, &results[i]) {
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	// Nothing the earlier commands did was kept
+	for i := range results {
+		if results[i].Status == dto.BatchCommandSucceeded {
+			results[i].Status = dto.BatchCommandRolledBack
+			results[i].ResultID = 0
+			results[i].Result = nil
+			if !errors.Is(err, errBatchAborted) {
+				results[i].Error = err.Error()
+			}
+		}
+	}
+}
+
+// executeBestEffort runs every command in its own transaction, skipping those that refer to a failed one
+func (e *Executor) executeBestEffort(ctx context.Context, commands []dto.BatchCommandDTO, results []dto.BatchResultDTO) {
+	ids := make(map[string]int)
+
+	for i, command := range commands {
+		_ = e.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if !e.run(ctx, command, ids, &results[i]) {
+				return errBatchAborted
+			}
+			return nil
+		})
+	}
+}
+
+// run resolves the references of a command, runs it and records its outcome, reporting whether it succeeded
+func (e *Executor) run(ctx context.Context, command dto.BatchCommandDTO, ids map[string]int, result *dto.BatchResultDTO) bool {
+	payload, err := resolveReferences(command.Payload, ids)
+	if err != nil {
+		result.Error = err.Error()
+		return false
+	}
+
+	run, _ := e.command(command.Type)
+	outcome, err := run(ctx, payload)
+	if err != nil {
+		result.Status = dto.BatchCommandFailed
+		result.Error = err.Error()
+		return false
+	}
+
+	result.Status = dto.BatchCommandSucceeded
+	result.ResultID = outcome.ID
+	result.Result = outcome.Data
+	if command.ID != "" && outcome.ID != 0 {
+		ids[command.ID] = outcome.ID
+	}
+	return true
+}
+
+// validate checks the shape of a batch before any command runs: known command types,
+// unique command IDs and references to earlier commands only
+func (e *Executor) validate(request dto.BatchRequest) error {
+	if len(request.Commands) == 0 {
+		return domainerrors.NewValidationError("a batch needs at least one command")
+	}
+	if len(request.Commands) > MaxCommands {
+		return domainerrors.NewValidationError(fmt.Sprintf("a batch cannot have more than %d commands", MaxCommands))
+	}
+
+	seen := make(map[string]bool)
+	for i, command := range request.Commands {
+		if _, ok := e.command(command.Type); !ok {
+			return domainerrors.NewValidationError(fmt.Sprintf("command %d has unknown type %q", i, command.Type))
+		}
+
+		names, err := references(command.Payload)
+		if err != nil {
+			return domainerrors.NewValidationError(fmt.Sprintf("command %d: %v", i, err))
+		}
+		for _, name := range names {
+			if !seen[name] {
+				return domainerrors.NewValidationError(fmt.Sprintf("command %d refers to %q, which is not an earlier command", i, name))
+			}
+		}
+
+		if command.ID != "" {
+			if seen[command.ID] {
+				return domainerrors.NewValidationError(fmt.Sprintf("command ID %q is used twice", command.ID))
+			}
+			seen[command.ID] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// referenceKey marks an object standing for the ID produced by an earlier command, as in {"$ref": "order"}
+const referenceKey = "$ref"
+
+// references returns the names of the commands a payload refers to
+func references(payload json.RawMessage) ([]string, error) {
+	value, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	_, err = replaceReferences(value, func(name string) (interface{}, error) {
+		names = append(names, name)
+		return nil, nil
+	})
+	return names, err
+}
+
+// resolveReferences replaces the references of a payload with the IDs they stand for
+func resolveReferences(payload json.RawMessage, ids map[string]int) (json.RawMessage, error) {
+	value, err := decodePayload(payload)
+	if err != nil || value == nil {
+		return payload, err
+	}
+
+	resolved := false
+	replace := func(name string) (interface{}, error) {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("reference %q has no result", name)
+		}
+		resolved = true
+		return id, nil
+	}
+
+	value, err = replaceReferences(value, replace)
+	if err != nil || !resolved {
+		return payload, err
+	}
+	return json.Marshal(value)
+}
+
+func decodePayload(payload json.RawMessage) (interface{}, error) {
+	if len(bytes.TrimSpace(payload)) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return value, nil
+}
+
+// replaceReferences returns the decoded payload with every reference replaced by what replace returns for it
+func replaceReferences(value interface{}, replace func(name string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v[referenceKey]; ok && len(v) == 1 {
+			name, ok := ref.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("%s must name an earlier command", referenceKey)
+			}
+			return replace(name)
+		}
+		for key, item := range v {
+			replaced, err := replaceReferences(item, replace)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = replaced
+		}
+	case []interface{}:
+		for i, item := range v {
+			replaced, err := replaceReferences(item, replace)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = replaced
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,96 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// CommandHandler handles a command and returns its result, or nil for commands without one
+type CommandHandler func(ctx context.Context, cmd interface{}) (interface{}, error)
+
+// CommandMiddleware decorates the handling of every command dispatched through a bus
+type CommandMiddleware func(next CommandHandler) CommandHandler
+
+// CommandBus dispatches commands to the handler registered for their type,
+// through a pipeline of middleware shared by all commands
+type CommandBus struct {
+	handlers   map[reflect.Type]CommandHandler
+	middleware []CommandMiddleware
+}
+
+// NewCommandBus creates a bus without handlers or middleware
+func NewCommandBus() *CommandBus {
+	return &CommandBus{handlers: make(map[reflect.Type]CommandHandler)}
+}
+
+// Use appends middleware to the pipeline. The first middleware added is the outermost one.
+func (b *CommandBus) Use(middleware ...CommandMiddleware) {
+	b.middleware = append(b.middleware, middleware...)
+}
+
+// RegisterCommand registers the handler for commands of type C
+func RegisterCommand[C any, R any](b *CommandBus, handle func(ctx context.Context, cmd C) (R, error)) {
+	b.register(reflect.TypeOf((*C)(nil)).Elem(), func(ctx context.Context, cmd interface{}) (interface{}, error) {
+		return handle(ctx, cmd.(C))
+	})
+}
+
+// RegisterVoidCommand registers the handler for commands of type C that have no result
+func RegisterVoidCommand[C any](b *CommandBus, handle func(ctx context.Context, cmd C) error) {
+	b.register(reflect.TypeOf((*C)(nil)).Elem(), func(ctx context.Context, cmd interface{}) (interface{}, error) {
+		return nil, handle(ctx, cmd.(C))
+	})
+}
+
+func (b *CommandBus) register(commandType reflect.Type, handler CommandHandler) {
+	if _, ok := b.handlers[commandType]; ok {
+		panic(fmt.Sprintf("a handler for %s is already registered", commandType.Name()))
+	}
+	b.handlers[commandType] = handler
+}
+
+// Dispatch runs a command through the middleware pipeline and its handler
+func (b *CommandBus) Dispatch(ctx context.Context, cmd interface{}) (interface{}, error) {
+	if value := reflect.ValueOf(cmd); value.Kind() == reflect.Pointer && !value.IsNil() {
+		cmd = value.Elem().Interface()
+	}
+
+	handler, ok := b.handlers[reflect.TypeOf(cmd)]
+	if !ok {
+		return nil, fmt.Errorf("no handler registered for %s", CommandName(cmd))
+	}
+
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](handler)
+	}
+	return handler(ctx, cmd)
+}
+
+// CommandName returns the name of the type of a command
+func CommandName(cmd interface{}) string {
+	commandType := reflect.TypeOf(cmd)
+	if commandType == nil {
+		return "<nil>"
+	}
+	if commandType.Kind() == reflect.Pointer {
+		commandType = commandType.Elem()
+	}
+	return commandType.Name()
+}
+
+// Result converts the result of Dispatch to the type the command's handler returns:
+//
+//	orderID, err := bus.Result[int](commandBus.Dispatch(ctx, cmd))
+func Result[R any](result interface{}, err error) (R, error) {
+	var zero R
+	if err != nil || result == nil {
+		return zero, err
+	}
+
+	typed, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("unexpected command result of type %T", result)
+	}
+	return typed, nil
+}
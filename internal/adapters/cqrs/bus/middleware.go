@@ -0,0 +1,116 @@
+package bus
+
+import (
+	"context"
+	"expvar"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"time"
+)
+
+// commandMetrics counts the commands handled per command type, published at /api/metrics
+var commandMetrics = expvar.NewMap("commands")
+
+// LoggingMiddleware logs every command with its outcome and duration
+func LoggingMiddleware(log logger.Logger) CommandMiddleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, cmd interface{}) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, cmd)
+
+			fields := []logger.Field{
+				logger.String("command", CommandName(cmd)),
+				logger.String("request_id", requestctx.RequestID(ctx)),
+				logger.String("actor", requestctx.Actor(ctx)),
+				logger.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				log.Warn("Command failed", append(fields, logger.Error(err))...)
+			} else {
+				log.Info("Command handled", fields...)
+			}
+			return result, err
+		}
+	}
+}
+
+// MetricsMiddleware counts commands, failures and handling time per command type
+func MetricsMiddleware() CommandMiddleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, cmd interface{}) (interface{}, error) {
+			name := CommandName(cmd)
+			start := time.Now()
+			result, err := next(ctx, cmd)
+
+			commandMetrics.Add(name+".count", 1)
+			if err != nil {
+				commandMetrics.Add(name+".errors", 1)
+			}
+			commandMetrics.AddFloat(name+".seconds", time.Since(start).Seconds())
+			return result, err
+		}
+	}
+}
+
+// RetryMiddleware runs a command again when it fails with an error that retryable accepts,
+// up to attempts times in all, doubling the wait between attempts from backoff.
+// It must sit outside TransactionMiddleware so that every attempt runs in a fresh transaction.
+func RetryMiddleware(attempts int, backoff time.Duration, retryable func(err error) bool) CommandMiddleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, cmd interface{}) (interface{}, error) {
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				result, err := next(ctx, cmd)
+				if err == nil || attempt >= attempts || !retryable(err) {
+					return result, err
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil, err
+				case <-time.After(wait):
+				}
+				wait *= 2
+			}
+		}
+	}
+}
+
+// TransactionMiddleware runs every command in a transaction, so that its writes and
+// the events it stores are committed together
+func TransactionMiddleware(txManager ports.TransactionManager) CommandMiddleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, cmd interface{}) (interface{}, error) {
+			var result interface{}
+			err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				var err error
+				result, err = next(ctx, cmd)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+}
+
+// SnapshotMiddleware runs every query in a read-only transaction over a single snapshot, so that
+// its reads see consistent data and are scoped to the tenant by the row level security policies
+func SnapshotMiddleware(snapshots ports.SnapshotManager) QueryMiddleware {
+	return func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query interface{}) (interface{}, error) {
+			var result interface{}
+			err := snapshots.WithinSnapshot(ctx, func(ctx context.Context) error {
+				var err error
+				result, err = next(ctx, query)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+}
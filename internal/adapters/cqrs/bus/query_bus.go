@@ -0,0 +1,69 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// QueryHandler handles a query and returns its result
+type QueryHandler func(ctx context.Context, query interface{}) (interface{}, error)
+
+// QueryMiddleware decorates the handling of every query asked through a bus
+type QueryMiddleware func(next QueryHandler) QueryHandler
+
+// QueryBus dispatches queries to the handler registered for their type,
+// through a pipeline of middleware shared by all queries
+type QueryBus struct {
+	handlers   map[reflect.Type]QueryHandler
+	middleware []QueryMiddleware
+}
+
+// NewQueryBus creates a bus without handlers or middleware
+func NewQueryBus() *QueryBus {
+	return &QueryBus{handlers: make(map[reflect.Type]QueryHandler)}
+}
+
+// Use appends middleware to the pipeline. The first middleware added is the outermost one.
+func (b *QueryBus) Use(middleware ...QueryMiddleware) {
+	b.middleware = append(b.middleware, middleware...)
+}
+
+// RegisterQuery registers the handler for queries of type Q
+func RegisterQuery[Q any, R any](b *QueryBus, handle func(ctx context.Context, query Q) (R, error)) {
+	queryType := reflect.TypeOf((*Q)(nil)).Elem()
+	if _, ok := b.handlers[queryType]; ok {
+		panic(fmt.Sprintf("a handler for %s is already registered", queryType.Name()))
+	}
+
+	b.handlers[queryType] = func(ctx context.Context, query interface{}) (interface{}, error) {
+		return handle(ctx, query.(Q))
+	}
+}
+
+// Ask runs a query through the middleware pipeline and its handler, returning the result
+// as the type its handler produces:
+//
+//	order, err := bus.Ask[*domain.Order](ctx, queryBus, queries.GetOrderQuery{ID: id})
+func Ask[R any, Q any](ctx context.Context, b *QueryBus, query Q) (R, error) {
+	var zero R
+
+	handler, ok := b.handlers[reflect.TypeOf(query)]
+	if !ok {
+		return zero, fmt.Errorf("no handler registered for %s", CommandName(query))
+	}
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](handler)
+	}
+
+	result, err := handler(ctx, query)
+	if err != nil || result == nil {
+		return zero, err
+	}
+
+	typed, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("unexpected result of type %T for %s", result, CommandName(query))
+	}
+	return typed, nil
+}
@@ -0,0 +1,239 @@
+package bus
+
+import (
+	"container/list"
+	"context"
+	"expvar"
+	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/database"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// cacheMetrics counts query cache hits and misses, published at /api/metrics
+var cacheMetrics = expvar.NewMap("queryCache")
+
+// QueryCache is an in-memory LRU cache of query results. Entries expire after a TTL and are
+// dropped earlier when a domain event changes what they were built from. Queries, together with
+// the tenant asking them unless shared, are the cache keys, so only comparable query types can be cached.
+type QueryCache struct {
+	mu         sync.Mutex
+	capacity   int
+	ttl        time.Duration
+	entries    map[interface{}]*list.Element
+	lru        *list.List // most recently used at the front
+	generation uint64     // incremented by every invalidation
+	shared     map[reflect.Type]bool
+}
+
+// tenantKey is the cache key of a query asked for a tenant, so that tenants never share results
+type tenantKey struct {
+	tenant string
+	query  interface{}
+}
+
+// ShareAcrossTenants makes the cache keep a single result for every tenant for the queries of the
+// same types as the given ones, which read data that belongs to no tenant
+func (c *QueryCache) ShareAcrossTenants(queries ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, query := range queries {
+		c.shared[reflect.TypeOf(query)] = true
+	}
+}
+
+// keyOf returns the cache key of a query asked for a tenant
+func (c *QueryCache) keyOf(tenant string, query interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shared[reflect.TypeOf(query)] {
+		return query
+	}
+	return tenantKey{tenant: tenant, query: query}
+}
+
+// queryOf returns the query a cache key stands for
+func queryOf(key interface{}) interface{} {
+	if key, ok := key.(tenantKey); ok {
+		return key.query
+	}
+	return key
+}
+
+type cacheEntry struct {
+	key       interface{}
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewQueryCache creates a cache holding at most capacity results for at most ttl
+func NewQueryCache(capacity int, ttl time.Duration) *QueryCache {
+	return &QueryCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[interface{}]*list.Element),
+		lru:      list.New(),
+		shared:   make(map[reflect.Type]bool),
+	}
+}
+
+// Get returns the cached result of a query, if it has one that has not expired
+func (c *QueryCache) Get(key interface{}) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set caches the result of a query, evicting the least recently used result when the cache is full
+func (c *QueryCache) Set(key, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// currentGeneration returns the generation to pass to setIfCurrent before a result is computed
+func (c *QueryCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// setIfCurrent caches a result unless an invalidation happened since generation was read,
+// in which case the result may have been computed from the state it invalidated
+func (c *QueryCache) setIfCurrent(key, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.set(key, value)
+	}
+}
+
+func (c *QueryCache) set(key, value interface{}) {
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate drops the cached results of the given queries
+func (c *QueryCache) Invalidate(keys ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+// InvalidateType drops the cached results of every query of the same type as query, for every tenant
+func (c *QueryCache) InvalidateType(query interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	queryType := reflect.TypeOf(query)
+	for key, element := range c.entries {
+		if reflect.TypeOf(queryOf(key)) == queryType {
+			c.remove(element)
+		}
+	}
+}
+
+// Len returns the number of cached results
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *QueryCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// Invalidation names the cached results a domain event makes stale. Stale lists single queries,
+// StaleTypes drops every cached query of the same type as one of its values.
+type Invalidation struct {
+	Stale      []interface{}
+	StaleTypes []interface{}
+}
+
+// InvalidateOn returns an event handler dropping the results an event makes stale for the tenant
+// storing it, or for every tenant when shared. Results are dropped once the transaction storing the event has committed, so that
+// a concurrent query cannot cache the old state again in between.
+func (c *QueryCache) InvalidateOn(stale func(event events.Event) Invalidation) event_store.EventHandler {
+	return event_store.EventHandlerFunc(func(ctx context.Context, event events.Event) error {
+		invalidation := stale(event)
+		tenant := requestctx.Tenant(ctx)
+		keys := make([]interface{}, len(invalidation.Stale))
+		for i, query := range invalidation.Stale {
+			keys[i] = c.keyOf(tenant, query)
+		}
+		database.AfterCommit(ctx, func() {
+			c.Invalidate(keys...)
+			for _, query := range invalidation.StaleTypes {
+				c.InvalidateType(query)
+			}
+		})
+		return nil
+	})
+}
+
+// CachingMiddleware answers queries of the given types from the results cached for the tenant of the
+// context, or for every tenant when shared, asking the handler only on a miss
+func CachingMiddleware(cache *QueryCache, cached ...interface{}) QueryMiddleware {
+	cachedTypes := make(map[reflect.Type]bool, len(cached))
+	for _, query := range cached {
+		cachedTypes[reflect.TypeOf(query)] = true
+	}
+
+	return func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query interface{}) (interface{}, error) {
+			if !cachedTypes[reflect.TypeOf(query)] {
+				return next(ctx, query)
+			}
+
+			key := cache.keyOf(requestctx.Tenant(ctx), query)
+			if result, ok := cache.Get(key); ok {
+				cacheMetrics.Add(CommandName(query)+".hits", 1)
+				return result, nil
+			}
+			cacheMetrics.Add(CommandName(query)+".misses", 1)
+
+			generation := cache.currentGeneration()
+			result, err := next(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			cache.setIfCurrent(key, result, generation)
+			return result, nil
+		}
+	}
+}
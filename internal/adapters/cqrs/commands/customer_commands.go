@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
 	"go-cqrs/internal/adapters/http/dto"
 	"go-cqrs/internal/application/ports"
 	"go-cqrs/internal/domain/events"
@@ -12,17 +13,30 @@ import (
 )
 
 type CustomerCommandHandler struct {
-	eventStore event_store.EventStore
-	useCase    ports.CustomerUseCase
+	eventStore      event_store.EventStore
+	orderEventStore event_store.EventStore
+	useCase         ports.CustomerUseCase
 }
 
-func NewCustomerCommandHandler(eventStore event_store.EventStore, useCase ports.CustomerUseCase) *CustomerCommandHandler {
-	return &CustomerCommandHandler{eventStore: eventStore, useCase: useCase}
+func NewCustomerCommandHandler(eventStore event_store.EventStore, orderEventStore event_store.EventStore, useCase ports.CustomerUseCase) *CustomerCommandHandler {
+	return &CustomerCommandHandler{eventStore: eventStore, orderEventStore: orderEventStore, useCase: useCase}
+}
+
+// Register registers the handlers of the commands on a command bus
+func (h *CustomerCommandHandler) Register(b *bus.CommandBus) {
+	bus.RegisterCommand(b, h.HandleCreateCustomerCommand)
+	bus.RegisterVoidCommand(b, h.HandleUpdateCustomerCommand)
+	bus.RegisterVoidCommand(b, h.HandleDeleteCustomerCommand)
+	bus.RegisterCommand(b, h.HandleMergeCustomersCommand)
+	bus.RegisterCommand(b, h.HandleAddCustomerAddressCommand)
+	bus.RegisterVoidCommand(b, h.HandleUpdateCustomerAddressCommand)
+	bus.RegisterVoidCommand(b, h.HandleRemoveCustomerAddressCommand)
 }
 
 type CreateCustomerCommand struct {
 	Name  string
 	Email string
+	Phone string
 }
 
 func (h *CustomerCommandHandler) HandleCreateCustomerCommand(ctx context.Context, cmd CreateCustomerCommand) (int, error) {
@@ -36,6 +50,7 @@ func (h *CustomerCommandHandler) HandleCreateCustomerCommand(ctx context.Context
 	request := dto.CreateCustomerRequest{
 		Name:  cmd.Name,
 		Email: cmd.Email,
+		Phone: cmd.Phone,
 	}
 
 	result, err := h.useCase.CreateCustomer(ctx, request)
@@ -48,6 +63,7 @@ func (h *CustomerCommandHandler) HandleCreateCustomerCommand(ctx context.Context
 		strconv.Itoa(result.ID),
 		result.Name,
 		result.Email,
+		result.Phone,
 	)
 	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
 		// Log the error but don't fail the operation
@@ -84,6 +100,7 @@ type UpdateCustomerCommand struct {
 	ID    int
 	Name  string
 	Email string
+	Phone string
 }
 
 func (h *CustomerCommandHandler) HandleUpdateCustomerCommand(ctx context.Context, cmd UpdateCustomerCommand) error {
@@ -101,6 +118,7 @@ func (h *CustomerCommandHandler) HandleUpdateCustomerCommand(ctx context.Context
 		ID:    cmd.ID,
 		Name:  cmd.Name,
 		Email: cmd.Email,
+		Phone: cmd.Phone,
 	}
 
 	err := h.useCase.UpdateCustomer(ctx, request)
@@ -108,11 +126,18 @@ func (h *CustomerCommandHandler) HandleUpdateCustomerCommand(ctx context.Context
 		return err
 	}
 
+	// Reload so the event carries the stored state, including a phone number that was kept
+	result, err := h.useCase.GetCustomer(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+
 	// Record customer updated event
 	event := events.NewCustomerUpdatedEvent(
-		strconv.Itoa(cmd.ID),
-		cmd.Name,
-		cmd.Email,
+		strconv.Itoa(result.ID),
+		result.Name,
+		result.Email,
+		result.Phone,
 	)
 	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
 		fmt.Printf("Warning: Failed to store customer updated event: %v\n", err)
@@ -120,3 +145,177 @@ func (h *CustomerCommandHandler) HandleUpdateCustomerCommand(ctx context.Context
 
 	return nil
 }
+
+type MergeCustomersCommand struct {
+	SurvivorID  int
+	DuplicateID int
+	DryRun      bool
+}
+
+func (h *CustomerCommandHandler) HandleMergeCustomersCommand(ctx context.Context, cmd MergeCustomersCommand) (*dto.MergeReportDTO, error) {
+	if cmd.SurvivorID <= 0 {
+		return nil, errors.New("invalid survivor customer ID")
+	}
+	if cmd.DuplicateID <= 0 {
+		return nil, errors.New("invalid duplicate customer ID")
+	}
+
+	request := dto.MergeCustomersRequest{
+		SurvivorID:  cmd.SurvivorID,
+		DuplicateID: cmd.DuplicateID,
+		DryRun:      cmd.DryRun,
+	}
+
+	report, err := h.useCase.MergeCustomers(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if report.DryRun {
+		return report, nil
+	}
+
+	// Record customer merged event
+	event := events.NewCustomerMergedEvent(
+		strconv.Itoa(report.DuplicateID),
+		strconv.Itoa(report.SurvivorID),
+		report.ReassignedOrderIDs,
+	)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store customer merged event: %v\n", err)
+	}
+
+	// Record the new customer of every reassigned order in the order's own history
+	for _, orderID := range report.ReassignedOrderIDs {
+		event := events.NewCustomerAssignedToOrderEvent(strconv.Itoa(orderID), strconv.Itoa(report.SurvivorID))
+		if err := h.orderEventStore.StoreEvent(ctx, event); err != nil {
+			fmt.Printf("Warning: Failed to store customer assigned event: %v\n", err)
+		}
+	}
+
+	return report, nil
+}
+
+// CustomerAddress describes an address in address commands
+type CustomerAddress struct {
+	Type       string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+	IsDefault  bool
+}
+
+type AddCustomerAddressCommand struct {
+	CustomerID int
+	CustomerAddress
+}
+
+func (h *CustomerCommandHandler) HandleAddCustomerAddressCommand(ctx context.Context, cmd AddCustomerAddressCommand) (int, error) {
+	if cmd.CustomerID <= 0 {
+		return 0, errors.New("invalid customer ID")
+	}
+	if cmd.Type == "" {
+		return 0, errors.New("type is required")
+	}
+
+	result, err := h.useCase.AddCustomerAddress(ctx, cmd.CustomerID, toAddressDTO(0, cmd.CustomerAddress))
+	if err != nil {
+		return 0, err
+	}
+
+	// Record address added event
+	event := events.NewCustomerAddressAddedEvent(strconv.Itoa(cmd.CustomerID), toEventAddress(*result))
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store customer address added event: %v\n", err)
+	}
+
+	return result.ID, nil
+}
+
+type UpdateCustomerAddressCommand struct {
+	CustomerID int
+	AddressID  int
+	CustomerAddress
+}
+
+func (h *CustomerCommandHandler) HandleUpdateCustomerAddressCommand(ctx context.Context, cmd UpdateCustomerAddressCommand) error {
+	if cmd.CustomerID <= 0 {
+		return errors.New("invalid customer ID")
+	}
+	if cmd.AddressID <= 0 {
+		return errors.New("invalid address ID")
+	}
+	if cmd.Type == "" {
+		return errors.New("type is required")
+	}
+
+	result, err := h.useCase.UpdateCustomerAddress(ctx, cmd.CustomerID, toAddressDTO(cmd.AddressID, cmd.CustomerAddress))
+	if err != nil {
+		return err
+	}
+
+	// Record address updated event
+	event := events.NewCustomerAddressUpdatedEvent(strconv.Itoa(cmd.CustomerID), toEventAddress(*result))
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store customer address updated event: %v\n", err)
+	}
+
+	return nil
+}
+
+type RemoveCustomerAddressCommand struct {
+	CustomerID int
+	AddressID  int
+}
+
+func (h *CustomerCommandHandler) HandleRemoveCustomerAddressCommand(ctx context.Context, cmd RemoveCustomerAddressCommand) error {
+	if cmd.CustomerID <= 0 {
+		return errors.New("invalid customer ID")
+	}
+	if cmd.AddressID <= 0 {
+		return errors.New("invalid address ID")
+	}
+
+	err := h.useCase.RemoveCustomerAddress(ctx, cmd.CustomerID, cmd.AddressID)
+	if err != nil {
+		return err
+	}
+
+	// Record address removed event
+	event := events.NewCustomerAddressRemovedEvent(strconv.Itoa(cmd.CustomerID), cmd.AddressID)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store customer address removed event: %v\n", err)
+	}
+
+	return nil
+}
+
+func toAddressDTO(id int, address CustomerAddress) dto.AddressDTO {
+	return dto.AddressDTO{
+		ID:         id,
+		Type:       address.Type,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		IsDefault:  address.IsDefault,
+	}
+}
+
+func toEventAddress(address dto.AddressDTO) events.CustomerAddress {
+	return events.CustomerAddress{
+		AddressID:  address.ID,
+		Type:       address.Type,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		IsDefault:  address.IsDefault,
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain/events"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+)
+
+type InventoryCommandHandler struct {
+	eventStore event_store.EventStore
+	useCase    ports.InventoryUseCase
+}
+
+func NewInventoryCommandHandler(eventStore event_store.EventStore, useCase ports.InventoryUseCase) *InventoryCommandHandler {
+	return &InventoryCommandHandler{eventStore: eventStore, useCase: useCase}
+}
+
+// Register registers the handlers of the commands on a command bus
+func (h *InventoryCommandHandler) Register(b *bus.CommandBus) {
+	bus.RegisterCommand(b, h.HandleSetStockLevelCommand)
+}
+
+type SetStockLevelCommand struct {
+	SKU    string
+	OnHand int
+}
+
+func (h *InventoryCommandHandler) HandleSetStockLevelCommand(ctx context.Context, cmd SetStockLevelCommand) (*dto.StockLevelDTO, error) {
+	if cmd.SKU == "" {
+		return nil, errors.New("sku is required")
+	}
+	if cmd.OnHand < 0 {
+		return nil, errors.New("onHand cannot be negative")
+	}
+
+	result, err := h.useCase.SetStockLevel(ctx, dto.SetStockLevelRequest{SKU: cmd.SKU, OnHand: cmd.OnHand})
+	if err != nil {
+		return nil, err
+	}
+
+	// Record stock level set event
+	event := events.NewStockLevelSetEvent(result.SKU, result.OnHand, result.Reserved)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store stock level set event: %v\n", err)
+	}
+
+	return result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
 	"go-cqrs/internal/adapters/http/dto"
 	"go-cqrs/internal/application/ports"
 	"go-cqrs/internal/domain/events"
@@ -20,24 +21,53 @@ func NewOrderCommandHandler(eventStore event_store.EventStore, useCase ports.Ord
 	return &OrderCommandHandler{eventStore: eventStore, useCase: useCase}
 }
 
+// Register registers the handlers of the commands on a command bus
+func (h *OrderCommandHandler) Register(b *bus.CommandBus) {
+	bus.RegisterCommand(b, h.HandleCreateOrderCommand)
+	bus.RegisterVoidCommand(b, h.HandleUpdateOrderCommand)
+	bus.RegisterVoidCommand(b, h.HandleDeleteOrderCommand)
+	bus.RegisterVoidCommand(b, h.HandleAssignCustomerCommand)
+	bus.RegisterVoidCommand(b, h.HandleAddOrderLineCommand)
+	bus.RegisterVoidCommand(b, h.HandleChangeOrderLineCommand)
+	bus.RegisterVoidCommand(b, h.HandleRemoveOrderLineCommand)
+}
+
+// OrderLine describes a line of an order in create and update commands.
+// UnitPrice is in minor units of the order currency.
+type OrderLine struct {
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+}
+
 type CreateOrderCommand struct {
-	CustomerID *int
-	Product    string
-	Quantity   int
+	CustomerID        *int
+	Product           string
+	Quantity          int
+	Currency          string
+	Lines             []OrderLine
+	ShippingAddressID *int
 }
 
 func (h *OrderCommandHandler) HandleCreateOrderCommand(ctx context.Context, cmd CreateOrderCommand) (int, error) {
-	if cmd.Product == "" {
-		return 0, errors.New("product is required")
-	}
-	if cmd.Quantity <= 0 {
-		return 0, errors.New("quantity must be greater than zero")
+	if len(cmd.Lines) == 0 {
+		if cmd.Product == "" {
+			return 0, errors.New("product is required")
+		}
+		if cmd.Quantity <= 0 {
+			return 0, errors.New("quantity must be greater than zero")
+		}
 	}
 
 	request := dto.CreateOrderRequest{
 		CustomerID: cmd.CustomerID,
 		Product:    cmd.Product,
 		Quantity:   cmd.Quantity,
+		Currency:   cmd.Currency,
+		Lines:      toOrderLineDTOs(cmd.Lines),
+
+		ShippingAddressID: cmd.ShippingAddressID,
 	}
 
 	result, err := h.useCase.CreateOrder(ctx, request)
@@ -50,6 +80,9 @@ func (h *OrderCommandHandler) HandleCreateOrderCommand(ctx context.Context, cmd
 		strconv.Itoa(result.ID),
 		result.Product,
 		result.Quantity,
+		result.Currency,
+		result.Total,
+		toEventLines(result.Lines),
 	)
 	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
 		// Log the error but don't fail the operation
@@ -87,17 +120,21 @@ type UpdateOrderCommand struct {
 	CustomerID *int
 	Product    string
 	Quantity   int
+	Currency   string
+	Lines      []OrderLine
 }
 
 func (h *OrderCommandHandler) HandleUpdateOrderCommand(ctx context.Context, cmd UpdateOrderCommand) error {
 	if cmd.ID <= 0 {
 		return errors.New("invalid order ID")
 	}
-	if cmd.Product == "" {
-		return errors.New("product is required")
-	}
-	if cmd.Quantity <= 0 {
-		return errors.New("quantity must be greater than zero")
+	if len(cmd.Lines) == 0 {
+		if cmd.Product == "" {
+			return errors.New("product is required")
+		}
+		if cmd.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
 	}
 
 	request := dto.UpdateOrderRequest{
@@ -105,6 +142,8 @@ func (h *OrderCommandHandler) HandleUpdateOrderCommand(ctx context.Context, cmd
 		CustomerID: cmd.CustomerID,
 		Product:    cmd.Product,
 		Quantity:   cmd.Quantity,
+		Currency:   cmd.Currency,
+		Lines:      toOrderLineDTOs(cmd.Lines),
 	}
 
 	err := h.useCase.UpdateOrder(ctx, request)
@@ -112,6 +151,12 @@ func (h *OrderCommandHandler) HandleUpdateOrderCommand(ctx context.Context, cmd
 		return err
 	}
 
+	// Reload the order so the event records the lines and totals as stored
+	updated, err := h.useCase.GetOrder(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+
 	// Record the order updated event
 	var customerIDStr *string
 	if cmd.CustomerID != nil {
@@ -121,8 +166,11 @@ func (h *OrderCommandHandler) HandleUpdateOrderCommand(ctx context.Context, cmd
 
 	event := events.NewOrderUpdatedEvent(
 		strconv.Itoa(cmd.ID),
-		cmd.Product,
-		cmd.Quantity,
+		updated.Product,
+		updated.Quantity,
+		updated.Currency,
+		updated.Total,
+		toEventLines(updated.Lines),
 		customerIDStr,
 	)
 	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
@@ -161,3 +209,179 @@ func (h *OrderCommandHandler) HandleAssignCustomerCommand(ctx context.Context, c
 
 	return nil
 }
+
+type AddOrderLineCommand struct {
+	OrderID     int
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+}
+
+func (h *OrderCommandHandler) HandleAddOrderLineCommand(ctx context.Context, cmd AddOrderLineCommand) error {
+	if cmd.OrderID <= 0 {
+		return errors.New("invalid order ID")
+	}
+	if cmd.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if cmd.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	line := OrderLine{SKU: cmd.SKU, Description: cmd.Description, Quantity: cmd.Quantity, UnitPrice: cmd.UnitPrice}
+	err := h.useCase.AddOrderLine(ctx, cmd.OrderID, toOrderLineDTO(line))
+	if err != nil {
+		return err
+	}
+
+	// Reload the order so the event records the line and total as stored
+	updated, err := h.useCase.GetOrder(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// Record the line added event
+	event := events.NewOrderLineAddedEvent(
+		strconv.Itoa(cmd.OrderID),
+		toEventLine(findOrderLine(updated.Lines, cmd.SKU)),
+		updated.Currency,
+		updated.Total,
+	)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store order line added event: %v\n", err)
+	}
+
+	return nil
+}
+
+type ChangeOrderLineCommand struct {
+	OrderID     int
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+}
+
+func (h *OrderCommandHandler) HandleChangeOrderLineCommand(ctx context.Context, cmd ChangeOrderLineCommand) error {
+	if cmd.OrderID <= 0 {
+		return errors.New("invalid order ID")
+	}
+	if cmd.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if cmd.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	line := OrderLine{SKU: cmd.SKU, Description: cmd.Description, Quantity: cmd.Quantity, UnitPrice: cmd.UnitPrice}
+	err := h.useCase.ChangeOrderLine(ctx, cmd.OrderID, toOrderLineDTO(line))
+	if err != nil {
+		return err
+	}
+
+	// Reload the order so the event records the line and total as stored
+	updated, err := h.useCase.GetOrder(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// Record the line changed event
+	event := events.NewOrderLineChangedEvent(
+		strconv.Itoa(cmd.OrderID),
+		toEventLine(findOrderLine(updated.Lines, cmd.SKU)),
+		updated.Currency,
+		updated.Total,
+	)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store order line changed event: %v\n", err)
+	}
+
+	return nil
+}
+
+type RemoveOrderLineCommand struct {
+	OrderID int
+	SKU     string
+}
+
+func (h *OrderCommandHandler) HandleRemoveOrderLineCommand(ctx context.Context, cmd RemoveOrderLineCommand) error {
+	if cmd.OrderID <= 0 {
+		return errors.New("invalid order ID")
+	}
+	if cmd.SKU == "" {
+		return errors.New("sku is required")
+	}
+
+	err := h.useCase.RemoveOrderLine(ctx, cmd.OrderID, cmd.SKU)
+	if err != nil {
+		return err
+	}
+
+	// Reload the order so the event records the remaining total
+	updated, err := h.useCase.GetOrder(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// Record the line removed event
+	event := events.NewOrderLineRemovedEvent(strconv.Itoa(cmd.OrderID), cmd.SKU, updated.Currency, updated.Total)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store order line removed event: %v\n", err)
+	}
+
+	return nil
+}
+
+// toOrderLineDTO converts a command line to its DTO
+func toOrderLineDTO(line OrderLine) dto.OrderLineDTO {
+	return dto.OrderLineDTO{
+		SKU:         line.SKU,
+		Description: line.Description,
+		Quantity:    line.Quantity,
+		UnitPrice:   line.UnitPrice,
+	}
+}
+
+// toOrderLineDTOs converts command lines to DTOs
+func toOrderLineDTOs(lines []OrderLine) []dto.OrderLineDTO {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	result := make([]dto.OrderLineDTO, len(lines))
+	for i, line := range lines {
+		result[i] = toOrderLineDTO(line)
+	}
+	return result
+}
+
+// findOrderLine returns the line with the given SKU
+func findOrderLine(lines []dto.OrderLineDTO, sku string) dto.OrderLineDTO {
+	for _, line := range lines {
+		if line.SKU == sku {
+			return line
+		}
+	}
+	return dto.OrderLineDTO{SKU: sku}
+}
+
+// toEventLine converts a line DTO to the form recorded in order events
+func toEventLine(line dto.OrderLineDTO) events.OrderLine {
+	return events.OrderLine{
+		SKU:         line.SKU,
+		Description: line.Description,
+		Quantity:    line.Quantity,
+		UnitPrice:   line.UnitPrice,
+		Subtotal:    line.Subtotal,
+	}
+}
+
+// toEventLines converts line DTOs to the form recorded in order events
+func toEventLines(lines []dto.OrderLineDTO) []events.OrderLine {
+	result := make([]events.OrderLine, len(lines))
+	for i, line := range lines {
+		result[i] = toEventLine(line)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain/events"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"strconv"
+)
+
+type ProductCommandHandler struct {
+	eventStore event_store.EventStore
+	useCase    ports.ProductUseCase
+}
+
+func NewProductCommandHandler(eventStore event_store.EventStore, useCase ports.ProductUseCase) *ProductCommandHandler {
+	return &ProductCommandHandler{eventStore: eventStore, useCase: useCase}
+}
+
+// Register registers the handlers of the commands on a command bus
+func (h *ProductCommandHandler) Register(b *bus.CommandBus) {
+	bus.RegisterCommand(b, h.HandleCreateProductCommand)
+	bus.RegisterVoidCommand(b, h.HandleUpdateProductCommand)
+	bus.RegisterVoidCommand(b, h.HandleDeactivateProductCommand)
+}
+
+type CreateProductCommand struct {
+	SKU      string
+	Name     string
+	Price    int64
+	Currency string
+}
+
+func (h *ProductCommandHandler) HandleCreateProductCommand(ctx context.Context, cmd CreateProductCommand) (int, error) {
+	if cmd.SKU == "" {
+		return 0, errors.New("sku is required")
+	}
+	if cmd.Name == "" {
+		return 0, errors.New("name is required")
+	}
+	if cmd.Currency == "" {
+		return 0, errors.New("currency is required")
+	}
+
+	request := dto.CreateProductRequest{
+		SKU:      cmd.SKU,
+		Name:     cmd.Name,
+		Price:    cmd.Price,
+		Currency: cmd.Currency,
+	}
+
+	result, err := h.useCase.CreateProduct(ctx, request)
+	if err != nil {
+		return 0, err
+	}
+
+	// Store event
+	event := events.NewProductCreatedEvent(
+		strconv.Itoa(result.ID),
+		result.SKU,
+		result.Name,
+		result.Price,
+		result.Currency,
+	)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		// Log the error but don't fail the operation
+		fmt.Printf("Warning: Failed to store product created event: %v\n", err)
+	}
+
+	return result.ID, nil
+}
+
+type UpdateProductCommand struct {
+	ID       int
+	Name     string
+	Price    int64
+	Currency string
+}
+
+func (h *ProductCommandHandler) HandleUpdateProductCommand(ctx context.Context, cmd UpdateProductCommand) error {
+	if cmd.ID <= 0 {
+		return errors.New("invalid product ID")
+	}
+	if cmd.Name == "" {
+		return errors.New("name is required")
+	}
+	if cmd.Currency == "" {
+		return errors.New("currency is required")
+	}
+
+	request := dto.UpdateProductRequest{
+		ID:       cmd.ID,
+		Name:     cmd.Name,
+		Price:    cmd.Price,
+		Currency: cmd.Currency,
+	}
+
+	result, err := h.useCase.UpdateProduct(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// Record product updated event
+	event := events.NewProductUpdatedEvent(
+		strconv.Itoa(result.ID),
+		result.Name,
+		result.Price,
+		result.Currency,
+	)
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store product updated event: %v\n", err)
+	}
+
+	return nil
+}
+
+type DeactivateProductCommand struct {
+	ID int
+}
+
+func (h *ProductCommandHandler) HandleDeactivateProductCommand(ctx context.Context, cmd DeactivateProductCommand) error {
+	if cmd.ID <= 0 {
+		return errors.New("invalid product ID")
+	}
+
+	err := h.useCase.DeactivateProduct(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+
+	// Record product deactivated event
+	event := events.NewProductDeactivatedEvent(strconv.Itoa(cmd.ID))
+	if err := h.eventStore.StoreEvent(ctx, event); err != nil {
+		fmt.Printf("Warning: Failed to store product deactivated event: %v\n", err)
+	}
+
+	return nil
+}
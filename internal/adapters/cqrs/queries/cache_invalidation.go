@@ -0,0 +1,50 @@
+package queries
+
+import (
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/domain/events"
+	"strconv"
+	"strings"
+)
+
+// CachedQueries are the queries whose results the query bus caches
+var CachedQueries = []interface{}{GetOrderQuery{}, GetCustomerQuery{}, GetProductQuery{}}
+
+// SharedQueries are the cached queries of the product catalog, which every tenant shares
+var SharedQueries = []interface{}{GetProductQuery{}}
+
+// StaleQueries names the cached query results a domain event makes stale
+func StaleQueries(event events.Event) bus.Invalidation {
+	id, err := strconv.Atoi(event.AggregateID())
+	if err != nil {
+		return bus.Invalidation{}
+	}
+
+	switch e := event.(type) {
+	case *events.CustomerDeletedEvent:
+		// The orders of a deleted customer lose their customer
+		return bus.Invalidation{
+			Stale:      []interface{}{GetCustomerQuery{ID: id}},
+			StaleTypes: []interface{}{GetOrderQuery{}},
+		}
+	case *events.CustomerMergedEvent:
+		stale := []interface{}{GetCustomerQuery{ID: id}}
+		if survivorID, err := strconv.Atoi(e.SurvivorID); err == nil {
+			stale = append(stale, GetCustomerQuery{ID: survivorID})
+		}
+		for _, orderID := range e.ReassignedOrderIDs {
+			stale = append(stale, GetOrderQuery{ID: orderID})
+		}
+		return bus.Invalidation{Stale: stale}
+	}
+
+	switch {
+	case strings.HasPrefix(event.EventType(), "order."):
+		return bus.Invalidation{Stale: []interface{}{GetOrderQuery{ID: id}}}
+	case strings.HasPrefix(event.EventType(), "customer."):
+		return bus.Invalidation{Stale: []interface{}{GetCustomerQuery{ID: id}}}
+	case strings.HasPrefix(event.EventType(), "product."):
+		return bus.Invalidation{Stale: []interface{}{GetProductQuery{ID: id}}}
+	}
+	return bus.Invalidation{}
+}
@@ -0,0 +1,95 @@
+package queries
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// CustomerOrderQueryHandler answers queries about the orders of a customer
+type CustomerOrderQueryHandler struct {
+	customerRepo ports.CustomerRepository
+	orderRepo    ports.OrderRepository
+}
+
+func NewCustomerOrderQueryHandler(customerRepo ports.CustomerRepository, orderRepo ports.OrderRepository) *CustomerOrderQueryHandler {
+	return &CustomerOrderQueryHandler{customerRepo: customerRepo, orderRepo: orderRepo}
+}
+
+// Register registers the customer order query handlers with a query bus
+func (h *CustomerOrderQueryHandler) Register(b *bus.QueryBus) {
+	bus.RegisterQuery(b, h.HandleListCustomerOrdersQuery)
+	bus.RegisterQuery(b, h.HandleGetCustomerSummaryQuery)
+}
+
+// ListCustomerOrdersQuery asks for a page of the orders of a customer, newest first.
+// The customer ID of the filter is ignored in favour of CustomerID.
+type ListCustomerOrdersQuery struct {
+	CustomerID int
+	Filter     ports.OrderFilter
+	Limit      int
+	Offset     int
+}
+
+// CustomerOrdersPage is a page of the orders of a customer with the number of orders matching the filter
+type CustomerOrdersPage struct {
+	Orders []dto.OrderDTO
+	Total  int
+}
+
+func (h *CustomerOrderQueryHandler) HandleListCustomerOrdersQuery(ctx context.Context, query ListCustomerOrdersQuery) (*CustomerOrdersPage, error) {
+	if err := h.checkCustomer(ctx, query.CustomerID); err != nil {
+		return nil, err
+	}
+
+	filter := query.Filter
+	filter.CustomerID = &query.CustomerID
+
+	orders, total, err := h.orderRepo.Find(ctx, filter, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CustomerOrdersPage{Orders: make([]dto.OrderDTO, len(orders)), Total: total}
+	for i, order := range orders {
+		page.Orders[i] = dto.ToOrderDTO(order)
+	}
+	return page, nil
+}
+
+type GetCustomerSummaryQuery struct {
+	CustomerID int
+}
+
+func (h *CustomerOrderQueryHandler) HandleGetCustomerSummaryQuery(ctx context.Context, query GetCustomerSummaryQuery) (*dto.CustomerSummaryDTO, error) {
+	if err := h.checkCustomer(ctx, query.CustomerID); err != nil {
+		return nil, err
+	}
+
+	summary, err := h.orderRepo.SummarizeByCustomer(ctx, query.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CustomerSummaryDTO{
+		CustomerID:    query.CustomerID,
+		OrderCount:    summary.OrderCount,
+		TotalQuantity: summary.TotalQuantity,
+		FirstOrderAt:  summary.FirstOrderAt,
+		LastOrderAt:   summary.LastOrderAt,
+	}, nil
+}
+
+// checkCustomer returns a not found error unless the customer exists
+func (h *CustomerOrderQueryHandler) checkCustomer(ctx context.Context, customerID int) error {
+	customer, err := h.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return domainerrors.NewNotFoundError("customer", customerID)
+	}
+	return nil
+}
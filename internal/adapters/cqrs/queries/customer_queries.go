@@ -2,29 +2,107 @@ package queries
 
 import (
 	"context"
-	"errors"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
 	"go-cqrs/internal/application/ports"
+	domainerrors "go-cqrs/internal/domain/errors"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"strconv"
 )
 
 type CustomerQueryHandler struct {
 	customerRepo ports.CustomerRepository
+	eventStore   event_store.EventStore
 }
 
-func NewCustomerQueryHandler(customerRepo ports.CustomerRepository) *CustomerQueryHandler {
-	return &CustomerQueryHandler{customerRepo: customerRepo}
+func NewCustomerQueryHandler(customerRepo ports.CustomerRepository, eventStore event_store.EventStore) *CustomerQueryHandler {
+	return &CustomerQueryHandler{customerRepo: customerRepo, eventStore: eventStore}
+}
+
+// Register registers the customer query handlers with a query bus
+func (h *CustomerQueryHandler) Register(b *bus.QueryBus) {
+	bus.RegisterQuery(b, h.HandleGetCustomerQuery)
+	bus.RegisterQuery(b, h.HandleGetCustomerHistoryQuery)
+	bus.RegisterQuery(b, h.HandleGetCustomersQuery)
+	bus.RegisterQuery(b, h.HandleListCustomersQuery)
 }
 
 type GetCustomerQuery struct {
 	ID int
 }
 
-func (h *CustomerQueryHandler) HandleGetCustomerQuery(ctx context.Context, query GetCustomerQuery) (interface{}, error) {
+func (h *CustomerQueryHandler) HandleGetCustomerQuery(ctx context.Context, query GetCustomerQuery) (*dto.CustomerDTO, error) {
 	customer, err := h.customerRepo.GetByID(ctx, query.ID)
 	if err != nil {
 		return nil, err
 	}
 	if customer == nil {
-		return nil, errors.New("customer not found")
+		return nil, domainerrors.NewNotFoundError("customer", query.ID)
+	}
+
+	customerDTO := dto.ToCustomerDTO(*customer)
+	return &customerDTO, nil
+}
+
+// GetCustomersQuery asks for several customers at once, e.g. to resolve the customers of a list of orders.
+// Customers that do not exist are left out of the result.
+type GetCustomersQuery struct {
+	IDs []int
+}
+
+func (h *CustomerQueryHandler) HandleGetCustomersQuery(ctx context.Context, query GetCustomersQuery) ([]dto.CustomerDTO, error) {
+	customers, err := h.customerRepo.GetByIDs(ctx, query.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	customerDTOs := make([]dto.CustomerDTO, len(customers))
+	for i, customer := range customers {
+		customerDTOs[i] = dto.ToCustomerDTO(customer)
 	}
-	return customer, nil
+	return customerDTOs, nil
+}
+
+// ListCustomersQuery asks for a page of the active customers in ID order
+type ListCustomersQuery struct {
+	Limit  int
+	Offset int
+}
+
+func (h *CustomerQueryHandler) HandleListCustomersQuery(ctx context.Context, query ListCustomersQuery) ([]dto.CustomerDTO, error) {
+	customers, err := h.customerRepo.List(ctx, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	customerDTOs := make([]dto.CustomerDTO, len(customers))
+	for i, customer := range customers {
+		customerDTOs[i] = dto.ToCustomerDTO(customer)
+	}
+	return customerDTOs, nil
+}
+
+type GetCustomerHistoryQuery struct {
+	ID int
+}
+
+func (h *CustomerQueryHandler) HandleGetCustomerHistoryQuery(ctx context.Context, query GetCustomerHistoryQuery) (*dto.HistoryDTO, error) {
+	records, err := h.eventStore.GetAggregateEvents(ctx, strconv.Itoa(query.ID))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, domainerrors.NewNotFoundError("customer", query.ID)
+	}
+
+	entries, err := buildHistory(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.HistoryDTO{
+		EntityType: "customer",
+		EntityID:   query.ID,
+		Entries:    entries,
+	}, nil
 }
@@ -0,0 +1,190 @@
+package queries
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/domain/events"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// entityState is the field-level state of an entity rebuilt from its events
+type entityState map[string]interface{}
+
+// buildHistory replays the stored events of one entity and returns an entry per event
+// with the fields that changed compared to the state before that event
+func buildHistory(records []event_store.StoredEvent) ([]dto.HistoryEntryDTO, error) {
+	entries := make([]dto.HistoryEntryDTO, 0, len(records))
+	state := entityState{}
+
+	for _, record := range records {
+		var data map[string]interface{}
+		if err := json.Unmarshal(record.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode event %d: %w", record.ID, err)
+		}
+
+		next := applyEvent(state, record.EventType, data)
+		entries = append(entries, dto.HistoryEntryDTO{
+			EventID:    record.ID,
+			EventType:  record.EventType,
+			Actor:      record.Actor,
+			RequestID:  record.RequestID,
+			OccurredAt: record.OccurredAt,
+			Changes:    diffStates(state, next),
+		})
+		state = next
+	}
+
+	return entries, nil
+}
+
+// applyEvent returns the state that results from applying an event's data to state
+func applyEvent(state entityState, eventType string, data map[string]interface{}) entityState {
+	next := make(entityState, len(state)+len(data))
+	for field, value := range state {
+		next[field] = value
+	}
+
+	switch eventType {
+	case events.CustomerDeletedEventType, events.OrderDeletedEventType:
+		return entityState{}
+	case events.OrderLineAddedEventType, events.OrderLineChangedEventType, events.OrderLineRemovedEventType:
+		next["lines"] = applyLineEvent(state["lines"], eventType, data)
+		if total, ok := data["OrderTotal"]; ok {
+			next["total"] = total
+		}
+		return next
+	case events.CustomerAddressAddedEventType, events.CustomerAddressUpdatedEventType, events.CustomerAddressRemovedEventType:
+		next["addresses"] = applyAddressEvent(state["addresses"], eventType, data)
+		return next
+	}
+
+	for field, value := range data {
+		if isEventMetadata(field) {
+			continue
+		}
+		next[historyFieldName(field)] = value
+	}
+	return next
+}
+
+// collectionChange tells how an event changes one item of a collection such as the lines of an order
+type collectionChange int
+
+const (
+	itemAdded collectionChange = iota
+	itemChanged
+	itemRemoved
+)
+
+// applyLineEvent returns the lines that result from adding, changing or removing the line named in data
+func applyLineEvent(current interface{}, eventType string, data map[string]interface{}) []interface{} {
+	change := itemChanged
+	switch eventType {
+	case events.OrderLineAddedEventType:
+		change = itemAdded
+	case events.OrderLineRemovedEventType:
+		change = itemRemoved
+	}
+	return applyCollectionEvent(current, "SKU", change, data, "Currency", "OrderTotal")
+}
+
+// applyAddressEvent returns the addresses that result from adding, changing or removing the address named in data
+func applyAddressEvent(current interface{}, eventType string, data map[string]interface{}) []interface{} {
+	change := itemChanged
+	switch eventType {
+	case events.CustomerAddressAddedEventType:
+		change = itemAdded
+	case events.CustomerAddressRemovedEventType:
+		change = itemRemoved
+	}
+	return applyCollectionEvent(current, "AddressID", change, data, "CustomerID")
+}
+
+// applyCollectionEvent returns the items that result from applying a change to the item whose key
+// field matches data. Fields listed in skip describe the parent entity rather than the item.
+func applyCollectionEvent(current interface{}, key string, change collectionChange, data map[string]interface{}, skip ...string) []interface{} {
+	existing, _ := current.([]interface{})
+	item := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		if !isEventMetadata(field) && !containsField(skip, field) {
+			item[field] = value
+		}
+	}
+
+	items := make([]interface{}, 0, len(existing)+1)
+	replaced := false
+	for _, entry := range existing {
+		if fields, ok := entry.(map[string]interface{}); ok && fields[key] == data[key] {
+			if change == itemChanged {
+				items = append(items, item)
+				replaced = true
+			}
+			continue
+		}
+		items = append(items, entry)
+	}
+
+	if change == itemAdded || (change == itemChanged && !replaced) {
+		items = append(items, item)
+	}
+	return items
+}
+
+func containsField(fields []string, field string) bool {
+	for _, candidate := range fields {
+		if candidate == field {
+			return true
+		}
+	}
+	return false
+}
+
+// diffStates lists the fields whose values differ between two states, sorted by field name
+func diffStates(before, after entityState) []dto.FieldChangeDTO {
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	changes := make([]dto.FieldChangeDTO, 0)
+	for _, field := range names {
+		if reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		changes = append(changes, dto.FieldChangeDTO{
+			Field: field,
+			From:  before[field],
+			To:    after[field],
+		})
+	}
+	return changes
+}
+
+// isEventMetadata reports whether an event field identifies the event rather than describing the entity
+func isEventMetadata(field string) bool {
+	return field == "ID" || field == "OrderID" || strings.HasSuffix(field, "At")
+}
+
+// historyFieldName converts a Go event field name such as CustomerID to its API form customerId
+func historyFieldName(field string) string {
+	if strings.HasSuffix(field, "ID") {
+		field = strings.TrimSuffix(field, "ID") + "Id"
+	}
+	runes := []rune(field)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,29 @@
+package queries
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+)
+
+type InventoryQueryHandler struct {
+	useCase ports.InventoryUseCase
+}
+
+func NewInventoryQueryHandler(useCase ports.InventoryUseCase) *InventoryQueryHandler {
+	return &InventoryQueryHandler{useCase: useCase}
+}
+
+// Register registers the inventory query handlers with a query bus
+func (h *InventoryQueryHandler) Register(b *bus.QueryBus) {
+	bus.RegisterQuery(b, h.HandleGetStockLevelQuery)
+}
+
+type GetStockLevelQuery struct {
+	SKU string
+}
+
+func (h *InventoryQueryHandler) HandleGetStockLevelQuery(ctx context.Context, query GetStockLevelQuery) (*dto.StockLevelDTO, error) {
+	return h.useCase.GetStockLevel(ctx, query.SKU)
+}
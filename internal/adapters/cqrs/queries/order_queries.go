@@ -2,29 +2,104 @@ package queries
 
 import (
 	"context"
-	"errors"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
 	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"strconv"
 )
 
 type OrderQueryHandler struct {
-	orderRepo ports.OrderRepository
+	orderRepo  ports.OrderRepository
+	eventStore event_store.EventStore
 }
 
-func NewOrderQueryHandler(orderRepo ports.OrderRepository) *OrderQueryHandler {
-	return &OrderQueryHandler{orderRepo: orderRepo}
+func NewOrderQueryHandler(orderRepo ports.OrderRepository, eventStore event_store.EventStore) *OrderQueryHandler {
+	return &OrderQueryHandler{orderRepo: orderRepo, eventStore: eventStore}
+}
+
+// Register registers the order query handlers with a query bus
+func (h *OrderQueryHandler) Register(b *bus.QueryBus) {
+	bus.RegisterQuery(b, h.HandleGetOrderQuery)
+	bus.RegisterQuery(b, h.HandleGetOrderHistoryQuery)
+	bus.RegisterQuery(b, h.HandleGetOrdersQuery)
+	bus.RegisterQuery(b, h.HandleGetOrdersByCustomersQuery)
 }
 
 type GetOrderQuery struct {
 	ID int
 }
 
-func (h *OrderQueryHandler) HandleGetOrderQuery(ctx context.Context, query GetOrderQuery) (interface{}, error) {
+func (h *OrderQueryHandler) HandleGetOrderQuery(ctx context.Context, query GetOrderQuery) (*dto.OrderDTO, error) {
 	order, err := h.orderRepo.GetByID(ctx, query.ID)
 	if err != nil {
 		return nil, err
 	}
 	if order == nil {
-		return nil, errors.New("order not found")
+		return nil, domainerrors.NewNotFoundError("order", query.ID)
+	}
+
+	orderDTO := dto.ToOrderDTO(*order)
+	return &orderDTO, nil
+}
+
+// GetOrdersQuery asks for several orders at once. Orders that do not exist are left out of the result.
+type GetOrdersQuery struct {
+	IDs []int
+}
+
+func (h *OrderQueryHandler) HandleGetOrdersQuery(ctx context.Context, query GetOrdersQuery) ([]dto.OrderDTO, error) {
+	orders, err := h.orderRepo.GetByIDs(ctx, query.IDs)
+	if err != nil {
+		return nil, err
+	}
+	return toOrderDTOs(orders), nil
+}
+
+// GetOrdersByCustomersQuery asks for the orders of several customers at once, newest first
+type GetOrdersByCustomersQuery struct {
+	CustomerIDs []int
+}
+
+func (h *OrderQueryHandler) HandleGetOrdersByCustomersQuery(ctx context.Context, query GetOrdersByCustomersQuery) ([]dto.OrderDTO, error) {
+	orders, err := h.orderRepo.GetByCustomerIDs(ctx, query.CustomerIDs)
+	if err != nil {
+		return nil, err
 	}
-	return order, nil
+	return toOrderDTOs(orders), nil
+}
+
+func toOrderDTOs(orders []domain.Order) []dto.OrderDTO {
+	orderDTOs := make([]dto.OrderDTO, len(orders))
+	for i, order := range orders {
+		orderDTOs[i] = dto.ToOrderDTO(order)
+	}
+	return orderDTOs
+}
+
+type GetOrderHistoryQuery struct {
+	ID int
+}
+
+func (h *OrderQueryHandler) HandleGetOrderHistoryQuery(ctx context.Context, query GetOrderHistoryQuery) (*dto.HistoryDTO, error) {
+	records, err := h.eventStore.GetAggregateEvents(ctx, strconv.Itoa(query.ID))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, domainerrors.NewNotFoundError("order", query.ID)
+	}
+
+	entries, err := buildHistory(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.HistoryDTO{
+		EntityType: "order",
+		EntityID:   query.ID,
+		Entries:    entries,
+	}, nil
 }
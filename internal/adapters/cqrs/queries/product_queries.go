@@ -0,0 +1,58 @@
+package queries
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+type ProductQueryHandler struct {
+	productRepo ports.ProductRepository
+}
+
+func NewProductQueryHandler(productRepo ports.ProductRepository) *ProductQueryHandler {
+	return &ProductQueryHandler{productRepo: productRepo}
+}
+
+// Register registers the product query handlers with a query bus
+func (h *ProductQueryHandler) Register(b *bus.QueryBus) {
+	bus.RegisterQuery(b, h.HandleGetProductQuery)
+	bus.RegisterQuery(b, h.HandleListProductsQuery)
+}
+
+type GetProductQuery struct {
+	ID int
+}
+
+func (h *ProductQueryHandler) HandleGetProductQuery(ctx context.Context, query GetProductQuery) (*dto.ProductDTO, error) {
+	product, err := h.productRepo.GetByID(ctx, query.ID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, domainerrors.NewNotFoundError("product", query.ID)
+	}
+
+	productDTO := dto.ToProductDTO(*product)
+	return &productDTO, nil
+}
+
+type ListProductsQuery struct {
+	Limit  int
+	Offset int
+}
+
+func (h *ProductQueryHandler) HandleListProductsQuery(ctx context.Context, query ListProductsQuery) ([]dto.ProductDTO, error) {
+	products, err := h.productRepo.List(ctx, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ProductDTO, len(products))
+	for i, product := range products {
+		result[i] = dto.ToProductDTO(product)
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package exports
+
+import (
+	"context"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// customerColumns are the columns of flat customer exports
+var customerColumns = []Column{
+	{Name: "id", Type: Int64Column},
+	{Name: "name", Type: StringColumn},
+	{Name: "email", Type: StringColumn},
+	{Name: "phone", Type: StringColumn, Optional: true},
+	{Name: "mergedInto", Type: Int64Column, Optional: true},
+	{Name: "addressCount", Type: Int64Column},
+}
+
+// orderColumns are the columns of flat order exports
+var orderColumns = []Column{
+	{Name: "id", Type: Int64Column},
+	{Name: "customerId", Type: Int64Column, Optional: true},
+	{Name: "product", Type: StringColumn},
+	{Name: "quantity", Type: Int64Column},
+	{Name: "currency", Type: StringColumn},
+	{Name: "total", Type: Int64Column},
+	{Name: "lineCount", Type: Int64Column},
+	{Name: "shippingCountry", Type: StringColumn, Optional: true},
+}
+
+// Exporter writes every customer or order matching a filter in one of the export formats.
+// Each export reads from a single database snapshot, so rows changed while it runs do not
+// leave it half old and half new.
+type Exporter struct {
+	customerRepo ports.CustomerRepository
+	orderRepo    ports.OrderRepository
+	snapshots    ports.SnapshotManager
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(customerRepo ports.CustomerRepository, orderRepo ports.OrderRepository, snapshots ports.SnapshotManager) *Exporter {
+	return &Exporter{customerRepo: customerRepo, orderRepo: orderRepo, snapshots: snapshots}
+}
+
+// ExportCustomers writes the customers matching the filter and returns how many were written
+func (e *Exporter) ExportCustomers(ctx context.Context, w io.Writer, format Format, filter ports.CustomerFilter) (int, error) {
+	return e.export(ctx, w, format, customerColumns, func(ctx context.Context, write func(Record) error) error {
+		return e.customerRepo.Stream(ctx, filter, func(customer domain.Customer) error {
+			return write(customerRecord(customer))
+		})
+	})
+}
+
+// ExportOrders writes the orders matching the filter and returns how many were written
+func (e *Exporter) ExportOrders(ctx context.Context, w io.Writer, format Format, filter ports.OrderFilter) (int, error) {
+	return e.export(ctx, w, format, orderColumns, func(ctx context.Context, write func(Record) error) error {
+		return e.orderRepo.Stream(ctx, filter, func(order domain.Order) error {
+			return write(orderRecord(order))
+		})
+	})
+}
+
+// export streams the records produced by stream into a writer for the format, within a snapshot
+func (e *Exporter) export(ctx context.Context, w io.Writer, format Format, columns []Column, stream func(ctx context.Context, write func(Record) error) error) (int, error) {
+	writer, err := NewRecordWriter(format, w, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = e.snapshots.WithinSnapshot(ctx, func(ctx context.Context) error {
+		return stream(ctx, func(record Record) error {
+			count++
+			return writer.Write(record)
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
+
+func customerRecord(customer domain.Customer) Record {
+	return Record{
+		Values: []interface{}{
+			int64(customer.ID),
+			customer.Name,
+			customer.Email.String(),
+			optionalString(customer.Phone),
+			optionalID(customer.MergedInto),
+			int64(len(customer.Addresses)),
+		},
+		Document: dto.ToCustomerDTO(customer),
+	}
+}
+
+func orderRecord(order domain.Order) Record {
+	var shippingCountry interface{}
+	if order.ShippingAddress != nil {
+		shippingCountry = order.ShippingAddress.Country
+	}
+
+	return Record{
+		Values: []interface{}{
+			int64(order.ID),
+			optionalID(order.CustomerID),
+			order.Product,
+			int64(order.Quantity),
+			order.Currency,
+			order.Total().Amount,
+			int64(len(order.Lines)),
+			shippingCountry,
+		},
+		Document: dto.ToOrderDTO(order),
+	}
+}
+
+func optionalString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func optionalID(id *int) interface{} {
+	if id == nil {
+		return nil
+	}
+	return int64(*id)
+}
+
+// ParseCustomerFilter reads a customer filter from the createdFrom, createdTo and includeMerged parameters
+func ParseCustomerFilter(values url.Values) (ports.CustomerFilter, error) {
+	var filter ports.CustomerFilter
+	var err error
+
+	if filter.CreatedFrom, err = optionalTime(values, "createdFrom"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = optionalTime(values, "createdTo"); err != nil {
+		return filter, err
+	}
+	if value := values.Get("includeMerged"); value != "" {
+		if filter.IncludeMerged, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("invalid includeMerged value %q", value)
+		}
+	}
+
+	return filter, nil
+}
+
+// ParseOrderFilter reads an order filter from the customerId, product, currency, createdFrom and createdTo parameters
+func ParseOrderFilter(values url.Values) (ports.OrderFilter, error) {
+	filter := ports.OrderFilter{
+		Product:  values.Get("product"),
+		Currency: values.Get("currency"),
+	}
+	var err error
+
+	if value := values.Get("customerId"); value != "" {
+		customerID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid customerId value %q", value)
+		}
+		filter.CustomerID = &customerID
+	}
+	if filter.CreatedFrom, err = optionalTime(values, "createdFrom"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = optionalTime(values, "createdTo"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// optionalTime parses an RFC 3339 timestamp or date parameter, returning nil when it is missing
+func optionalTime(values url.Values, name string) (*time.Time, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s value %q, expected an RFC 3339 timestamp or a date", name, value)
+}
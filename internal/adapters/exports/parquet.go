@@ -0,0 +1,296 @@
+package exports
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is written
+const parquetRowGroupSize = 10000
+
+// Parquet enum values, see parquet.thrift
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedUTF8 = 0
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecUncompressed = 0
+	parquetPageData          = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetWriter writes records as an uncompressed Parquet file with one plain data page per column chunk.
+// Rows are buffered per row group, so memory use does not grow with the size of the export.
+type parquetWriter struct {
+	w         io.Writer
+	columns   []Column
+	offset    int64
+	started   bool
+	numRows   int64
+	groupRows int
+	chunks    []parquetChunk
+	groups    []parquetRowGroup
+}
+
+// parquetChunk buffers the values of one column for the current row group
+type parquetChunk struct {
+	values []byte
+	levels []byte // definition level of every row, 1 when the value is present
+}
+
+// parquetRowGroup records where the column chunks of a written row group are
+type parquetRowGroup struct {
+	numRows   int64
+	totalSize int64
+	chunks    []parquetChunkMeta
+}
+
+type parquetChunkMeta struct {
+	offset int64
+	size   int64
+}
+
+func newParquetWriter(w io.Writer, columns []Column) *parquetWriter {
+	return &parquetWriter{w: w, columns: columns, chunks: make([]parquetChunk, len(columns))}
+}
+
+func (p *parquetWriter) Write(record Record) error {
+	if len(record.Values) != len(p.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(p.columns), len(record.Values))
+	}
+
+	// Check the whole record first so that a bad value leaves no partial row behind
+	for i, column := range p.columns {
+		if err := column.check(record.Values[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, column := range p.columns {
+		chunk := &p.chunks[i]
+
+		switch value := record.Values[i].(type) {
+		case nil:
+			chunk.levels = append(chunk.levels, 0)
+			continue
+		case int64:
+			chunk.values = binary.LittleEndian.AppendUint64(chunk.values, uint64(value))
+		case string:
+			chunk.values = binary.LittleEndian.AppendUint32(chunk.values, uint32(len(value)))
+			chunk.values = append(chunk.values, value...)
+		}
+		if column.Optional {
+			chunk.levels = append(chunk.levels, 1)
+		}
+	}
+
+	p.groupRows++
+	if p.groupRows >= parquetRowGroupSize {
+		return p.flushRowGroup()
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.flushRowGroup(); err != nil {
+		return err
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+
+	footer := p.fileMetadata()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	return p.write(append(footer, parquetMagic...))
+}
+
+// start writes the leading magic bytes
+func (p *parquetWriter) start() error {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	return p.write(parquetMagic)
+}
+
+func (p *parquetWriter) write(data []byte) error {
+	n, err := p.w.Write(data)
+	p.offset += int64(n)
+	return err
+}
+
+// flushRowGroup writes the buffered rows as a row group with one column chunk per column
+func (p *parquetWriter) flushRowGroup() error {
+	if p.groupRows == 0 {
+		return nil
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+
+	group := parquetRowGroup{numRows: int64(p.groupRows)}
+	for i, column := range p.columns {
+		chunk := &p.chunks[i]
+
+		// Optional columns start the page with their definition levels; required ones have none
+		var page []byte
+		if column.Optional {
+			levels := encodeLevels(chunk.levels)
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+			page = append(page, levels...)
+		}
+		page = append(page, chunk.values...)
+
+		header := pageHeader(len(page), p.groupRows)
+		meta := parquetChunkMeta{offset: p.offset, size: int64(len(header) + len(page))}
+
+		if err := p.write(header); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+
+		group.chunks = append(group.chunks, meta)
+		group.totalSize += meta.size
+
+		chunk.values = chunk.values[:0]
+		chunk.levels = chunk.levels[:0]
+	}
+
+	p.groups = append(p.groups, group)
+	p.numRows += int64(p.groupRows)
+	p.groupRows = 0
+	return nil
+}
+
+// encodeLevels encodes definition levels with the RLE/bit-packing hybrid, using RLE runs only
+func encodeLevels(levels []byte) []byte {
+	var out []byte
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		out = binary.AppendUvarint(out, uint64(end-start)<<1)
+		out = append(out, levels[start])
+		start = end
+	}
+	return out
+}
+
+// pageHeader encodes the header of a plain, uncompressed data page
+func pageHeader(size, numValues int) []byte {
+	w := &compactWriter{}
+	w.beginStruct(0)
+	w.i32(1, parquetPageData)
+	w.i32(2, int32(size))
+	w.i32(3, int32(size))
+	w.beginStruct(5)
+	w.i32(1, int32(numValues))
+	w.i32(2, parquetEncodingPlain)
+	w.i32(3, parquetEncodingRLE)
+	w.i32(4, parquetEncodingRLE)
+	w.endStruct()
+	w.endStruct()
+	return w.buf
+}
+
+// fileMetadata encodes the footer describing the schema and the written row groups
+func (p *parquetWriter) fileMetadata() []byte {
+	w := &compactWriter{}
+	w.beginStruct(0)
+	w.i32(1, 1)
+
+	// The schema is a root element followed by one element per column
+	w.listHeader(2, thriftStruct, len(p.columns)+1)
+	w.beginStruct(0)
+	w.string(4, "schema")
+	w.i32(5, int32(len(p.columns)))
+	w.endStruct()
+	for _, column := range p.columns {
+		w.beginStruct(0)
+		w.i32(1, column.parquetType())
+		w.i32(3, column.repetition())
+		w.string(4, column.Name)
+		if column.Type == StringColumn {
+			w.i32(6, parquetConvertedUTF8)
+		}
+		w.endStruct()
+	}
+
+	w.i64(3, p.numRows)
+
+	w.listHeader(4, thriftStruct, len(p.groups))
+	for _, group := range p.groups {
+		w.beginStruct(0)
+		w.listHeader(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			column := p.columns[i]
+			w.beginStruct(0)
+			w.i64(2, chunk.offset)
+			w.beginStruct(3)
+			w.i32(1, column.parquetType())
+			w.i32List(2, parquetEncodingPlain, parquetEncodingRLE)
+			w.stringList(3, column.Name)
+			w.i32(4, parquetCodecUncompressed)
+			w.i64(5, group.numRows)
+			w.i64(6, chunk.size)
+			w.i64(7, chunk.size)
+			w.i64(9, chunk.offset)
+			w.endStruct()
+			w.endStruct()
+		}
+		w.i64(2, group.totalSize)
+		w.i64(3, group.numRows)
+		w.endStruct()
+	}
+
+	w.string(6, "go-cqrs")
+	w.endStruct()
+	return w.buf
+}
+
+// check reports whether a value can be stored in the column
+func (c Column) check(value interface{}) error {
+	switch value.(type) {
+	case nil:
+		if !c.Optional {
+			return fmt.Errorf("column %s cannot be empty", c.Name)
+		}
+	case int64:
+		if c.Type != Int64Column {
+			return fmt.Errorf("column %s expects a string, got an int64", c.Name)
+		}
+	case string:
+		if c.Type != StringColumn {
+			return fmt.Errorf("column %s expects an int64, got a string", c.Name)
+		}
+	default:
+		return fmt.Errorf("column %s cannot store a %T", c.Name, value)
+	}
+	return nil
+}
+
+func (c Column) parquetType() int32 {
+	if c.Type == Int64Column {
+		return parquetTypeInt64
+	}
+	return parquetTypeByteArray
+}
+
+func (c Column) repetition() int32 {
+	if c.Optional {
+		return parquetOptional
+	}
+	return parquetRequired
+}
@@ -0,0 +1,101 @@
+package exports
+
+import (
+	"encoding/binary"
+)
+
+// Thrift compact protocol field types used by the Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// compactWriter encodes Thrift structs with the compact protocol, which Parquet uses for its
+// page headers and file metadata. Fields must be written in increasing ID order.
+type compactWriter struct {
+	buf        []byte
+	lastField  int16
+	fieldStack []int16
+}
+
+func (w *compactWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - w.lastField; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|fieldType)
+	} else {
+		w.buf = append(w.buf, fieldType)
+		w.varint(int64(id))
+	}
+	w.lastField = id
+}
+
+// varint writes a zigzag encoded integer
+func (w *compactWriter) varint(v int64) {
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *compactWriter) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *compactWriter) string(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.uvarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// listHeader starts a list field of size elements of the given type
+func (w *compactWriter) listHeader(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.uvarint(uint64(size))
+	}
+}
+
+// i32List writes a list field of i32 values
+func (w *compactWriter) i32List(id int16, values ...int32) {
+	w.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		w.varint(int64(v))
+	}
+}
+
+// stringList writes a list field of strings
+func (w *compactWriter) stringList(id int16, values ...string) {
+	w.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		w.uvarint(uint64(len(v)))
+		w.buf = append(w.buf, v...)
+	}
+}
+
+// beginStruct starts a struct, either as a field or, with id 0, as a list element or the top-level value
+func (w *compactWriter) beginStruct(id int16) {
+	if id != 0 {
+		w.fieldHeader(id, thriftStruct)
+	}
+	w.fieldStack = append(w.fieldStack, w.lastField)
+	w.lastField = 0
+}
+
+// endStruct writes the stop field of the current struct
+func (w *compactWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.lastField = w.fieldStack[len(w.fieldStack)-1]
+	w.fieldStack = w.fieldStack[:len(w.fieldStack)-1]
+}
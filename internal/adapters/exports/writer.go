@@ -0,0 +1,155 @@
+package exports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of an export
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat returns the export format with the given name, CSV when it is empty
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "parquet":
+		return FormatParquet, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q, use csv, ndjson or parquet", name)
+}
+
+// FormatForMediaType returns the export format of a media type from an Accept header
+func FormatForMediaType(mediaType string) (Format, bool) {
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/ndjson":
+		return FormatNDJSON, true
+	case "application/vnd.apache.parquet", "application/x-parquet":
+		return FormatParquet, true
+	}
+	return "", false
+}
+
+// ContentType is the media type of exports in the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	StringColumn ColumnType = iota
+	Int64Column
+)
+
+// Column describes a column of the flat CSV and Parquet exports
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Optional bool
+}
+
+// Record is one exported entity. Values hold one value per column, a string or an int64, or nil for
+// a missing optional value. Document is the full representation written to NDJSON exports.
+type Record struct {
+	Values   []interface{}
+	Document interface{}
+}
+
+// RecordWriter writes the records of an export. Close finishes the export without closing the underlying writer.
+type RecordWriter interface {
+	Write(record Record) error
+	Close() error
+}
+
+// NewRecordWriter returns a writer encoding records with the given columns in the format
+func NewRecordWriter(format Format, w io.Writer, columns []Column) (RecordWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return newParquetWriter(w, columns), nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// csvWriter writes a header row followed by one row per record
+type csvWriter struct {
+	writer *csv.Writer
+	row    []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{writer: writer, row: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(record Record) error {
+	if len(record.Values) != len(c.row) {
+		return fmt.Errorf("expected %d values, got %d", len(c.row), len(record.Values))
+	}
+
+	for i, value := range record.Values {
+		switch v := value.(type) {
+		case nil:
+			c.row[i] = ""
+		case int64:
+			c.row[i] = strconv.FormatInt(v, 10)
+		case string:
+			c.row[i] = v
+		default:
+			return fmt.Errorf("unsupported value of type %T", value)
+		}
+	}
+	return c.writer.Write(c.row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// ndjsonWriter writes the document of every record on its own line
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(record Record) error {
+	return n.encoder.Encode(record.Document)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
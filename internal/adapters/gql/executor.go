@@ -0,0 +1,100 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	domainerrors "go-cqrs/internal/domain/errors"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Executor runs GraphQL requests against the schema within the limits
+type Executor struct {
+	schema   graphql.Schema
+	queryBus *bus.QueryBus
+	limits   atomic.Pointer[Limits]
+}
+
+func NewExecutor(schema graphql.Schema, queryBus *bus.QueryBus, limits Limits) *Executor {
+	e := &Executor{schema: schema, queryBus: queryBus}
+	e.SetLimits(limits)
+	return e
+}
+
+// SetLimits replaces the limits; requests already checked keep running
+func (e *Executor) SetLimits(limits Limits) {
+	e.limits.Store(&limits)
+}
+
+// Execute parses, validates and runs a request. Requests that do not parse, validate or stay
+// within the limits answer with errors only; a result with data may still carry field errors.
+func (e *Executor) Execute(ctx context.Context, request dto.GraphQLRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(request.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&e.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := checkLimits(e.schema, doc, request.OperationName, request.Variables, *e.limits.Load()); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       withLoaders(ctx, e.queryBus),
+	})
+	for i := range result.Errors {
+		result.Errors[i] = withErrorCode(result.Errors[i])
+	}
+	return result
+}
+
+// withErrorCode adds the code of the domain error behind a field error to its extensions
+func withErrorCode(formatted gqlerrors.FormattedError) gqlerrors.FormattedError {
+	err := originalError(formatted)
+
+	var domainErr *domainerrors.DomainError
+	if !errors.As(err, &domainErr) {
+		return formatted
+	}
+	if formatted.Extensions == nil {
+		formatted.Extensions = make(map[string]interface{})
+	}
+	formatted.Extensions["code"] = string(domainErr.Code)
+	if domainErr.Code == domainerrors.ErrorCodeDatabaseError {
+		// Do not leak database details to clients
+		formatted.Message = domainErr.Message
+	}
+	return formatted
+}
+
+// originalError unwraps the errors the executor wraps resolver errors in; those of thunks are wrapped twice
+func originalError(err error) error {
+	for {
+		switch wrapper := err.(type) {
+		case gqlerrors.FormattedError:
+			err = wrapper.OriginalError()
+		case *gqlerrors.Error:
+			err = wrapper.OriginalError
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize is the number of items assumed for list fields without a limit argument
+const defaultListSize = 10
+
+// Limits bound the queries the endpoint runs
+type Limits struct {
+	// MaxDepth is the deepest nesting of fields, the fields of the operation being at depth 1
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of fields resolved. Every field costs 1, and
+	// the fields below a list count once per item, estimated from its limit argument.
+	MaxComplexity int
+}
+
+// limitChecker walks an operation to measure its depth and complexity
+type limitChecker struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits measures the operation of a validated document against the limits
+func checkLimits(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, limits Limits) error {
+	checker := limitChecker{schema: schema, fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			checker.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		// The executor reports the missing operation
+		return nil
+	}
+
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	if root == nil {
+		return nil
+	}
+
+	depth, complexity := checker.measure(root, operation.SelectionSet)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, limits.MaxDepth)
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, limits.MaxComplexity)
+	}
+	return nil
+}
+
+// measure returns the depth and complexity of a selection set on the given type
+func (c limitChecker) measure(parent *graphql.Object, selectionSet *ast.SelectionSet) (depth, complexity int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+
+	for _, selection := range selectionSet.Selections {
+		var selectionDepth, selectionComplexity int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionDepth, selectionComplexity = c.measureField(parent, selection)
+		case *ast.InlineFragment:
+			selectionDepth, selectionComplexity = c.measure(c.fragmentType(parent, selection.TypeCondition), selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment := c.fragments[selection.Name.Value]; fragment != nil {
+				selectionDepth, selectionComplexity = c.measure(c.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet)
+			}
+		}
+		if selectionDepth > depth {
+			depth = selectionDepth
+		}
+		complexity += selectionComplexity
+	}
+	return depth, complexity
+}
+
+// measureField returns the depth and complexity of a field and its selections
+func (c limitChecker) measureField(parent *graphql.Object, field *ast.Field) (depth, complexity int) {
+	// Introspection is bounded by the schema itself
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return 0, 0
+	}
+
+	definition := parent.Fields()[name]
+	if definition == nil {
+		return 1, 1
+	}
+
+	fieldType, isList := unwrap(definition.Type)
+	object, ok := fieldType.(*graphql.Object)
+	if !ok {
+		return 1, 1
+	}
+
+	childDepth, childComplexity := c.measure(object, field.SelectionSet)
+	if isList {
+		childComplexity *= c.listSize(field)
+	}
+	return 1 + childDepth, 1 + childComplexity
+}
+
+// listSize estimates the number of items of a list field from its limit argument
+func (c limitChecker) listSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if size, err := strconv.Atoi(value.Value); err == nil {
+				return size
+			}
+		case *ast.Variable:
+			if size, ok := c.variables[value.Name.Value].(float64); ok {
+				return int(size)
+			}
+		}
+	}
+	if field.Name.Value == "customers" {
+		return defaultCustomerPageSize
+	}
+	return defaultListSize
+}
+
+// fragmentType returns the type a fragment applies to, the parent when it has no type condition
+func (c limitChecker) fragmentType(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	if object, ok := c.schema.Type(condition.Name.Value).(*graphql.Object); ok {
+		return object
+	}
+	return parent
+}
+
+// unwrap strips the non-null and list wrappers off a type and reports whether it was a list
+func unwrap(t graphql.Type) (graphql.Type, bool) {
+	isList := false
+	for {
+		switch wrapper := t.(type) {
+		case *graphql.NonNull:
+			t = wrapper.OfType
+		case *graphql.List:
+			t = wrapper.OfType
+			isList = true
+		default:
+			return t, isList
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package gql
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc fetches the values of several keys at once. Keys without a value are left out of the result.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches the lookups of one request, in the manner of DataLoader.
+// Load only queues the key; the first thunk that is called fetches every queued key at once.
+// The executor calls thunks breadth-first, so the lookups of all the fields at one depth of
+// the query end up in a single batch.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+// NewLoader creates a loader fetching with the given batch function
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:  fetch,
+		queued: make(map[K]bool),
+		values: make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+// Load queues the key and returns a thunk answering its value and whether it exists
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, bool, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, done := l.errs[key]; !done {
+			if _, done = l.values[key]; !done {
+				l.flush(ctx)
+			}
+		}
+		if err := l.errs[key]; err != nil {
+			var zero V
+			return zero, false, err
+		}
+		value, ok := l.values[key]
+		return value, ok, nil
+	}
+}
+
+// flush fetches the queued keys. Keys without a value are remembered as missing.
+func (l *Loader[K, V]) flush(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		if value, ok := values[key]; ok {
+			l.values[key] = value
+		} else {
+			l.errs[key] = nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package gql
+
+import (
+	"context"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+)
+
+// loaders holds the loaders of one request
+type loaders struct {
+	customers      *Loader[int, dto.CustomerDTO]
+	orders         *Loader[int, dto.OrderDTO]
+	customerOrders *Loader[int, []dto.OrderDTO]
+}
+
+type loadersKey struct{}
+
+// withLoaders returns a context carrying fresh loaders that fetch through the query bus
+func withLoaders(ctx context.Context, queryBus *bus.QueryBus) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		customers: NewLoader(func(ctx context.Context, ids []int) (map[int]dto.CustomerDTO, error) {
+			customers, err := bus.Ask[[]dto.CustomerDTO](ctx, queryBus, queries.GetCustomersQuery{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int]dto.CustomerDTO, len(customers))
+			for _, customer := range customers {
+				result[customer.ID] = customer
+			}
+			return result, nil
+		}),
+		orders: NewLoader(func(ctx context.Context, ids []int) (map[int]dto.OrderDTO, error) {
+			orders, err := bus.Ask[[]dto.OrderDTO](ctx, queryBus, queries.GetOrdersQuery{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int]dto.OrderDTO, len(orders))
+			for _, order := range orders {
+				result[order.ID] = order
+			}
+			return result, nil
+		}),
+		customerOrders: NewLoader(func(ctx context.Context, customerIDs []int) (map[int][]dto.OrderDTO, error) {
+			orders, err := bus.Ask[[]dto.OrderDTO](ctx, queryBus, queries.GetOrdersByCustomersQuery{CustomerIDs: customerIDs})
+			if err != nil {
+				return nil, err
+			}
+			// Every customer has a list, customers without orders an empty one
+			result := make(map[int][]dto.OrderDTO, len(customerIDs))
+			for _, id := range customerIDs {
+				result[id] = []dto.OrderDTO{}
+			}
+			for _, order := range orders {
+				if order.CustomerID != nil {
+					result[*order.CustomerID] = append(result[*order.CustomerID], order)
+				}
+			}
+			return result, nil
+		}),
+	})
+}
+
+// loadersFrom returns the loaders of the request
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
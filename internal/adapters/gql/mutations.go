@@ -0,0 +1,231 @@
+package gql
+
+import (
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newMutation maps mutations to the existing customer and order commands. Mutations answer
+// with the changed customer or order, read back through the query bus.
+func newMutation(commandBus *bus.CommandBus, queryBus *bus.QueryBus, customerType, orderType *graphql.Object) *graphql.Object {
+	orderLineInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "OrderLineInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"sku":         {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.String},
+			"quantity":    {Type: graphql.NewNonNull(graphql.Int)},
+			"unitPrice":   {Type: graphql.Int, Description: "Unit price in minor units of the order currency, the catalog price when omitted"},
+		},
+	})
+
+	getCustomer := func(p graphql.ResolveParams, id int) (interface{}, error) {
+		customer, err := bus.Ask[*dto.CustomerDTO](p.Context, queryBus, queries.GetCustomerQuery{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		return *customer, nil
+	}
+	getOrder := func(p graphql.ResolveParams, id int) (interface{}, error) {
+		order, err := bus.Ask[*dto.OrderDTO](p.Context, queryBus, queries.GetOrderQuery{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		return *order, nil
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCustomer": {
+				Type: graphql.NewNonNull(customerType),
+				Args: graphql.FieldConfigArgument{
+					"name":  {Type: graphql.NewNonNull(graphql.String)},
+					"email": {Type: graphql.NewNonNull(graphql.String)},
+					"phone": {Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := bus.Result[int](commandBus.Dispatch(p.Context, commands.CreateCustomerCommand{
+						Name:  p.Args["name"].(string),
+						Email: p.Args["email"].(string),
+						Phone: stringArg(p, "phone"),
+					}))
+					if err != nil {
+						return nil, err
+					}
+					return getCustomer(p, id)
+				},
+			},
+			"updateCustomer": {
+				Type: graphql.NewNonNull(customerType),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.Int)},
+					"name":  {Type: graphql.NewNonNull(graphql.String)},
+					"email": {Type: graphql.NewNonNull(graphql.String)},
+					"phone": {Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(int)
+					_, err := commandBus.Dispatch(p.Context, commands.UpdateCustomerCommand{
+						ID:    id,
+						Name:  p.Args["name"].(string),
+						Email: p.Args["email"].(string),
+						Phone: stringArg(p, "phone"),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return getCustomer(p, id)
+				},
+			},
+			"deleteCustomer": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					_, err := commandBus.Dispatch(p.Context, commands.DeleteCustomerCommand{ID: p.Args["id"].(int)})
+					return err == nil, err
+				},
+			},
+			"createOrder": {
+				Type: graphql.NewNonNull(orderType),
+				Args: graphql.FieldConfigArgument{
+					"customerId":        {Type: graphql.Int},
+					"product":           {Type: graphql.String},
+					"quantity":          {Type: graphql.Int},
+					"currency":          {Type: graphql.String},
+					"lines":             {Type: graphql.NewList(graphql.NewNonNull(orderLineInput))},
+					"shippingAddressId": {Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					quantity, _ := p.Args["quantity"].(int)
+					id, err := bus.Result[int](commandBus.Dispatch(p.Context, commands.CreateOrderCommand{
+						CustomerID:        intArg(p, "customerId"),
+						Product:           stringArg(p, "product"),
+						Quantity:          quantity,
+						Currency:          stringArg(p, "currency"),
+						Lines:             orderLinesArg(p, "lines"),
+						ShippingAddressID: intArg(p, "shippingAddressId"),
+					}))
+					if err != nil {
+						return nil, err
+					}
+					return getOrder(p, id)
+				},
+			},
+			"deleteOrder": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					_, err := commandBus.Dispatch(p.Context, commands.DeleteOrderCommand{ID: p.Args["id"].(int)})
+					return err == nil, err
+				},
+			},
+			"assignCustomer": {
+				Type: graphql.NewNonNull(orderType),
+				Args: graphql.FieldConfigArgument{
+					"orderId":    {Type: graphql.NewNonNull(graphql.Int)},
+					"customerId": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					orderID := p.Args["orderId"].(int)
+					_, err := commandBus.Dispatch(p.Context, commands.AssignCustomerCommand{
+						OrderID:    orderID,
+						CustomerID: p.Args["customerId"].(int),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return getOrder(p, orderID)
+				},
+			},
+			"addOrderLine": {
+				Type: graphql.NewNonNull(orderType),
+				Args: graphql.FieldConfigArgument{
+					"orderId": {Type: graphql.NewNonNull(graphql.Int)},
+					"line":    {Type: graphql.NewNonNull(orderLineInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					orderID := p.Args["orderId"].(int)
+					line := toOrderLine(p.Args["line"].(map[string]interface{}))
+					_, err := commandBus.Dispatch(p.Context, commands.AddOrderLineCommand{
+						OrderID:     orderID,
+						SKU:         line.SKU,
+						Description: line.Description,
+						Quantity:    line.Quantity,
+						UnitPrice:   line.UnitPrice,
+					})
+					if err != nil {
+						return nil, err
+					}
+					return getOrder(p, orderID)
+				},
+			},
+			"removeOrderLine": {
+				Type: graphql.NewNonNull(orderType),
+				Args: graphql.FieldConfigArgument{
+					"orderId": {Type: graphql.NewNonNull(graphql.Int)},
+					"sku":     {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					orderID := p.Args["orderId"].(int)
+					_, err := commandBus.Dispatch(p.Context, commands.RemoveOrderLineCommand{
+						OrderID: orderID,
+						SKU:     p.Args["sku"].(string),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return getOrder(p, orderID)
+				},
+			},
+		},
+	})
+}
+
+// stringArg returns an optional string argument, empty when it is missing
+func stringArg(p graphql.ResolveParams, name string) string {
+	value, _ := p.Args[name].(string)
+	return value
+}
+
+// intArg returns an optional integer argument, nil when it is missing
+func intArg(p graphql.ResolveParams, name string) *int {
+	value, ok := p.Args[name].(int)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// orderLinesArg converts an optional list of order line inputs to the lines of an order command
+func orderLinesArg(p graphql.ResolveParams, name string) []commands.OrderLine {
+	lines, _ := p.Args[name].([]interface{})
+	if len(lines) == 0 {
+		return nil
+	}
+	result := make([]commands.OrderLine, len(lines))
+	for i, line := range lines {
+		result[i] = toOrderLine(line.(map[string]interface{}))
+	}
+	return result
+}
+
+// toOrderLine converts an order line input to the line of an order command. An omitted unit
+// price is left zero for the catalog price to apply, as in the REST commands.
+func toOrderLine(line map[string]interface{}) commands.OrderLine {
+	description, _ := line["description"].(string)
+	unitPrice, _ := line["unitPrice"].(int)
+	return commands.OrderLine{
+		SKU:         line["sku"].(string),
+		Description: description,
+		Quantity:    line["quantity"].(int),
+		UnitPrice:   int64(unitPrice),
+	}
+}
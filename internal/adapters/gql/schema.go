@@ -0,0 +1,171 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultCustomerPageSize = 50
+	maxCustomerPageSize     = 500
+)
+
+var (
+	errLimit  = fmt.Errorf("limit must be between 1 and %d", maxCustomerPageSize)
+	errOffset = errors.New("offset must be a non-negative number")
+)
+
+// NewSchema builds the GraphQL schema over customers and orders. Reads go through the query bus,
+// with the lookups of nested customers and orders batched per request; the mutations, when
+// enabled, dispatch the existing commands.
+func NewSchema(commandBus *bus.CommandBus, queryBus *bus.QueryBus, mutations bool) (graphql.Schema, error) {
+	addressType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Address",
+		Fields: graphql.Fields{
+			"id":         {Type: graphql.NewNonNull(graphql.Int)},
+			"type":       {Type: graphql.NewNonNull(graphql.String)},
+			"line1":      {Type: graphql.NewNonNull(graphql.String)},
+			"line2":      {Type: graphql.String},
+			"city":       {Type: graphql.NewNonNull(graphql.String)},
+			"region":     {Type: graphql.String},
+			"postalCode": {Type: graphql.String},
+			"country":    {Type: graphql.NewNonNull(graphql.String)},
+			"isDefault":  {Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	orderLineType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "OrderLine",
+		Description: "A line of an order. Amounts are in minor units of the order currency.",
+		Fields: graphql.Fields{
+			"sku":         {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.String},
+			"quantity":    {Type: graphql.NewNonNull(graphql.Int)},
+			"unitPrice":   {Type: graphql.NewNonNull(graphql.Int)},
+			"subtotal":    {Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	customerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Customer",
+		Fields: graphql.Fields{
+			"id":         {Type: graphql.NewNonNull(graphql.Int)},
+			"name":       {Type: graphql.NewNonNull(graphql.String)},
+			"email":      {Type: graphql.NewNonNull(graphql.String)},
+			"phone":      {Type: graphql.String},
+			"mergedInto": {Type: graphql.Int, Description: "The customer this one was merged into"},
+			"addresses":  {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(addressType)))},
+		},
+	})
+
+	orderType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Order",
+		Fields: graphql.Fields{
+			"id":              {Type: graphql.NewNonNull(graphql.Int)},
+			"customerId":      {Type: graphql.Int},
+			"product":         {Type: graphql.NewNonNull(graphql.String)},
+			"quantity":        {Type: graphql.NewNonNull(graphql.Int)},
+			"currency":        {Type: graphql.NewNonNull(graphql.String)},
+			"total":           {Type: graphql.NewNonNull(graphql.Int), Description: "Total in minor units of the currency"},
+			"status":          {Type: graphql.String},
+			"lines":           {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderLineType)))},
+			"shippingAddress": {Type: addressType},
+			"customer": {
+				Type: customerType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					order := p.Source.(dto.OrderDTO)
+					if order.CustomerID == nil {
+						return nil, nil
+					}
+					return loadCustomer(p, *order.CustomerID), nil
+				},
+			},
+		},
+	})
+
+	customerType.AddFieldConfig("orders", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderType))),
+		Description: "The orders of the customer, newest first",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			customer := p.Source.(dto.CustomerDTO)
+			thunk := loadersFrom(p.Context).customerOrders.Load(p.Context, customer.ID)
+			return func() (interface{}, error) {
+				orders, _, err := thunk()
+				return orders, err
+			}, nil
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"customer": {
+				Type: customerType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadCustomer(p, p.Args["id"].(int)), nil
+				},
+			},
+			"customers": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(customerType))),
+				Description: "A page of the active customers in ID order",
+				Args: graphql.FieldConfigArgument{
+					"limit":  {Type: graphql.Int, DefaultValue: defaultCustomerPageSize},
+					"offset": {Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, offset := p.Args["limit"].(int), p.Args["offset"].(int)
+					if limit < 1 || limit > maxCustomerPageSize {
+						return nil, errLimit
+					}
+					if offset < 0 {
+						return nil, errOffset
+					}
+					return bus.Ask[[]dto.CustomerDTO](p.Context, queryBus, queries.ListCustomersQuery{Limit: limit, Offset: offset})
+				},
+			},
+			"order": {
+				Type: orderType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thunk := loadersFrom(p.Context).orders.Load(p.Context, p.Args["id"].(int))
+					return func() (interface{}, error) {
+						order, ok, err := thunk()
+						if err != nil || !ok {
+							return nil, err
+						}
+						return order, nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	config := graphql.SchemaConfig{Query: query}
+	if mutations {
+		config.Mutation = newMutation(commandBus, queryBus, customerType, orderType)
+	}
+	return graphql.NewSchema(config)
+}
+
+// loadCustomer returns a thunk resolving to the customer, or to null when it does not exist
+func loadCustomer(p graphql.ResolveParams, id int) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).customers.Load(p.Context, id)
+	return func() (interface{}, error) {
+		customer, ok, err := thunk()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return customer, nil
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/grpc/pb"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultCustomerOrderPageSize = 50
+	maxCustomerOrderPageSize     = 500
+)
+
+// CustomerService implements pb.CustomerServiceServer on top of the command and query buses
+type CustomerService struct {
+	pb.UnimplementedCustomerServiceServer
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
+}
+
+func NewCustomerService(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *CustomerService {
+	return &CustomerService{commandBus: commandBus, queryBus: queryBus}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req *pb.CreateCustomerRequest) (*pb.CreateCustomerResponse, error) {
+	id, err := bus.Result[int](s.commandBus.Dispatch(ctx, commands.CreateCustomerCommand{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Phone: req.GetPhone(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateCustomerResponse{Id: int64(id)}, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, req *pb.UpdateCustomerRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.UpdateCustomerCommand{
+		ID:    int(req.GetId()),
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Phone: req.GetPhone(),
+	})
+	return empty(err)
+}
+
+func (s *CustomerService) DeleteCustomer(ctx context.Context, req *pb.DeleteCustomerRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.DeleteCustomerCommand{ID: int(req.GetId())})
+	return empty(err)
+}
+
+func (s *CustomerService) MergeCustomers(ctx context.Context, req *pb.MergeCustomersRequest) (*pb.MergeReport, error) {
+	report, err := bus.Result[*dto.MergeReportDTO](s.commandBus.Dispatch(ctx, commands.MergeCustomersCommand{
+		SurvivorID:  int(req.GetSurvivorId()),
+		DuplicateID: int(req.GetDuplicateId()),
+		DryRun:      req.GetDryRun(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pb.MergeReport{
+		SurvivorId:   int64(report.SurvivorID),
+		DuplicateId:  int64(report.DuplicateID),
+		DryRun:       report.DryRun,
+		AdoptedPhone: report.AdoptedPhone,
+	}
+	for _, id := range report.ReassignedOrderIDs {
+		result.ReassignedOrderIds = append(result.ReassignedOrderIds, int64(id))
+	}
+	for _, address := range report.CopiedAddresses {
+		result.CopiedAddresses = append(result.CopiedAddresses, toAddressMessage(address))
+	}
+	return result, nil
+}
+
+func (s *CustomerService) AddCustomerAddress(ctx context.Context, req *pb.AddCustomerAddressRequest) (*pb.AddCustomerAddressResponse, error) {
+	id, err := bus.Result[int](s.commandBus.Dispatch(ctx, commands.AddCustomerAddressCommand{
+		CustomerID:      int(req.GetCustomerId()),
+		CustomerAddress: toCustomerAddress(req.GetAddress()),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AddCustomerAddressResponse{Id: int64(id)}, nil
+}
+
+func (s *CustomerService) UpdateCustomerAddress(ctx context.Context, req *pb.UpdateCustomerAddressRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.UpdateCustomerAddressCommand{
+		CustomerID:      int(req.GetCustomerId()),
+		AddressID:       int(req.GetAddressId()),
+		CustomerAddress: toCustomerAddress(req.GetAddress()),
+	})
+	return empty(err)
+}
+
+func (s *CustomerService) RemoveCustomerAddress(ctx context.Context, req *pb.RemoveCustomerAddressRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.RemoveCustomerAddressCommand{
+		CustomerID: int(req.GetCustomerId()),
+		AddressID:  int(req.GetAddressId()),
+	})
+	return empty(err)
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, req *pb.GetCustomerRequest) (*pb.Customer, error) {
+	customer, err := bus.Ask[*dto.CustomerDTO](ctx, s.queryBus, queries.GetCustomerQuery{ID: int(req.GetId())})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pb.Customer{
+		Id:         int64(customer.ID),
+		Name:       customer.Name,
+		Email:      customer.Email,
+		Phone:      customer.Phone,
+		MergedInto: optionalID(customer.MergedInto),
+	}
+	for _, address := range customer.Addresses {
+		result.Addresses = append(result.Addresses, toAddressMessage(address))
+	}
+	return result, nil
+}
+
+func (s *CustomerService) ListCustomerOrders(ctx context.Context, req *pb.ListCustomerOrdersRequest) (*pb.ListCustomerOrdersResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultCustomerOrderPageSize
+	}
+	if limit < 0 || limit > maxCustomerOrderPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxCustomerOrderPageSize)
+	}
+	if req.GetOffset() < 0 {
+		return nil, fmt.Errorf("offset must be a non-negative number")
+	}
+
+	filter := ports.OrderFilter{Product: req.GetProduct(), Currency: req.GetCurrency()}
+	if req.CreatedFrom != nil {
+		createdFrom := req.CreatedFrom.AsTime()
+		filter.CreatedFrom = &createdFrom
+	}
+	if req.CreatedTo != nil {
+		createdTo := req.CreatedTo.AsTime()
+		filter.CreatedTo = &createdTo
+	}
+
+	page, err := bus.Ask[*queries.CustomerOrdersPage](ctx, s.queryBus, queries.ListCustomerOrdersQuery{
+		CustomerID: int(req.GetCustomerId()),
+		Filter:     filter,
+		Limit:      limit,
+		Offset:     int(req.GetOffset()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pb.ListCustomerOrdersResponse{Total: int32(page.Total)}
+	for _, order := range page.Orders {
+		result.Orders = append(result.Orders, toOrderMessage(order))
+	}
+	return result, nil
+}
+
+func (s *CustomerService) GetCustomerSummary(ctx context.Context, req *pb.GetCustomerSummaryRequest) (*pb.CustomerSummary, error) {
+	summary, err := bus.Ask[*dto.CustomerSummaryDTO](ctx, s.queryBus, queries.GetCustomerSummaryQuery{CustomerID: int(req.GetCustomerId())})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pb.CustomerSummary{
+		CustomerId:    int64(summary.CustomerID),
+		OrderCount:    int32(summary.OrderCount),
+		TotalQuantity: int32(summary.TotalQuantity),
+	}
+	if summary.FirstOrderAt != nil {
+		result.FirstOrderAt = timestamppb.New(*summary.FirstOrderAt)
+	}
+	if summary.LastOrderAt != nil {
+		result.LastOrderAt = timestamppb.New(*summary.LastOrderAt)
+	}
+	return result, nil
+}
+
+// toCustomerAddress converts an address message to the address of a customer command
+func toCustomerAddress(address *pb.Address) commands.CustomerAddress {
+	return commands.CustomerAddress{
+		Type:       address.GetType(),
+		Line1:      address.GetLine1(),
+		Line2:      address.GetLine2(),
+		City:       address.GetCity(),
+		Region:     address.GetRegion(),
+		PostalCode: address.GetPostalCode(),
+		Country:    address.GetCountry(),
+		IsDefault:  address.GetIsDefault(),
+	}
+}
+
+// toAddressMessage converts an AddressDTO to an address message
+func toAddressMessage(address dto.AddressDTO) *pb.Address {
+	return &pb.Address{
+		Id:         int64(address.ID),
+		Type:       address.Type,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		IsDefault:  address.IsDefault,
+	}
+}
+
+// optionalID converts an optional ID to an optional message field
+func optionalID(id *int) *int64 {
+	if id == nil {
+		return nil
+	}
+	value := int64(*id)
+	return &value
+}
+
+// optionalInt converts an optional message field to an optional ID
+func optionalInt(id *int64) *int {
+	if id == nil {
+		return nil
+	}
+	value := int(*id)
+	return &value
+}
+
+// empty answers a command without a result
+func empty(err error) (*emptypb.Empty, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// domainErrorCodes maps the codes of domain errors to gRPC status codes
+var domainErrorCodes = map[domainerrors.ErrorCode]codes.Code{
+	domainerrors.ErrorCodeNotFound:          codes.NotFound,
+	domainerrors.ErrorCodeValidation:        codes.InvalidArgument,
+	domainerrors.ErrorCodeInvalidInput:      codes.InvalidArgument,
+	domainerrors.ErrorCodeUnauthorized:      codes.PermissionDenied,
+	domainerrors.ErrorCodeInsufficientStock: codes.FailedPrecondition,
+	domainerrors.ErrorCodeConflict:          codes.AlreadyExists,
+	domainerrors.ErrorCodeDatabaseError:     codes.Internal,
+}
+
+// StatusFromError converts an error of a command or query handler to a gRPC status.
+// Errors that are not domain errors are the handlers' own input checks, which the HTTP
+// adapter answers with 400, so they become InvalidArgument.
+func StatusFromError(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	var domainErr *domainerrors.DomainError
+	switch {
+	case errors.As(err, &domainErr):
+		code, ok := domainErrorCodes[domainErr.Code]
+		if !ok {
+			code = codes.Unknown
+		}
+		if code == codes.Internal {
+			// Do not leak database details to clients
+			return status.New(code, domainErr.Message)
+		}
+		return status.New(code, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+	return status.New(codes.InvalidArgument, err.Error())
+}
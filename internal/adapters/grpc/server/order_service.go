@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/grpc/pb"
+	"go-cqrs/internal/adapters/http/dto"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// OrderService implements pb.OrderServiceServer on top of the command and query buses
+type OrderService struct {
+	pb.UnimplementedOrderServiceServer
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
+}
+
+func NewOrderService(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *OrderService {
+	return &OrderService{commandBus: commandBus, queryBus: queryBus}
+}
+
+func (s *OrderService) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error) {
+	id, err := bus.Result[int](s.commandBus.Dispatch(ctx, commands.CreateOrderCommand{
+		CustomerID:        optionalInt(req.CustomerId),
+		Product:           req.GetProduct(),
+		Quantity:          int(req.GetQuantity()),
+		Currency:          req.GetCurrency(),
+		Lines:             toOrderLines(req.GetLines()),
+		ShippingAddressID: optionalInt(req.ShippingAddressId),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateOrderResponse{Id: int64(id)}, nil
+}
+
+func (s *OrderService) UpdateOrder(ctx context.Context, req *pb.UpdateOrderRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.UpdateOrderCommand{
+		ID:         int(req.GetId()),
+		CustomerID: optionalInt(req.CustomerId),
+		Product:    req.GetProduct(),
+		Quantity:   int(req.GetQuantity()),
+		Currency:   req.GetCurrency(),
+		Lines:      toOrderLines(req.GetLines()),
+	})
+	return empty(err)
+}
+
+func (s *OrderService) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.DeleteOrderCommand{ID: int(req.GetId())})
+	return empty(err)
+}
+
+func (s *OrderService) AssignCustomer(ctx context.Context, req *pb.AssignCustomerRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.AssignCustomerCommand{
+		OrderID:    int(req.GetOrderId()),
+		CustomerID: int(req.GetCustomerId()),
+	})
+	return empty(err)
+}
+
+func (s *OrderService) AddOrderLine(ctx context.Context, req *pb.AddOrderLineRequest) (*emptypb.Empty, error) {
+	line := req.GetLine()
+	_, err := s.commandBus.Dispatch(ctx, commands.AddOrderLineCommand{
+		OrderID:     int(req.GetOrderId()),
+		SKU:         line.GetSku(),
+		Description: line.GetDescription(),
+		Quantity:    int(line.GetQuantity()),
+		UnitPrice:   line.GetUnitPrice(),
+	})
+	return empty(err)
+}
+
+func (s *OrderService) ChangeOrderLine(ctx context.Context, req *pb.ChangeOrderLineRequest) (*emptypb.Empty, error) {
+	line := req.GetLine()
+	_, err := s.commandBus.Dispatch(ctx, commands.ChangeOrderLineCommand{
+		OrderID:     int(req.GetOrderId()),
+		SKU:         line.GetSku(),
+		Description: line.GetDescription(),
+		Quantity:    int(line.GetQuantity()),
+		UnitPrice:   line.GetUnitPrice(),
+	})
+	return empty(err)
+}
+
+func (s *OrderService) RemoveOrderLine(ctx context.Context, req *pb.RemoveOrderLineRequest) (*emptypb.Empty, error) {
+	_, err := s.commandBus.Dispatch(ctx, commands.RemoveOrderLineCommand{
+		OrderID: int(req.GetOrderId()),
+		SKU:     req.GetSku(),
+	})
+	return empty(err)
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+	order, err := bus.Ask[*dto.OrderDTO](ctx, s.queryBus, queries.GetOrderQuery{ID: int(req.GetId())})
+	if err != nil {
+		return nil, err
+	}
+	return toOrderMessage(*order), nil
+}
+
+// toOrderLines converts order line messages to the lines of an order command
+func toOrderLines(lines []*pb.OrderLine) []commands.OrderLine {
+	if len(lines) == 0 {
+		return nil
+	}
+	result := make([]commands.OrderLine, len(lines))
+	for i, line := range lines {
+		result[i] = commands.OrderLine{
+			SKU:         line.GetSku(),
+			Description: line.GetDescription(),
+			Quantity:    int(line.GetQuantity()),
+			UnitPrice:   line.GetUnitPrice(),
+		}
+	}
+	return result
+}
+
+// toOrderMessage converts an OrderDTO to an order message
+func toOrderMessage(order dto.OrderDTO) *pb.Order {
+	result := &pb.Order{
+		Id:         int64(order.ID),
+		CustomerId: optionalID(order.CustomerID),
+		Product:    order.Product,
+		Quantity:   int32(order.Quantity),
+		Currency:   order.Currency,
+		Total:      order.Total,
+		Status:     order.Status,
+	}
+	for _, line := range order.Lines {
+		result.Lines = append(result.Lines, &pb.OrderLine{
+			Sku:         line.SKU,
+			Description: line.Description,
+			Quantity:    int32(line.Quantity),
+			UnitPrice:   line.UnitPrice,
+			Subtotal:    line.Subtotal,
+		})
+	}
+	if order.ShippingAddress != nil {
+		result.ShippingAddress = toAddressMessage(*order.ShippingAddress)
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package server
+
+//go:generate protoc -I ../proto --go_out=../../../.. --go_opt=module=go-cqrs --go-grpc_out=../../../.. --go-grpc_opt=module=go-cqrs address.proto order.proto customer.proto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/grpc/pb"
+	"go-cqrs/internal/adapters/http/middleware"
+	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RequestIDMetadata carries the request ID in both directions
+	RequestIDMetadata = "x-request-id"
+	// ActorMetadata identifies who is making the call
+	ActorMetadata = "x-actor"
+	// TenantMetadata names the tenant the call is made for
+	TenantMetadata = "x-tenant-id"
+)
+
+// NewServer creates a gRPC server for the customer and order services. The services dispatch
+// through the same buses as the HTTP controllers, so they run the same handlers and middleware,
+// and calls are scoped to their tenant the same way as HTTP requests.
+func NewServer(commandBus *bus.CommandBus, queryBus *bus.QueryBus, log logger.Logger, tenants middleware.TenantResolver) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestContextInterceptor,
+		tenantInterceptor(tenants),
+		loggingInterceptor(log),
+		errorInterceptor,
+	))
+	pb.RegisterCustomerServiceServer(server, NewCustomerService(commandBus, queryBus))
+	pb.RegisterOrderServiceServer(server, NewOrderService(commandBus, queryBus))
+	reflection.Register(server)
+	return server
+}
+
+// requestContextInterceptor stores the request ID and actor from the call metadata in the context,
+// like the HTTP request context middleware does with headers
+func requestContextInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, RequestIDMetadata)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+
+	ctx = requestctx.WithRequestID(ctx, requestID)
+	if actor := firstValue(md, ActorMetadata); actor != "" {
+		ctx = requestctx.WithActor(ctx, actor)
+	}
+	return handler(ctx, req)
+}
+
+// tenantInterceptor stores the tenant named by the call metadata or its authority in the context,
+// turning away calls naming a malformed or unknown tenant
+func tenantInterceptor(tenants middleware.TenantResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		tenant, err := tenants.ResolveFrom(firstValue(md, TenantMetadata), firstValue(md, "authorization"), firstValue(md, ":authority"))
+		if errors.Is(err, middleware.ErrTenantMismatch) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return handler(requestctx.WithTenant(ctx, tenant), req)
+	}
+}
+
+// loggingInterceptor logs every call with its status code and duration
+func loggingInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		log.Info("gRPC call",
+			logger.String("method", info.FullMethod),
+			logger.String("code", status.Code(err).String()),
+			logger.String("request_id", requestctx.RequestID(ctx)),
+			logger.Duration("duration", time.Since(start)),
+		)
+		return resp, err
+	}
+}
+
+// errorInterceptor turns the errors of the services into gRPC statuses
+func errorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, StatusFromError(err).Err()
+	}
+	return resp, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-cqrs/internal/adapters/batch"
+	"go-cqrs/internal/adapters/http/dto"
+	"net/http"
+)
+
+type BatchController struct {
+	executor *batch.Executor
+}
+
+func NewBatchController(executor *batch.Executor) *BatchController {
+	return &BatchController{executor: executor}
+}
+
+// ExecuteBatch handles running an ordered list of commands. A batch that ran answers 200 when every
+// command succeeded, 207 when a best-effort batch partly failed and 400 when an atomic batch was rolled back.
+func (c *BatchController) ExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	var request dto.BatchRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := c.executor.Execute(r.Context(), request)
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !response.Succeeded {
+		status = http.StatusMultiStatus
+		if response.Atomic {
+			status = http.StatusBadRequest
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,22 +2,32 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
 	"go-cqrs/internal/adapters/cqrs/commands"
 	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/exports"
+	"go-cqrs/internal/adapters/http/dto"
+	domainerrors "go-cqrs/internal/domain/errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	defaultCustomerOrderPageSize = 50
+	maxCustomerOrderPageSize     = 500
+)
+
 type CustomerController struct {
-	commandHandler *commands.CustomerCommandHandler
-	queryHandler   *queries.CustomerQueryHandler
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
 }
 
-func NewCustomerController(commandHandler *commands.CustomerCommandHandler, queryHandler *queries.CustomerQueryHandler) *CustomerController {
-	return &CustomerController{commandHandler: commandHandler, queryHandler: queryHandler}
+func NewCustomerController(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *CustomerController {
+	return &CustomerController{commandBus: commandBus, queryBus: queryBus}
 }
 
 // CreateCustomer handles the creation of a new customer
@@ -29,7 +39,7 @@ func (c *CustomerController) CreateCustomer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	customerID, err := c.commandHandler.HandleCreateCustomerCommand(r.Context(), createCmd)
+	customerID, err := bus.Result[int](c.commandBus.Dispatch(r.Context(), createCmd))
 	if err != nil {
 		HandleCustomerErrorResponse(w, err)
 		return
@@ -53,7 +63,7 @@ func (c *CustomerController) GetCustomer(w http.ResponseWriter, r *http.Request)
 	}
 
 	getQuery := queries.GetCustomerQuery{ID: id}
-	customer, err := c.queryHandler.HandleGetCustomerQuery(r.Context(), getQuery)
+	customer, err := bus.Ask[*dto.CustomerDTO](r.Context(), c.queryBus, getQuery)
 	if err != nil {
 		HandleCustomerErrorResponse(w, err)
 		return
@@ -65,8 +75,90 @@ func (c *CustomerController) GetCustomer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(customer)
+	writeView(w, r, customer)
+}
+
+// GetCustomerHistory handles retrieving the audit trail of a customer
+func (c *CustomerController) GetCustomerHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+
+	historyQuery := queries.GetCustomerHistoryQuery{ID: id}
+	history, err := bus.Ask[*dto.HistoryDTO](r.Context(), c.queryBus, historyQuery)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, history)
+}
+
+// ListCustomerOrders handles retrieving a page of the orders of a customer, newest first.
+// The orders can be filtered like exports, by product, currency, createdFrom and createdTo;
+// the X-Total-Count header holds the number of matching orders.
+func (c *CustomerController) ListCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+
+	values := r.URL.Query()
+	filter, err := exports.ParseOrderFilter(values)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	listQuery := queries.ListCustomerOrdersQuery{CustomerID: id, Filter: filter, Limit: defaultCustomerOrderPageSize}
+	if limit := values.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 || value > maxCustomerOrderPageSize {
+			HandleCustomerErrorResponse(w, fmt.Errorf("limit must be between 1 and %d", maxCustomerOrderPageSize))
+			return
+		}
+		listQuery.Limit = value
+	}
+	if offset := values.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			HandleCustomerErrorResponse(w, fmt.Errorf("offset must be a non-negative number"))
+			return
+		}
+		listQuery.Offset = value
+	}
+
+	page, err := bus.Ask[*queries.CustomerOrdersPage](r.Context(), c.queryBus, listQuery)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	writeView(w, r, page.Orders)
+}
+
+// GetCustomerSummary handles retrieving the order count, total quantity and first and last order times of a customer
+func (c *CustomerController) GetCustomerSummary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+
+	summary, err := bus.Ask[*dto.CustomerSummaryDTO](r.Context(), c.queryBus, queries.GetCustomerSummaryQuery{CustomerID: id})
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, summary)
 }
 
 // UpdateCustomer handles updating an existing customer
@@ -86,7 +178,7 @@ func (c *CustomerController) UpdateCustomer(w http.ResponseWriter, r *http.Reque
 	}
 	updateCmd.ID = id
 
-	err = c.commandHandler.HandleUpdateCustomerCommand(r.Context(), updateCmd)
+	_, err = c.commandBus.Dispatch(r.Context(), updateCmd)
 	if err != nil {
 		HandleCustomerErrorResponse(w, err)
 		return
@@ -107,7 +199,7 @@ func (c *CustomerController) DeleteCustomer(w http.ResponseWriter, r *http.Reque
 	}
 
 	deleteCmd := commands.DeleteCustomerCommand{ID: id}
-	err = c.commandHandler.HandleDeleteCustomerCommand(r.Context(), deleteCmd)
+	_, err = c.commandBus.Dispatch(r.Context(), deleteCmd)
 	if err != nil {
 		HandleCustomerErrorResponse(w, err)
 		return
@@ -124,9 +216,142 @@ func (c *CustomerController) ListCustomers(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(map[string]string{"message": "List customers not implemented yet"})
 }
 
+// MergeCustomers handles merging a duplicate customer into the customer in the path.
+// With dryRun set in the body or the query string, it only reports what would change.
+func (c *CustomerController) MergeCustomers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	survivorID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+
+	var mergeCmd commands.MergeCustomersCommand
+	err = json.NewDecoder(r.Body).Decode(&mergeCmd)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+	mergeCmd.SurvivorID = survivorID
+	if dryRun := r.URL.Query().Get("dryRun"); dryRun != "" {
+		mergeCmd.DryRun, err = strconv.ParseBool(dryRun)
+		if err != nil {
+			HandleCustomerErrorResponse(w, fmt.Errorf("invalid dryRun value: %w", err))
+			return
+		}
+	}
+
+	report, err := bus.Result[*dto.MergeReportDTO](c.commandBus.Dispatch(r.Context(), mergeCmd))
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// AddCustomerAddress handles adding an address to a customer
+func (c *CustomerController) AddCustomerAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	customerID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+
+	var addCmd commands.AddCustomerAddressCommand
+	err = json.NewDecoder(r.Body).Decode(&addCmd)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+	addCmd.CustomerID = customerID
+
+	addressID, err := bus.Result[int](c.commandBus.Dispatch(r.Context(), addCmd))
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      addressID,
+		"message": "Address added successfully",
+	})
+}
+
+// UpdateCustomerAddress handles changing an address of a customer
+func (c *CustomerController) UpdateCustomerAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	customerID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+	addressID, err := strconv.Atoi(vars["addressId"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid address ID: %w", err))
+		return
+	}
+
+	var updateCmd commands.UpdateCustomerAddressCommand
+	err = json.NewDecoder(r.Body).Decode(&updateCmd)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+	updateCmd.CustomerID = customerID
+	updateCmd.AddressID = addressID
+
+	_, err = c.commandBus.Dispatch(r.Context(), updateCmd)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Address updated successfully"})
+}
+
+// RemoveCustomerAddress handles removing an address from a customer
+func (c *CustomerController) RemoveCustomerAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	customerID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid customer ID: %w", err))
+		return
+	}
+	addressID, err := strconv.Atoi(vars["addressId"])
+	if err != nil {
+		HandleCustomerErrorResponse(w, fmt.Errorf("invalid address ID: %w", err))
+		return
+	}
+
+	removeCmd := commands.RemoveCustomerAddressCommand{CustomerID: customerID, AddressID: addressID}
+	_, err = c.commandBus.Dispatch(r.Context(), removeCmd)
+	if err != nil {
+		HandleCustomerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Address removed successfully"})
+}
+
 // HandleCustomerErrorResponse handles error responses for customer endpoints
 func HandleCustomerErrorResponse(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	var domainErr *domainerrors.DomainError
+	if errors.As(err, &domainErr) && domainErr.Code == domainerrors.ErrorCodeConflict {
+		status = http.StatusConflict
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 }
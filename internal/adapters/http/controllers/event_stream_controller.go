@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// streamHeartbeatInterval keeps idle streams open through proxies
+	streamHeartbeatInterval = 15 * time.Second
+	// streamWriteTimeout drops clients that stop reading
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry tells clients how long to wait before reconnecting, in milliseconds
+	streamRetry = 3000
+)
+
+type EventStreamController struct {
+	feed *event_store.Feed
+}
+
+func NewEventStreamController(feed *event_store.Feed) *EventStreamController {
+	return &EventStreamController{feed: feed}
+}
+
+// StreamEvents handles pushing newly stored domain events of the request's tenant as server-sent
+// events. The events are filtered by ?type= (repeatable or comma-separated), ?aggregateType= and ?aggregateId=. Clients
+// resume after the event in the Last-Event-ID header, or ?lastEventId= for the first connection,
+// and get the events they missed before the live ones. A client that falls behind is disconnected,
+// to resume from where it was when it reconnects.
+func (c *EventStreamController) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	filter := event_store.EventFilter{
+		TenantID:      requestctx.Tenant(r.Context()),
+		AggregateType: r.URL.Query().Get("aggregateType"),
+		AggregateID:   r.URL.Query().Get("aggregateId"),
+	}
+	for _, value := range r.URL.Query()["type"] {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				if filter.EventTypes == nil {
+					filter.EventTypes = make(map[string]bool)
+				}
+				filter.EventTypes[eventType] = true
+			}
+		}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var resumeAfter int64 = -1
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			writeErrorStatus(w, http.StatusBadRequest, errors.New("last event ID must be a non-negative number"))
+			return
+		}
+		resumeAfter = id
+	}
+
+	controller := http.NewResponseController(w)
+	subscription, position := c.feed.Subscribe(filter)
+	defer c.feed.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	// send writes a message and flushes it, giving up on clients that do not take it in time
+	send := func(message string) error {
+		controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprint(w, message); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+	sendEvent := func(event event_store.StoredEvent) error {
+		data, err := json.Marshal(dto.EventDTO{
+			ID:            event.ID,
+			EventType:     event.EventType,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			TenantID:      event.TenantID,
+			Actor:         event.Actor,
+			RequestID:     event.RequestID,
+			OccurredAt:    event.OccurredAt,
+			Data:          event.Data,
+		})
+		if err != nil {
+			return err
+		}
+		return send(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.EventType, data))
+	}
+
+	if err := send(fmt.Sprintf("retry: %d\n\n", streamRetry)); err != nil {
+		return
+	}
+
+	// The events up to the position of the subscription come from the log, later ones from the feed
+	if resumeAfter >= 0 && resumeAfter < position {
+		if err := c.feed.Replay(r.Context(), resumeAfter, position, filter, sendEvent); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := send(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events():
+			if !ok {
+				// Fell behind or the server is shutting down; the client reconnects with its Last-Event-ID
+				return
+			}
+			if err := sendEvent(event); err != nil {
+				return
+			}
+		}
+	}
+}
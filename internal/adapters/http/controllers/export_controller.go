@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"go-cqrs/internal/adapters/exports"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ExportController struct {
+	exporter *exports.Exporter
+}
+
+func NewExportController(exporter *exports.Exporter) *ExportController {
+	return &ExportController{exporter: exporter}
+}
+
+// ExportCustomers handles streaming all customers matching the query filters
+func (c *ExportController) ExportCustomers(w http.ResponseWriter, r *http.Request) {
+	filter, err := exports.ParseCustomerFilter(r.URL.Query())
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c.runExport(w, r, "customers", func(ctx context.Context, out io.Writer, format exports.Format) error {
+		_, err := c.exporter.ExportCustomers(ctx, out, format, filter)
+		return err
+	})
+}
+
+// ExportOrders handles streaming all orders matching the query filters
+func (c *ExportController) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	filter, err := exports.ParseOrderFilter(r.URL.Query())
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c.runExport(w, r, "orders", func(ctx context.Context, out io.Writer, format exports.Format) error {
+		_, err := c.exporter.ExportOrders(ctx, out, format, filter)
+		return err
+	})
+}
+
+// runExport negotiates the format and encoding of an export and streams it to the client.
+// The format comes from ?format= or the Accept header; the export is gzipped when the client accepts it.
+func (c *ExportController) runExport(w http.ResponseWriter, r *http.Request, name string, export func(ctx context.Context, out io.Writer, format exports.Format) error) {
+	format, err := negotiateExportFormat(r)
+	if err != nil {
+		writeErrorStatus(w, http.StatusNotAcceptable, err)
+		return
+	}
+
+	// Large exports outlast the server write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	out := &trackingWriter{w: w}
+	var body io.Writer = out
+	var gz *gzip.Writer
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(out)
+		body = gz
+	}
+
+	err = export(r.Context(), body, format)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	// Before anything was sent the failure can still be reported properly. Afterwards the
+	// connection is aborted so that the client does not take a truncated export for a full one.
+	if !out.written {
+		w.Header().Del("Content-Encoding")
+		w.Header().Del("Content-Disposition")
+		writeErrorStatus(w, http.StatusInternalServerError, err)
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// negotiateExportFormat picks the export format from ?format=, or else from the Accept header
+func negotiateExportFormat(r *http.Request) (exports.Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return exports.ParseFormat(name)
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if format, ok := exports.FormatForMediaType(mediaType); ok {
+			return format, nil
+		}
+	}
+	return exports.FormatCSV, nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// trackingWriter records whether any part of the response has been written
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.written = true
+	}
+	return t.w.Write(p)
+}
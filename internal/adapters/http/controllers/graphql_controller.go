@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-cqrs/internal/adapters/gql"
+	"go-cqrs/internal/adapters/http/dto"
+	"net/http"
+)
+
+type GraphQLController struct {
+	executor *gql.Executor
+}
+
+func NewGraphQLController(executor *gql.Executor) *GraphQLController {
+	return &GraphQLController{executor: executor}
+}
+
+// ExecuteGraphQL handles a GraphQL request. Requests that ran answer 200, with any field errors
+// next to the data; requests that did not parse, validate or stay within the limits answer 400.
+func (c *GraphQLController) ExecuteGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request dto.GraphQLRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.Query == "" {
+		writeErrorStatus(w, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+
+	result := c.executor.Execute(r.Context(), request)
+
+	status := http.StatusOK
+	if result.Data == nil {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/adapters/imports"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxImportSize bounds uploads imported while the client waits
+	maxImportSize = 64 << 20
+	// maxAsyncImportSize bounds uploads imported in the background
+	maxAsyncImportSize = 1 << 30
+)
+
+type ImportController struct {
+	customerRows imports.RowHandler
+	orderRows    imports.RowHandler
+	txManager    ports.TransactionManager
+	jobs         *imports.JobStore
+	runner       *imports.JobRunner
+}
+
+func NewImportController(commandBus *bus.CommandBus, txManager ports.TransactionManager, jobs *imports.JobStore, runner *imports.JobRunner) *ImportController {
+	return &ImportController{
+		customerRows: imports.CustomerRows(commandBus),
+		orderRows:    imports.OrderRows(commandBus),
+		txManager:    txManager,
+		jobs:         jobs,
+		runner:       runner,
+	}
+}
+
+// ImportCustomers handles creating customers from a CSV or NDJSON upload
+func (c *ImportController) ImportCustomers(w http.ResponseWriter, r *http.Request) {
+	c.runImport(w, r, "customers", c.customerRows)
+}
+
+// ImportOrders handles creating orders from a CSV or NDJSON upload
+func (c *ImportController) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	c.runImport(w, r, "orders", c.orderRows)
+}
+
+// GetImportJob handles retrieving the status and report of a background import
+func (c *ImportController) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := c.jobs.Get(mux.Vars(r)["jobId"])
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Import job not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// runImport imports an upload while the client waits, or in the background with ?async=true.
+// The format comes from ?format= or the Content-Type, the batch size from ?batchSize=.
+func (c *ImportController) runImport(w http.ResponseWriter, r *http.Request, kind string, handle imports.RowHandler) {
+	query := r.URL.Query()
+
+	format, err := imports.DetectFormat(r.Header.Get("Content-Type"), query.Get("format"))
+	if err != nil {
+		writeErrorStatus(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	batchSize := 0
+	if value := query.Get("batchSize"); value != "" {
+		if batchSize, err = strconv.Atoi(value); err != nil || batchSize <= 0 {
+			writeErrorStatus(w, http.StatusBadRequest, fmt.Errorf("invalid batchSize value %q", value))
+			return
+		}
+	}
+
+	async := false
+	if value := query.Get("async"); value != "" {
+		if async, err = strconv.ParseBool(value); err != nil {
+			writeErrorStatus(w, http.StatusBadRequest, fmt.Errorf("invalid async value: %w", err))
+			return
+		}
+	}
+
+	importer := imports.NewImporter(c.txManager, batchSize)
+	if async {
+		c.startImportJob(w, r, kind, format, importer, handle)
+		return
+	}
+
+	reader, err := imports.NewRowReader(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := importer.Run(r.Context(), reader, handle)
+	if err != nil {
+		// The rows before the unreadable part were imported, so report them too
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(importReadErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// startImportJob spools the upload to a temporary file and queues its import on the job runner,
+// answering with the job to poll at /api/imports/{jobId}, or 503 when too many imports are waiting
+func (c *ImportController) startImportJob(w http.ResponseWriter, r *http.Request, kind string, format imports.Format, importer *imports.Importer, handle imports.RowHandler) {
+	file, err := os.CreateTemp("", "go-cqrs-import-*")
+	if err != nil {
+		writeErrorStatus(w, http.StatusInternalServerError, fmt.Errorf("failed to store upload: %w", err))
+		return
+	}
+
+	_, err = io.Copy(file, http.MaxBytesReader(w, r.Body, maxAsyncImportSize))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		writeErrorStatus(w, importReadErrorStatus(err), fmt.Errorf("failed to store upload: %w", err))
+		return
+	}
+
+	// The job outlives the request and runs with the lifetime of the runner, but its events still
+	// record who started it and for which tenant
+	requestID := requestctx.RequestID(r.Context())
+	actor := requestctx.Actor(r.Context())
+	tenant := requestctx.Tenant(r.Context())
+
+	job, err := c.runner.Submit(kind, func(ctx context.Context) (*dto.ImportReportDTO, error) {
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		ctx = requestctx.WithRequestID(ctx, requestID)
+		ctx = requestctx.WithActor(ctx, actor)
+		ctx = requestctx.WithTenant(ctx, tenant)
+
+		reader, err := imports.NewRowReader(format, file)
+		if err != nil {
+			return nil, err
+		}
+		return importer.Run(ctx, reader, handle)
+	})
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		writeErrorStatus(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/imports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// importReadErrorStatus answers 413 for uploads over the size limit and 400 otherwise
+func importReadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func writeErrorStatus(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type InventoryController struct {
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
+}
+
+func NewInventoryController(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *InventoryController {
+	return &InventoryController{commandBus: commandBus, queryBus: queryBus}
+}
+
+// GetStockLevel handles retrieving the stock level of a SKU
+func (c *InventoryController) GetStockLevel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	level, err := bus.Ask[*dto.StockLevelDTO](r.Context(), c.queryBus, queries.GetStockLevelQuery{SKU: vars["sku"]})
+	if err != nil {
+		HandleInventoryErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, level)
+}
+
+// SetStockLevel handles recording the stock on hand for a SKU
+func (c *InventoryController) SetStockLevel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var setCmd commands.SetStockLevelCommand
+	err := json.NewDecoder(r.Body).Decode(&setCmd)
+	if err != nil {
+		HandleInventoryErrorResponse(w, err)
+		return
+	}
+	setCmd.SKU = vars["sku"]
+
+	level, err := bus.Result[*dto.StockLevelDTO](c.commandBus.Dispatch(r.Context(), setCmd))
+	if err != nil {
+		HandleInventoryErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(level)
+}
+
+// HandleInventoryErrorResponse handles error responses for inventory endpoints
+func HandleInventoryErrorResponse(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -3,8 +3,10 @@ package controllers
 import (
 	"encoding/json"
 	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
 	"go-cqrs/internal/adapters/cqrs/commands"
 	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
 	"net/http"
 	"strconv"
 
@@ -12,12 +14,12 @@ import (
 )
 
 type OrderController struct {
-	commandHandler *commands.OrderCommandHandler
-	queryHandler   *queries.OrderQueryHandler
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
 }
 
-func NewOrderController(commandHandler *commands.OrderCommandHandler, queryHandler *queries.OrderQueryHandler) *OrderController {
-	return &OrderController{commandHandler: commandHandler, queryHandler: queryHandler}
+func NewOrderController(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *OrderController {
+	return &OrderController{commandBus: commandBus, queryBus: queryBus}
 }
 
 // CreateOrder handles the creation of a new order
@@ -29,7 +31,7 @@ func (c *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID, err := c.commandHandler.HandleCreateOrderCommand(r.Context(), createCmd)
+	orderID, err := bus.Result[int](c.commandBus.Dispatch(r.Context(), createCmd))
 	if err != nil {
 		HandleOrderErrorResponse(w, err)
 		return
@@ -53,7 +55,7 @@ func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	getQuery := queries.GetOrderQuery{ID: id}
-	order, err := c.queryHandler.HandleGetOrderQuery(r.Context(), getQuery)
+	order, err := bus.Ask[*dto.OrderDTO](r.Context(), c.queryBus, getQuery)
 	if err != nil {
 		HandleOrderErrorResponse(w, err)
 		return
@@ -65,8 +67,26 @@ func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	writeView(w, r, order)
+}
+
+// GetOrderHistory handles retrieving the audit trail of an order
+func (c *OrderController) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleOrderErrorResponse(w, fmt.Errorf("invalid order ID: %w", err))
+		return
+	}
+
+	historyQuery := queries.GetOrderHistoryQuery{ID: id}
+	history, err := bus.Ask[*dto.HistoryDTO](r.Context(), c.queryBus, historyQuery)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, history)
 }
 
 // UpdateOrder handles updating an existing order
@@ -86,7 +106,7 @@ func (c *OrderController) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	updateCmd.ID = id
 
-	err = c.commandHandler.HandleUpdateOrderCommand(r.Context(), updateCmd)
+	_, err = c.commandBus.Dispatch(r.Context(), updateCmd)
 	if err != nil {
 		HandleOrderErrorResponse(w, err)
 		return
@@ -107,7 +127,7 @@ func (c *OrderController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	deleteCmd := commands.DeleteOrderCommand{ID: id}
-	err = c.commandHandler.HandleDeleteOrderCommand(r.Context(), deleteCmd)
+	_, err = c.commandBus.Dispatch(r.Context(), deleteCmd)
 	if err != nil {
 		HandleOrderErrorResponse(w, err)
 		return
@@ -144,7 +164,7 @@ func (c *OrderController) AssignCustomer(w http.ResponseWriter, r *http.Request)
 		CustomerID: customerID,
 	}
 
-	err = c.commandHandler.HandleAssignCustomerCommand(r.Context(), assignCmd)
+	_, err = c.commandBus.Dispatch(r.Context(), assignCmd)
 	if err != nil {
 		HandleOrderErrorResponse(w, err)
 		return
@@ -157,6 +177,88 @@ func (c *OrderController) AssignCustomer(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// AddOrderLine handles adding a line to an order
+func (c *OrderController) AddOrderLine(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleOrderErrorResponse(w, fmt.Errorf("invalid order ID: %w", err))
+		return
+	}
+
+	var addCmd commands.AddOrderLineCommand
+	err = json.NewDecoder(r.Body).Decode(&addCmd)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+	addCmd.OrderID = orderID
+
+	_, err = c.commandBus.Dispatch(r.Context(), addCmd)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Order line added successfully"})
+}
+
+// ChangeOrderLine handles changing an existing line of an order
+func (c *OrderController) ChangeOrderLine(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleOrderErrorResponse(w, fmt.Errorf("invalid order ID: %w", err))
+		return
+	}
+
+	var changeCmd commands.ChangeOrderLineCommand
+	err = json.NewDecoder(r.Body).Decode(&changeCmd)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+	changeCmd.OrderID = orderID
+	changeCmd.SKU = vars["sku"]
+
+	_, err = c.commandBus.Dispatch(r.Context(), changeCmd)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Order line updated successfully"})
+}
+
+// RemoveOrderLine handles removing a line from an order
+func (c *OrderController) RemoveOrderLine(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleOrderErrorResponse(w, fmt.Errorf("invalid order ID: %w", err))
+		return
+	}
+
+	removeCmd := commands.RemoveOrderLineCommand{
+		OrderID: orderID,
+		SKU:     vars["sku"],
+	}
+
+	_, err = c.commandBus.Dispatch(r.Context(), removeCmd)
+	if err != nil {
+		HandleOrderErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Order line removed successfully"})
+}
+
 // HandleOrderErrorResponse handles error responses for order endpoints
 func HandleOrderErrorResponse(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultProductPageSize = 50
+	maxProductPageSize     = 500
+)
+
+type ProductController struct {
+	commandBus *bus.CommandBus
+	queryBus   *bus.QueryBus
+}
+
+func NewProductController(commandBus *bus.CommandBus, queryBus *bus.QueryBus) *ProductController {
+	return &ProductController{commandBus: commandBus, queryBus: queryBus}
+}
+
+// CreateProduct handles adding a product to the catalog
+func (c *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var createCmd commands.CreateProductCommand
+	err := json.NewDecoder(r.Body).Decode(&createCmd)
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	productID, err := bus.Result[int](c.commandBus.Dispatch(r.Context(), createCmd))
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      productID,
+		"message": "Product created successfully",
+	})
+}
+
+// GetProduct handles retrieving a product by ID
+func (c *ProductController) GetProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleProductErrorResponse(w, fmt.Errorf("invalid product ID: %w", err))
+		return
+	}
+
+	product, err := bus.Ask[*dto.ProductDTO](r.Context(), c.queryBus, queries.GetProductQuery{ID: id})
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, product)
+}
+
+// ListProducts handles retrieving a page of products
+func (c *ProductController) ListProducts(w http.ResponseWriter, r *http.Request) {
+	listQuery := queries.ListProductsQuery{Limit: defaultProductPageSize}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 || value > maxProductPageSize {
+			HandleProductErrorResponse(w, fmt.Errorf("limit must be between 1 and %d", maxProductPageSize))
+			return
+		}
+		listQuery.Limit = value
+	}
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			HandleProductErrorResponse(w, fmt.Errorf("offset must be a non-negative number"))
+			return
+		}
+		listQuery.Offset = value
+	}
+
+	products, err := bus.Ask[[]dto.ProductDTO](r.Context(), c.queryBus, listQuery)
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, products)
+}
+
+// UpdateProduct handles updating an existing product
+func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleProductErrorResponse(w, fmt.Errorf("invalid product ID: %w", err))
+		return
+	}
+
+	var updateCmd commands.UpdateProductCommand
+	err = json.NewDecoder(r.Body).Decode(&updateCmd)
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+	updateCmd.ID = id
+
+	_, err = c.commandBus.Dispatch(r.Context(), updateCmd)
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Product updated successfully"})
+}
+
+// DeactivateProduct handles withdrawing a product from the catalog.
+// Products are never removed so that existing orders keep referring to them.
+func (c *ProductController) DeactivateProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		HandleProductErrorResponse(w, fmt.Errorf("invalid product ID: %w", err))
+		return
+	}
+
+	_, err = c.commandBus.Dispatch(r.Context(), commands.DeactivateProductCommand{ID: id})
+	if err != nil {
+		HandleProductErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Product deactivated successfully"})
+}
+
+// HandleProductErrorResponse handles error responses for product endpoints
+func HandleProductErrorResponse(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
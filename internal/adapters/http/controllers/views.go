@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Representations of the results of read endpoints
+const (
+	viewJSON = "application/json"
+	viewCSV  = "text/csv"
+)
+
+// writeView writes the result of a query in the representation the Accept header asks for,
+// JSON unless the client prefers CSV. With ?fields=id,name only the listed fields are written;
+// nested fields are named by their path, such as shippingAddress.city or lines.sku.
+func writeView(w http.ResponseWriter, r *http.Request, view interface{}) {
+	w.Header().Add("Vary", "Accept")
+
+	mediaType, ok := negotiateView(r.Header.Get("Accept"))
+	if !ok {
+		writeErrorStatus(w, http.StatusNotAcceptable, fmt.Errorf("supported media types are %s and %s", viewJSON, viewCSV))
+		return
+	}
+
+	fields, err := viewFields(reflect.TypeOf(view), r.URL.Query().Get("fields"))
+	if err != nil {
+		writeErrorStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Views go through their JSON form, so projections and CSV columns use the JSON field names
+	var document interface{}
+	if len(fields) > 0 || mediaType == viewCSV {
+		if document, err = decodeView(view); err != nil {
+			writeErrorStatus(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if mediaType == viewCSV {
+		if len(fields) == 0 {
+			fields = jsonFieldNames(reflect.TypeOf(view))
+		}
+		body, err := viewCSVRows(document, fields)
+		if err != nil {
+			writeErrorStatus(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Write(body)
+		return
+	}
+
+	if len(fields) > 0 {
+		view = projectView(document, fields)
+	}
+	w.Header().Set("Content-Type", viewJSON)
+	json.NewEncoder(w).Encode(view)
+}
+
+// negotiateView picks the representation with the highest quality in an Accept header
+func negotiateView(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return viewJSON, true
+	}
+
+	type candidate struct {
+		mediaType string
+		quality   float64
+	}
+	var candidates []candidate
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case viewJSON, "application/*", "*/*":
+			candidates = append(candidates, candidate{viewJSON, quality})
+		case viewCSV, "text/*":
+			candidates = append(candidates, candidate{viewCSV, quality})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+	if len(candidates) == 0 || candidates[0].quality <= 0 {
+		return "", false
+	}
+	return candidates[0].mediaType, true
+}
+
+// viewFields parses a ?fields= list, checking every field against the JSON fields of the view type
+func viewFields(viewType reflect.Type, list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		fieldType := viewType
+		for _, name := range strings.Split(field, ".") {
+			fieldType = elemType(fieldType)
+			next, ok := jsonField(fieldType, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown field %q, available fields are %s", field, strings.Join(jsonFieldNames(fieldType), ", "))
+			}
+			fieldType = next
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// elemType strips pointers and slices from a type
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonField returns the type of the struct field with the given JSON name
+func jsonField(t reflect.Type, name string) (reflect.Type, bool) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return t.Field(i).Type, true
+		}
+	}
+	return nil, false
+}
+
+// jsonFieldNames returns the JSON names of the fields of a view type in declaration order
+func jsonFieldNames(t reflect.Type) []string {
+	t = elemType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// decodeView turns a view into its generic JSON form
+func decodeView(view interface{}) (interface{}, error) {
+	data, err := json.Marshal(view)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// viewObject is a JSON object that keeps its fields in the order they were requested in
+type viewObject struct {
+	names  []string
+	values map[string]interface{}
+}
+
+func (o *viewObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range o.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(o.values[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// projectView keeps the listed fields of a decoded view, or of every element of a list.
+// Fields the view omitted, such as empty optional ones, are left out.
+func projectView(document interface{}, fields []string) interface{} {
+	switch value := document.(type) {
+	case []interface{}:
+		projected := make([]interface{}, len(value))
+		for i, element := range value {
+			projected[i] = projectView(element, fields)
+		}
+		return projected
+	case map[string]interface{}:
+		// Group nested fields under their top-level field, in the order the fields were listed
+		object := &viewObject{values: make(map[string]interface{})}
+		nested := make(map[string][]string)
+		whole := make(map[string]bool)
+		for _, field := range fields {
+			name, rest, isNested := strings.Cut(field, ".")
+			if _, ok := value[name]; !ok {
+				continue
+			}
+			if _, seen := object.values[name]; !seen {
+				object.names = append(object.names, name)
+				object.values[name] = nil
+			}
+			if isNested {
+				nested[name] = append(nested[name], rest)
+			} else {
+				whole[name] = true
+			}
+		}
+
+		for _, name := range object.names {
+			if whole[name] {
+				object.values[name] = value[name]
+			} else {
+				object.values[name] = projectView(value[name], nested[name])
+			}
+		}
+		return object
+	}
+	return document
+}
+
+// viewCSVRows writes a decoded view, or every element of a list, as a CSV row with a column per field.
+// Nested objects and lists are written as JSON.
+func viewCSVRows(document interface{}, fields []string) ([]byte, error) {
+	rows, ok := document.([]interface{})
+	if !ok {
+		rows = []interface{}{document}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(fields); err != nil {
+		return nil, err
+	}
+
+	record := make([]string, len(fields))
+	for _, row := range rows {
+		for i, field := range fields {
+			cell, err := csvCell(lookupField(row, field))
+			if err != nil {
+				return nil, err
+			}
+			record[i] = cell
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// lookupField returns the value of a field path in a decoded view, collecting it from every
+// element when the path goes through a list
+func lookupField(document interface{}, field string) interface{} {
+	name, rest, isNested := strings.Cut(field, ".")
+
+	switch value := document.(type) {
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, element := range value {
+			values[i] = lookupField(element, field)
+		}
+		return values
+	case map[string]interface{}:
+		if !isNested {
+			return value[name]
+		}
+		return lookupField(value[name], rest)
+	}
+	return nil
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	data, err := json.Marshal(value)
+	return string(data), err
+}
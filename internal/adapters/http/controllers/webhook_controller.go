@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 500
+)
+
+type WebhookController struct {
+	webhooks ports.WebhookUseCase
+}
+
+func NewWebhookController(webhooks ports.WebhookUseCase) *WebhookController {
+	return &WebhookController{webhooks: webhooks}
+}
+
+// CreateWebhook handles subscribing a URL to domain events. The response carries the signing
+// secret, which is not shown again.
+func (c *WebhookController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var request dto.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	webhook, err := c.webhooks.CreateWebhook(r.Context(), request)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// ListWebhooks handles retrieving every webhook
+func (c *WebhookController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := c.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, webhooks)
+}
+
+// GetWebhook handles retrieving a webhook by ID
+func (c *WebhookController) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		HandleWebhookErrorResponse(w, fmt.Errorf("invalid webhook ID: %w", err))
+		return
+	}
+
+	webhook, err := c.webhooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, webhook)
+}
+
+// UpdateWebhook handles replacing the URL, event types and state of a webhook, and rotating its secret
+func (c *WebhookController) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		HandleWebhookErrorResponse(w, fmt.Errorf("invalid webhook ID: %w", err))
+		return
+	}
+
+	var request dto.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+	request.ID = id
+
+	webhook, err := c.webhooks.UpdateWebhook(r.Context(), request)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// DeleteWebhook handles removing a webhook together with its delivery log
+func (c *WebhookController) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		HandleWebhookErrorResponse(w, fmt.Errorf("invalid webhook ID: %w", err))
+		return
+	}
+
+	if err := c.webhooks.DeleteWebhook(r.Context(), id); err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries handles retrieving a page of the deliveries of a webhook, newest first
+func (c *WebhookController) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		HandleWebhookErrorResponse(w, fmt.Errorf("invalid webhook ID: %w", err))
+		return
+	}
+
+	limit, offset := defaultDeliveryPageSize, 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxDeliveryPageSize {
+			HandleWebhookErrorResponse(w, fmt.Errorf("limit must be between 1 and %d", maxDeliveryPageSize))
+			return
+		}
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			HandleWebhookErrorResponse(w, fmt.Errorf("offset must be a non-negative number"))
+			return
+		}
+	}
+
+	deliveries, err := c.webhooks.ListWebhookDeliveries(r.Context(), id, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, deliveries)
+}
+
+// GetWebhookDelivery handles retrieving a delivery with its payload and attempts
+func (c *WebhookController) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, err := deliveryIDs(r)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	delivery, err := c.webhooks.GetWebhookDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	writeView(w, r, delivery)
+}
+
+// RedeliverWebhookDelivery handles queueing a delivery again, typically one that ran out of attempts
+func (c *WebhookController) RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, err := deliveryIDs(r)
+	if err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	if err := c.webhooks.RedeliverWebhookDelivery(r.Context(), id, deliveryID); err != nil {
+		HandleWebhookErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Delivery queued for redelivery"})
+}
+
+// deliveryIDs reads the webhook and delivery IDs of a delivery route
+func deliveryIDs(r *http.Request) (int, int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid webhook ID: %w", err)
+	}
+	deliveryID, err := strconv.Atoi(vars["deliveryId"])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid delivery ID: %w", err)
+	}
+	return id, deliveryID, nil
+}
+
+// HandleWebhookErrorResponse handles error responses for webhook endpoints
+func HandleWebhookErrorResponse(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	var domainErr *domainerrors.DomainError
+	if errors.As(err, &domainErr) && domainErr.Code == domainerrors.ErrorCodeNotFound {
+		status = http.StatusNotFound
+	}
+
+	writeErrorStatus(w, status, err)
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"encoding/json"
+)
+
+// Batch command statuses
+const (
+	BatchCommandSucceeded  = "succeeded"
+	BatchCommandFailed     = "failed"
+	BatchCommandRolledBack = "rolled_back"
+	BatchCommandSkipped    = "skipped"
+)
+
+// BatchRequest represents an ordered list of commands run together.
+// Atomic batches, the default, keep nothing unless every command succeeds.
+type BatchRequest struct {
+	Atomic   *bool             `json:"atomic,omitempty"`
+	Commands []BatchCommandDTO `json:"commands"`
+}
+
+// IsAtomic reports whether the batch must succeed as a whole
+func (r BatchRequest) IsAtomic() bool {
+	return r.Atomic == nil || *r.Atomic
+}
+
+// BatchCommandDTO is one command of a batch. Later commands refer to the ID the command
+// produced with {"$ref": "<id>"} anywhere in their payload.
+type BatchCommandDTO struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// BatchResponseDTO reports the outcome of every command of a batch
+type BatchResponseDTO struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded bool             `json:"succeeded"`
+	Results   []BatchResultDTO `json:"results"`
+}
+
+// BatchResultDTO reports the outcome of one command of a batch
+type BatchResultDTO struct {
+	Index    int         `json:"index"`
+	ID       string      `json:"id,omitempty"`
+	Type     string      `json:"type"`
+	Status   string      `json:"status"`
+	ResultID int         `json:"resultId,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
@@ -2,19 +2,37 @@ package dto
 
 import (
 	"go-cqrs/internal/domain"
+	"time"
 )
 
 // CustomerDTO represents the data transfer object for Customer
 type CustomerDTO struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Email      string       `json:"email"`
+	Phone      string       `json:"phone,omitempty"`
+	Addresses  []AddressDTO `json:"addresses"`
+	MergedInto *int         `json:"mergedInto,omitempty"`
+}
+
+// AddressDTO represents the data transfer object for Address
+type AddressDTO struct {
+	ID         int    `json:"id,omitempty"`
+	Type       string `json:"type"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	Country    string `json:"country"`
+	IsDefault  bool   `json:"isDefault"`
 }
 
 // CreateCustomerRequest represents a request to create a customer
 type CreateCustomerRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
 }
 
 // UpdateCustomerRequest represents a request to update a customer
@@ -22,20 +40,87 @@ type UpdateCustomerRequest struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// MergeCustomersRequest represents a request to merge a duplicate customer into a survivor
+type MergeCustomersRequest struct {
+	SurvivorID  int  `json:"survivorId"`
+	DuplicateID int  `json:"duplicateId"`
+	DryRun      bool `json:"dryRun"`
+}
+
+// MergeReportDTO describes what a customer merge changed, or would change for a dry run
+type MergeReportDTO struct {
+	SurvivorID         int          `json:"survivorId"`
+	DuplicateID        int          `json:"duplicateId"`
+	DryRun             bool         `json:"dryRun"`
+	ReassignedOrderIDs []int        `json:"reassignedOrderIds"`
+	CopiedAddresses    []AddressDTO `json:"copiedAddresses"`
+	AdoptedPhone       string       `json:"adoptedPhone,omitempty"`
+}
+
+// CustomerSummaryDTO aggregates the orders of a customer
+type CustomerSummaryDTO struct {
+	CustomerID    int        `json:"customerId"`
+	OrderCount    int        `json:"orderCount"`
+	TotalQuantity int        `json:"totalQuantity"`
+	FirstOrderAt  *time.Time `json:"firstOrderAt,omitempty"`
+	LastOrderAt   *time.Time `json:"lastOrderAt,omitempty"`
 }
 
 // ToCustomerDTO converts a domain Customer to a CustomerDTO
 func ToCustomerDTO(customer domain.Customer) CustomerDTO {
+	addresses := make([]AddressDTO, len(customer.Addresses))
+	for i, address := range customer.Addresses {
+		addresses[i] = ToAddressDTO(address)
+	}
+
 	return CustomerDTO{
-		ID:    customer.ID,
-		Name:  customer.Name,
-		Email: customer.Email,
+		ID:         customer.ID,
+		Name:       customer.Name,
+		Email:      customer.Email.String(),
+		Phone:      customer.Phone,
+		Addresses:  addresses,
+		MergedInto: customer.MergedInto,
+	}
+}
+
+// ToAddressDTO converts a domain Address to an AddressDTO
+func ToAddressDTO(address domain.Address) AddressDTO {
+	return AddressDTO{
+		ID:         address.ID,
+		Type:       string(address.Type),
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		IsDefault:  address.IsDefault,
+	}
+}
+
+// ToDomain converts an AddressDTO to a domain Address
+func (dto AddressDTO) ToDomain() (*domain.Address, error) {
+	address, err := domain.NewAddress(domain.AddressType(dto.Type), dto.Line1, dto.Line2, dto.City, dto.Region, dto.PostalCode, dto.Country, dto.IsDefault)
+	if err != nil {
+		return nil, err
 	}
+	address.ID = dto.ID
+	return address, nil
 }
 
 // ToDomain converts a CustomerDTO to a domain Customer
 func (dto CreateCustomerRequest) ToDomain() (*domain.Customer, error) {
-	return domain.NewCustomer(dto.Name, dto.Email)
+	customer, err := domain.NewCustomer(dto.Name, dto.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err := customer.SetPhone(dto.Phone); err != nil {
+		return nil, err
+	}
+	return customer, nil
 }
 
 // ToDomain converts an UpdateCustomerRequest to a domain Customer
@@ -44,6 +129,9 @@ func (dto UpdateCustomerRequest) ToDomain() (*domain.Customer, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := customer.SetPhone(dto.Phone); err != nil {
+		return nil, err
+	}
 	customer.ID = dto.ID
 	return customer, nil
 }
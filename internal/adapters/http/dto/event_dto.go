@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventDTO represents a stored domain event as pushed to stream subscribers
+type EventDTO struct {
+	ID            int64           `json:"id"`
+	EventType     string          `json:"eventType"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	TenantID      string          `json:"tenantId"`
+	Actor         string          `json:"actor,omitempty"`
+	RequestID     string          `json:"requestId,omitempty"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Data          json.RawMessage `json:"data"`
+}
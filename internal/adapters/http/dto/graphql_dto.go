@@ -0,0 +1,8 @@
+package dto
+
+// GraphQLRequest represents a GraphQL query or mutation with its variables
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+)
+
+// HistoryDTO represents the audit trail of a single entity
+type HistoryDTO struct {
+	EntityType string            `json:"entityType"`
+	EntityID   int               `json:"entityId"`
+	Entries    []HistoryEntryDTO `json:"entries"`
+}
+
+// HistoryEntryDTO represents one recorded change to an entity
+type HistoryEntryDTO struct {
+	EventID    int64            `json:"eventId"`
+	EventType  string           `json:"eventType"`
+	Actor      string           `json:"actor"`
+	RequestID  string           `json:"requestId,omitempty"`
+	OccurredAt time.Time        `json:"occurredAt"`
+	Changes    []FieldChangeDTO `json:"changes"`
+}
+
+// FieldChangeDTO represents the change of a single field between two states
+type FieldChangeDTO struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
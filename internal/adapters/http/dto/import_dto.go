@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+)
+
+// Import row statuses
+const (
+	ImportRowCreated = "created"
+	ImportRowFailed  = "failed"
+)
+
+// Import job statuses
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportReportDTO reports the outcome of every row of a bulk import
+type ImportReportDTO struct {
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Rows      []ImportRowDTO `json:"rows"`
+}
+
+// ImportRowDTO reports the outcome of one row of a bulk import.
+// Row counts data rows from 1, not counting a CSV header.
+type ImportRowDTO struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportJobDTO represents a bulk import running in the background
+type ImportJobDTO struct {
+	ID         string           `json:"id"`
+	Kind       string           `json:"kind"`
+	Status     string           `json:"status"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	FinishedAt *time.Time       `json:"finishedAt,omitempty"`
+	Report     *ImportReportDTO `json:"report,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"go-cqrs/internal/domain"
+)
+
+// StockLevelDTO represents the data transfer object for StockLevel
+type StockLevelDTO struct {
+	SKU       string `json:"sku"`
+	OnHand    int    `json:"onHand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+// SetStockLevelRequest represents a request to record the stock on hand for a SKU
+type SetStockLevelRequest struct {
+	SKU    string `json:"sku"`
+	OnHand int    `json:"onHand"`
+}
+
+// ToStockLevelDTO converts a domain StockLevel to a StockLevelDTO
+func ToStockLevelDTO(level domain.StockLevel) StockLevelDTO {
+	return StockLevelDTO{
+		SKU:       level.SKU,
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+	}
+}
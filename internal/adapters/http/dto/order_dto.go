@@ -6,26 +6,49 @@ import (
 
 // OrderDTO represents the data transfer object for Order
 type OrderDTO struct {
-	ID         int    `json:"id"`
-	CustomerID *int   `json:"customerId,omitempty"`
-	Product    string `json:"product"`
-	Quantity   int    `json:"quantity"`
-	Status     string `json:"status,omitempty"`
+	ID         int            `json:"id"`
+	CustomerID *int           `json:"customerId,omitempty"`
+	Product    string         `json:"product"`
+	Quantity   int            `json:"quantity"`
+	Currency   string         `json:"currency"`
+	Total      int64          `json:"total"`
+	Lines      []OrderLineDTO `json:"lines"`
+	Status     string         `json:"status,omitempty"`
+
+	ShippingAddress *AddressDTO `json:"shippingAddress,omitempty"`
+}
+
+// OrderLineDTO represents the data transfer object for OrderLine.
+// Amounts are in minor units of the order currency.
+type OrderLineDTO struct {
+	SKU         string `json:"sku"`
+	Description string `json:"description,omitempty"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int64  `json:"unitPrice"`
+	Subtotal    int64  `json:"subtotal"`
 }
 
-// CreateOrderRequest represents a request to create an order
+// CreateOrderRequest represents a request to create an order.
+// Lines take precedence over Product and Quantity, which remain for single-product clients.
+// ShippingAddressID picks one of the customer's shipping addresses instead of their default one.
 type CreateOrderRequest struct {
-	CustomerID *int   `json:"customerId,omitempty"`
-	Product    string `json:"product"`
-	Quantity   int    `json:"quantity"`
+	CustomerID        *int           `json:"customerId,omitempty"`
+	Product           string         `json:"product,omitempty"`
+	Quantity          int            `json:"quantity,omitempty"`
+	Currency          string         `json:"currency,omitempty"`
+	Lines             []OrderLineDTO `json:"lines,omitempty"`
+	ShippingAddressID *int           `json:"shippingAddressId,omitempty"`
 }
 
-// UpdateOrderRequest represents a request to update an order
+// UpdateOrderRequest represents a request to update an order.
+// Lines take precedence over Product and Quantity, which remain for single-product clients.
 type UpdateOrderRequest struct {
-	ID         int    `json:"id"`
-	CustomerID *int   `json:"customerId,omitempty"`
-	Product    string `json:"product"`
-	Quantity   int    `json:"quantity"`
+	ID         int            `json:"id"`
+	CustomerID *int           `json:"customerId,omitempty"`
+	Product    string         `json:"product,omitempty"`
+	Quantity   int            `json:"quantity,omitempty"`
+	Currency   string         `json:"currency,omitempty"`
+	Lines      []OrderLineDTO `json:"lines,omitempty"`
 }
 
 // AssignCustomerRequest represents a request to assign a customer to an order
@@ -36,17 +59,52 @@ type AssignCustomerRequest struct {
 
 // ToOrderDTO converts a domain Order to an OrderDTO
 func ToOrderDTO(order domain.Order) OrderDTO {
+	var shippingAddress *AddressDTO
+	if order.ShippingAddress != nil {
+		address := ToAddressDTO(*order.ShippingAddress)
+		shippingAddress = &address
+	}
+
 	return OrderDTO{
 		ID:         order.ID,
 		CustomerID: order.CustomerID,
 		Product:    order.Product,
 		Quantity:   order.Quantity,
+		Currency:   order.Currency,
+		Total:      order.Total().Amount,
+		Lines:      ToOrderLineDTOs(order.Lines),
+
+		ShippingAddress: shippingAddress,
+	}
+}
+
+// ToOrderLineDTOs converts domain OrderLines to OrderLineDTOs
+func ToOrderLineDTOs(lines []domain.OrderLine) []OrderLineDTO {
+	result := make([]OrderLineDTO, len(lines))
+	for i, line := range lines {
+		result[i] = OrderLineDTO{
+			SKU:         line.SKU,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice.Amount,
+			Subtotal:    line.Subtotal().Amount,
+		}
+	}
+	return result
+}
+
+// ToDomain converts an OrderLineDTO priced in the given currency to a domain OrderLine
+func (dto OrderLineDTO) ToDomain(currency string) (domain.OrderLine, error) {
+	unitPrice, err := domain.NewMoney(dto.UnitPrice, currency)
+	if err != nil {
+		return domain.OrderLine{}, err
 	}
+	return domain.NewOrderLine(dto.SKU, dto.Description, dto.Quantity, unitPrice)
 }
 
 // ToDomain converts a CreateOrderRequest to a domain Order
 func (dto CreateOrderRequest) ToDomain() (*domain.Order, error) {
-	order, err := domain.NewOrder(dto.Product, dto.Quantity)
+	order, err := newDomainOrder(dto.Product, dto.Quantity, dto.Currency, dto.Lines)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +119,7 @@ func (dto CreateOrderRequest) ToDomain() (*domain.Order, error) {
 
 // ToDomain converts an UpdateOrderRequest to a domain Order
 func (dto UpdateOrderRequest) ToDomain() (*domain.Order, error) {
-	order, err := domain.NewOrder(dto.Product, dto.Quantity)
+	order, err := newDomainOrder(dto.Product, dto.Quantity, dto.Currency, dto.Lines)
 	if err != nil {
 		return nil, err
 	}
@@ -74,3 +132,29 @@ func (dto UpdateOrderRequest) ToDomain() (*domain.Order, error) {
 	}
 	return order, nil
 }
+
+// newDomainOrder builds an order from its lines, or from a single product when no lines are given
+func newDomainOrder(product string, quantity int, currency string, lines []OrderLineDTO) (*domain.Order, error) {
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	if len(lines) == 0 {
+		// Validate the single-product fields before turning them into a line
+		if _, err := domain.NewOrder(product, quantity); err != nil {
+			return nil, err
+		}
+		lines = []OrderLineDTO{{SKU: product, Description: product, Quantity: quantity}}
+	}
+
+	domainLines := make([]domain.OrderLine, len(lines))
+	for i, line := range lines {
+		domainLine, err := line.ToDomain(currency)
+		if err != nil {
+			return nil, err
+		}
+		domainLines[i] = domainLine
+	}
+
+	return domain.NewOrderWithLines(domainLines)
+}
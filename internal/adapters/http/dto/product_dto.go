@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"go-cqrs/internal/domain"
+)
+
+// ProductDTO represents the data transfer object for Product.
+// Price is in minor units of Currency.
+type ProductDTO struct {
+	ID       int    `json:"id"`
+	SKU      string `json:"sku"`
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// CreateProductRequest represents a request to add a product to the catalog
+type CreateProductRequest struct {
+	SKU      string `json:"sku"`
+	Name     string `json:"name"`
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// UpdateProductRequest represents a request to update a product
+type UpdateProductRequest struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// ToProductDTO converts a domain Product to a ProductDTO
+func ToProductDTO(product domain.Product) ProductDTO {
+	return ProductDTO{
+		ID:       product.ID,
+		SKU:      product.SKU,
+		Name:     product.Name,
+		Active:   product.Active,
+		Price:    product.Price.Amount,
+		Currency: product.Price.Currency,
+	}
+}
+
+// ToDomain converts a CreateProductRequest to a domain Product
+func (dto CreateProductRequest) ToDomain() (*domain.Product, error) {
+	price, err := domain.NewMoney(dto.Price, dto.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewProduct(dto.SKU, dto.Name, price)
+}
@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"encoding/json"
+	"go-cqrs/internal/domain"
+	"time"
+)
+
+// WebhookDTO represents the data transfer object for Webhook.
+// The secret is only returned when the webhook is created or its secret is changed.
+type WebhookDTO struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CreateWebhookRequest represents a request to subscribe a URL to domain events.
+// No event types subscribe to every event type; a secret is generated when none is given.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret"`
+}
+
+// UpdateWebhookRequest represents a request to update a webhook. An empty secret keeps the current one.
+type UpdateWebhookRequest struct {
+	ID         int      `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret"`
+	Active     bool     `json:"active"`
+}
+
+// WebhookDeliveryDTO represents the delivery of an event to a webhook, with its attempts when a
+// single delivery is requested
+type WebhookDeliveryDTO struct {
+	ID             int                 `json:"id"`
+	WebhookID      int                 `json:"webhookId"`
+	EventID        int64               `json:"eventId"`
+	EventType      string              `json:"eventType"`
+	Status         string              `json:"status"`
+	Attempts       int                 `json:"attempts"`
+	NextAttemptAt  *time.Time          `json:"nextAttemptAt,omitempty"`
+	LastStatusCode int                 `json:"lastStatusCode,omitempty"`
+	LastError      string              `json:"lastError,omitempty"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	DeliveredAt    *time.Time          `json:"deliveredAt,omitempty"`
+	Payload        json.RawMessage     `json:"payload,omitempty"`
+	AttemptLog     []WebhookAttemptDTO `json:"attemptLog,omitempty"`
+}
+
+// WebhookAttemptDTO represents one attempt at a delivery
+type WebhookAttemptDTO struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"durationMs"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+// ToWebhookDTO converts a domain Webhook to a WebhookDTO, leaving out its secret
+func ToWebhookDTO(webhook domain.Webhook) WebhookDTO {
+	eventTypes := webhook.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return WebhookDTO{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: eventTypes,
+		Active:     webhook.Active,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+// ToWebhookDeliveryDTO converts a domain WebhookDelivery to a WebhookDeliveryDTO.
+// Only pending deliveries have a next attempt.
+func ToWebhookDeliveryDTO(delivery domain.WebhookDelivery) WebhookDeliveryDTO {
+	deliveryDTO := WebhookDeliveryDTO{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		deliveryDTO.NextAttemptAt = &nextAttemptAt
+	}
+	return deliveryDTO
+}
+
+// ToWebhookAttemptDTO converts a domain WebhookAttempt to a WebhookAttemptDTO
+func ToWebhookAttemptDTO(attempt domain.WebhookAttempt) WebhookAttemptDTO {
+	return WebhookAttemptDTO{
+		Attempt:     attempt.Attempt,
+		StatusCode:  attempt.StatusCode,
+		Error:       attempt.Error,
+		DurationMS:  attempt.Duration.Milliseconds(),
+		AttemptedAt: attempt.AttemptedAt,
+	}
+}
+
+// ToDomain converts a CreateWebhookRequest to a domain Webhook
+func (dto CreateWebhookRequest) ToDomain() (*domain.Webhook, error) {
+	return domain.NewWebhook(dto.URL, dto.EventTypes, dto.Secret)
+}
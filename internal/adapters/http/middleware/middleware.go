@@ -1,9 +1,22 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+const (
+	// RequestIDHeader carries the request ID in both directions
+	RequestIDHeader = "X-Request-ID"
+	// ActorHeader identifies who is making the request
+	ActorHeader = "X-Actor"
 )
 
 // LoggingMiddleware logs information about each request
@@ -29,22 +42,95 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// CorsMiddleware handles CORS headers
-func CorsMiddleware(next http.Handler) http.Handler {
+// RequestContextMiddleware stores the request ID and actor in the request context
+// so they can be recorded alongside the events produced by the request
+func RequestContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
+		ctx := requestctx.WithRequestID(r.Context(), requestID)
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			ctx = requestctx.WithActor(ctx, actor)
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// newRequestID generates a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// CorsPolicy holds the origins allowed to call the API from a browser. They can be replaced
+// while requests are being served.
+type CorsPolicy struct {
+	origins atomic.Pointer[map[string]bool]
+}
+
+// NewCorsPolicy creates a policy allowing the given origins, any origin when one of them is *
+func NewCorsPolicy(origins []string) *CorsPolicy {
+	p := &CorsPolicy{}
+	p.SetOrigins(origins)
+	return p
+}
+
+// SetOrigins replaces the allowed origins
+func (p *CorsPolicy) SetOrigins(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[origin] = true
+		}
+	}
+	p.origins.Store(&allowed)
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request origin, or an empty
+// string when the origin is not allowed
+func (p *CorsPolicy) allowOrigin(origin string) string {
+	allowed := *p.origins.Load()
+	if allowed["*"] {
+		return "*"
+	}
+	if origin != "" && allowed[origin] {
+		return origin
+	}
+	return ""
+}
+
+// CorsMiddleware handles CORS headers, letting the origins of the policy call the API
+func CorsMiddleware(policy *CorsPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allow := policy.allowOrigin(r.Header.Get("Origin"))
+			if allow != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			if allow != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allow)
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Actor, X-Tenant-ID")
+
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // responseWriter is a custom response writer that captures the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -57,6 +143,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap returns the wrapped response writer, so that http.ResponseController can flush
+// streamed responses and extend their write deadline
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // AuthMiddleware is an example middleware for authentication.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+// TenantHeader names the tenant a request is made for
+const TenantHeader = "X-Tenant-ID"
+
+// ErrTenantMismatch is returned for requests whose tenant header names another tenant than their bearer token
+var ErrTenantMismatch = errors.New("tenant header does not match the tenant of the token")
+
+// tenantPattern accepts tenant IDs that are also valid host name labels, so that every tenant can have a subdomain
+var tenantPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TenantResolver finds the tenant of a request in, by order of precedence, a claim of the bearer
+// token, the X-Tenant-ID header and the subdomain of the host. A header naming another tenant
+// than the token is rejected, so that callers cannot step out of the tenant of their token. The
+// bearer token is only decoded, not verified: authentication is expected to happen in front of
+// the service.
+type TenantResolver struct {
+	// Claim is the bearer token claim naming the tenant; no claim is read when it is empty
+	Claim string
+	// BaseDomains are the domains whose subdomains name a tenant, such as example.com for acme.example.com
+	BaseDomains []string
+	// Allowed lists the known tenants; any well-formed tenant is accepted when it is empty
+	Allowed map[string]bool
+}
+
+// NewTenantResolver creates a resolver reading the given claim and subdomains of the given base
+// domains, accepting only the allowed tenants unless none are given
+func NewTenantResolver(claim string, baseDomains, allowed []string) TenantResolver {
+	resolver := TenantResolver{Claim: claim}
+	for _, domain := range baseDomains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			resolver.BaseDomains = append(resolver.BaseDomains, domain)
+		}
+	}
+	for _, tenant := range allowed {
+		if tenant = strings.ToLower(strings.TrimSpace(tenant)); tenant != "" {
+			if resolver.Allowed == nil {
+				resolver.Allowed = make(map[string]bool)
+			}
+			resolver.Allowed[tenant] = true
+		}
+	}
+	return resolver
+}
+
+// Resolve returns the tenant of a request, requestctx.DefaultTenant when it names none
+func (t TenantResolver) Resolve(r *http.Request) (string, error) {
+	return t.ResolveFrom(r.Header.Get(TenantHeader), r.Header.Get("Authorization"), r.Host)
+}
+
+// ResolveFrom returns the tenant named by the value of a tenant header, an authorization header
+// or a host, requestctx.DefaultTenant when none of them names one. It lets other transports
+// resolve tenants the way HTTP requests do.
+func (t TenantResolver) ResolveFrom(header, authorization, host string) (string, error) {
+	tenant := t.fromToken(authorization)
+	if tenant != "" && header != "" && !strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(tenant)) {
+		return "", ErrTenantMismatch
+	}
+	if tenant == "" {
+		tenant = header
+	}
+	if tenant == "" {
+		tenant = t.fromHost(host)
+	}
+	if tenant == "" {
+		return requestctx.DefaultTenant, nil
+	}
+
+	tenant = strings.ToLower(strings.TrimSpace(tenant))
+	if !tenantPattern.MatchString(tenant) {
+		return "", errors.New("malformed tenant ID")
+	}
+	if len(t.Allowed) > 0 && !t.Allowed[tenant] {
+		return "", errors.New("unknown tenant")
+	}
+	return tenant, nil
+}
+
+// fromToken returns the tenant claim of a bearer token authorization, or an empty string
+func (t TenantResolver) fromToken(authorization string) string {
+	if t.Claim == "" {
+		return ""
+	}
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	tenant, _ := claims[t.Claim].(string)
+	return tenant
+}
+
+// fromHost returns the label of the host right under one of the base domains, or an empty string
+func (t TenantResolver) fromHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for _, domain := range t.BaseDomains {
+		prefix, ok := strings.CutSuffix(host, "."+domain)
+		if !ok {
+			continue
+		}
+		return prefix[strings.LastIndex(prefix, ".")+1:]
+	}
+	return ""
+}
+
+// TenantMiddleware stores the tenant of every request in its context, turning away the requests
+// naming a malformed or unknown tenant, and those whose header and token name different tenants
+func TenantMiddleware(resolver TenantResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := resolver.Resolve(r)
+			if errors.Is(err, ErrTenantMismatch) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(requestctx.WithTenant(r.Context(), tenant)))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"go-cqrs/internal/domain"
+)
+
+// constraint narrows the schema of a property to what the domain accepts
+type constraint func(*Schema)
+
+func minimum(value int64) constraint {
+	return func(s *Schema) { s.Minimum = &value }
+}
+
+func nonEmpty(s *Schema) {
+	length := 1
+	s.MinLength = &length
+}
+
+func maxItems(count int) constraint {
+	return func(s *Schema) { s.MaxItems = &count }
+}
+
+func pattern(expr string) constraint {
+	return func(s *Schema) { s.Pattern = expr }
+}
+
+func format(name string) constraint {
+	return func(s *Schema) { s.Format = name }
+}
+
+func enum(values ...string) constraint {
+	return func(s *Schema) { s.Enum = values }
+}
+
+func describe(text string) constraint {
+	return func(s *Schema) { s.Description = text }
+}
+
+var currency = enum(domain.CurrencyCodes()...)
+
+var addressType = enum(string(domain.AddressTypeBilling), string(domain.AddressTypeShipping))
+
+// requiredProperties lists the properties a request must carry, by component name
+var requiredProperties = map[string][]string{
+	"CreateCustomerRequest": {"name", "email"},
+	"UpdateCustomerRequest": {"name", "email"},
+	"MergeCustomersRequest": {"duplicateId"},
+	"Address":               {"type", "line1", "city", "country"},
+	"OrderLine":             {"sku", "quantity"},
+	"CreateProductRequest":  {"sku", "name", "currency"},
+	"UpdateProductRequest":  {"name", "currency"},
+	"SetStockLevelRequest":  {"onHand"},
+	"BatchRequest":          {"commands"},
+	"BatchCommand":          {"type", "payload"},
+}
+
+// propertyConstraints mirrors the validation of the domain, by component and property name
+var propertyConstraints = map[string]map[string][]constraint{
+	"Customer": {
+		"email":      {format("email")},
+		"phone":      {pattern(domain.PhoneNumberPattern)},
+		"addresses":  {maxItems(domain.MaxCustomerAddresses)},
+		"mergedInto": {describe("ID of the customer this one was merged into")},
+	},
+	"CreateCustomerRequest": {
+		"name":  {nonEmpty},
+		"email": {format("email")},
+		"phone": {describe("E.164 phone number; spaces, dots, dashes and parentheses are removed")},
+	},
+	"UpdateCustomerRequest": {
+		"name":  {nonEmpty},
+		"email": {format("email")},
+		"phone": {describe("E.164 phone number; spaces, dots, dashes and parentheses are removed")},
+	},
+	"Address": {
+		"type":    {addressType},
+		"line1":   {nonEmpty},
+		"city":    {nonEmpty},
+		"country": {pattern(domain.CountryCodePattern), describe("ISO 3166-1 alpha-2 country code")},
+	},
+	"MergeCustomersRequest": {
+		"survivorId": {describe("Ignored, the survivor is the customer of the path")},
+	},
+	"Order": {
+		"currency": {currency},
+		"total":    {describe("Total in minor units of the currency")},
+		"lines":    {maxItems(domain.MaxOrderLines)},
+	},
+	"OrderLine": {
+		"sku":       {nonEmpty},
+		"quantity":  {minimum(1)},
+		"unitPrice": {minimum(0), describe("Price in minor units of the order currency; must be the catalog price, which applies when omitted")},
+		"subtotal":  {describe("Read only, quantity times unit price")},
+	},
+	"CreateOrderRequest": {
+		"quantity": {minimum(1)},
+		"currency": {currency, describe("Defaults to " + domain.DefaultCurrency)},
+		"lines":    {maxItems(domain.MaxOrderLines), describe("Takes precedence over product and quantity")},
+	},
+	"UpdateOrderRequest": {
+		"quantity": {minimum(1)},
+		"currency": {currency, describe("Defaults to " + domain.DefaultCurrency)},
+		"lines":    {maxItems(domain.MaxOrderLines), describe("Takes precedence over product and quantity")},
+	},
+	"Product": {
+		"price":    {minimum(0)},
+		"currency": {currency},
+	},
+	"CreateProductRequest": {
+		"sku":      {nonEmpty},
+		"name":     {nonEmpty},
+		"price":    {minimum(0), describe("Price in minor units of the currency")},
+		"currency": {currency},
+	},
+	"UpdateProductRequest": {
+		"name":     {nonEmpty},
+		"price":    {minimum(0), describe("Price in minor units of the currency")},
+		"currency": {currency},
+	},
+	"StockLevel": {
+		"onHand": {minimum(0)},
+	},
+	"SetStockLevelRequest": {
+		"onHand": {minimum(0), describe("Cannot be lower than the reserved quantity")},
+	},
+	"BatchCommand": {
+		"payload": {describe(`Command payload; {"$ref": "<id>"} stands for the ID an earlier command produced`)},
+	},
+}
+
+// applyConstraints adds the domain constraints of a component to its schema
+func applyConstraints(name string, schema *Schema) {
+	schema.Required = requiredProperties[name]
+	for property, constraints := range propertyConstraints[name] {
+		propertySchema, ok := schema.Properties[property]
+		if !ok {
+			continue
+		}
+		for _, constrain := range constraints {
+			constrain(propertySchema)
+		}
+	}
+}
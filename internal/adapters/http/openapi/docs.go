@@ -0,0 +1,31 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// The documentation pages load their scripts from a CDN and read the document from /api/openapi.json
+var (
+	//go:embed swagger.html
+	swaggerPage []byte
+	//go:embed redoc.html
+	redocPage []byte
+)
+
+// SwaggerUIHandler serves a Swagger UI page for the OpenAPI document
+func SwaggerUIHandler() http.Handler {
+	return pageHandler(swaggerPage)
+}
+
+// RedocHandler serves a Redoc page for the OpenAPI document
+func RedocHandler() http.Handler {
+	return pageHandler(redocPage)
+}
+
+func pageHandler(page []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"net/http"
+
+	"go-cqrs/internal/adapters/http/dto"
+)
+
+// Operation documents one method of a route
+type Operation struct {
+	Summary string
+	Tag     string
+	// Query lists the query parameters
+	Query []Parameter
+	// Body is a value of the request body type, nil for none
+	Body interface{}
+	// BodyMediaTypes defaults to application/json
+	BodyMediaTypes []string
+	// Responses maps the status codes of the successful responses to their content
+	Responses map[int]Response
+	// View marks responses written by writeView, which accept ?fields= and can be CSV
+	View bool
+}
+
+// Parameter documents a query parameter
+type Parameter struct {
+	Name        string
+	Description string
+	Schema      *Schema
+}
+
+// Response documents the content of a response
+type Response struct {
+	Description string
+	// Body is a value of the response body type, nil for none
+	Body interface{}
+	// MediaTypes defaults to application/json
+	MediaTypes []string
+	// Headers maps response header names to their description
+	Headers map[string]string
+}
+
+// Small response bodies the controllers build from maps
+type (
+	errorBody struct {
+		Error string `json:"error"`
+	}
+	messageBody struct {
+		Message string `json:"message"`
+	}
+	createdBody struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}
+	healthBody struct {
+		Status string `json:"status"`
+	}
+	graphQLBody struct {
+		Data   map[string]interface{} `json:"data,omitempty"`
+		Errors []graphQLErrorBody     `json:"errors,omitempty"`
+	}
+	graphQLErrorBody struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path,omitempty"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
+	}
+	importFailureBody struct {
+		Error  string              `json:"error"`
+		Report dto.ImportReportDTO `json:"report"`
+	}
+)
+
+// OperationKey identifies an operation by method and path template, e.g. "GET /api/orders/{id}"
+func OperationKey(method, path string) string {
+	return method + " " + path
+}
+
+func stringParameter(name, description string) Parameter {
+	return Parameter{Name: name, Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func integerParameter(name, description string) Parameter {
+	return Parameter{Name: name, Description: description, Schema: &Schema{Type: "integer"}}
+}
+
+func booleanParameter(name, description string) Parameter {
+	return Parameter{Name: name, Description: description, Schema: &Schema{Type: "boolean"}}
+}
+
+func ok(body interface{}) map[int]Response {
+	return map[int]Response{http.StatusOK: {Description: "OK", Body: body}}
+}
+
+func created(body interface{}) map[int]Response {
+	return map[int]Response{http.StatusCreated: {Description: "Created", Body: body}}
+}
+
+var pageParameters = []Parameter{
+	integerParameter("limit", "Page size, 1 to 500, 50 by default"),
+	integerParameter("offset", "Number of items to skip"),
+}
+
+var orderFilterParameters = []Parameter{
+	stringParameter("product", "Only orders of the product"),
+	stringParameter("currency", "Only orders in the currency"),
+	stringParameter("createdFrom", "Only orders created at or after this RFC 3339 timestamp or date"),
+	stringParameter("createdTo", "Only orders created before this RFC 3339 timestamp or date"),
+}
+
+var importParameters = []Parameter{
+	stringParameter("format", "csv or ndjson, detected from the Content-Type when missing"),
+	integerParameter("batchSize", "Number of rows committed per transaction"),
+	booleanParameter("async", "Import in the background and answer with the job to poll"),
+}
+
+var importResponses = map[int]Response{
+	http.StatusOK:                    {Description: "Import report", Body: dto.ImportReportDTO{}},
+	http.StatusAccepted:              {Description: "Import job started", Body: dto.ImportJobDTO{}, Headers: map[string]string{"Location": "URL of the import job"}},
+	http.StatusBadRequest:            {Description: "The upload could not be read past a row; the rows before it were imported", Body: importFailureBody{}},
+	http.StatusRequestEntityTooLarge: {Description: "The upload is too large; the rows before the limit were imported", Body: importFailureBody{}},
+}
+
+var exportParameters = []Parameter{
+	stringParameter("format", "csv, ndjson or parquet, negotiated from the Accept header when missing"),
+}
+
+var exportMediaTypes = []string{"text/csv", "application/x-ndjson", "application/vnd.apache.parquet"}
+
+var importMediaTypes = []string{"text/csv", "application/x-ndjson"}
+
+// Operations documents every route of the API by OperationKey
+var Operations = map[string]Operation{
+	"GET /api/health": {Summary: "Check that the service is up", Tag: "system", Responses: ok(healthBody{})},
+	"GET /api/metrics": {Summary: "Read the command, query and cache metrics", Tag: "system",
+		Responses: ok(map[string]interface{}{})},
+	"GET /api/openapi.json": {Summary: "Read this OpenAPI document", Tag: "system",
+		Responses: ok(map[string]interface{}{})},
+	"GET /api/docs": {Summary: "Browse the API documentation with Swagger UI", Tag: "system",
+		Responses: map[int]Response{http.StatusOK: {Description: "HTML page", MediaTypes: []string{"text/html"}}}},
+	"GET /api/docs/redoc": {Summary: "Browse the API documentation with Redoc", Tag: "system",
+		Responses: map[int]Response{http.StatusOK: {Description: "HTML page", MediaTypes: []string{"text/html"}}}},
+
+	// Customers
+	"POST /api/customers": {Summary: "Create a customer", Tag: "customers",
+		Body: dto.CreateCustomerRequest{}, Responses: created(createdBody{})},
+	"GET /api/customers": {Summary: "List customers (not implemented yet)", Tag: "customers", Responses: ok(messageBody{})},
+	"GET /api/customers/{id}": {Summary: "Get a customer", Tag: "customers",
+		Responses: ok(dto.CustomerDTO{}), View: true},
+	"PUT /api/customers/{id}": {Summary: "Update a customer", Tag: "customers",
+		Body: dto.UpdateCustomerRequest{}, Responses: ok(messageBody{})},
+	"DELETE /api/customers/{id}": {Summary: "Delete a customer", Tag: "customers", Responses: ok(messageBody{})},
+	"GET /api/customers/{id}/history": {Summary: "Get the change history of a customer", Tag: "customers",
+		Responses: ok(dto.HistoryDTO{}), View: true},
+	"GET /api/customers/{id}/orders": {Summary: "List the orders of a customer, newest first", Tag: "customers",
+		Query: append(append([]Parameter{}, orderFilterParameters...), pageParameters...),
+		Responses: map[int]Response{http.StatusOK: {Description: "A page of orders", Body: []dto.OrderDTO{},
+			Headers: map[string]string{"X-Total-Count": "Number of orders matching the filter"}}},
+		View: true},
+	"GET /api/customers/{id}/summary": {Summary: "Summarize the orders of a customer", Tag: "customers",
+		Responses: ok(dto.CustomerSummaryDTO{}), View: true},
+	"POST /api/customers/{id}/merge": {Summary: "Merge a duplicate customer into this one", Tag: "customers",
+		Query: []Parameter{booleanParameter("dryRun", "Report what the merge would change without changing anything")},
+		Body:  dto.MergeCustomersRequest{}, Responses: ok(dto.MergeReportDTO{})},
+	"POST /api/customers/{id}/addresses": {Summary: "Add an address to a customer", Tag: "customers",
+		Body: dto.AddressDTO{}, Responses: created(createdBody{})},
+	"PUT /api/customers/{id}/addresses/{addressId}": {Summary: "Update an address of a customer", Tag: "customers",
+		Body: dto.AddressDTO{}, Responses: ok(messageBody{})},
+	"DELETE /api/customers/{id}/addresses/{addressId}": {Summary: "Remove an address of a customer", Tag: "customers",
+		Responses: ok(messageBody{})},
+	"POST /api/customers:import": {Summary: "Import customers from CSV or NDJSON", Tag: "customers",
+		Query: importParameters, Body: "", BodyMediaTypes: importMediaTypes, Responses: importResponses},
+	"GET /api/customers:export": {Summary: "Export customers", Tag: "customers",
+		Query: append([]Parameter{
+			stringParameter("createdFrom", "Only customers created at or after this RFC 3339 timestamp or date"),
+			stringParameter("createdTo", "Only customers created before this RFC 3339 timestamp or date"),
+			booleanParameter("includeMerged", "Include customers merged into others"),
+		}, exportParameters...),
+		Responses: map[int]Response{http.StatusOK: {Description: "Export file", Body: "", MediaTypes: exportMediaTypes}}},
+
+	// Orders
+	"POST /api/orders": {Summary: "Create an order", Tag: "orders",
+		Body: dto.CreateOrderRequest{}, Responses: created(createdBody{})},
+	"GET /api/orders":      {Summary: "List orders (not implemented yet)", Tag: "orders", Responses: ok(messageBody{})},
+	"GET /api/orders/{id}": {Summary: "Get an order", Tag: "orders", Responses: ok(dto.OrderDTO{}), View: true},
+	"PUT /api/orders/{id}": {Summary: "Update an order", Tag: "orders",
+		Body: dto.UpdateOrderRequest{}, Responses: ok(messageBody{})},
+	"DELETE /api/orders/{id}": {Summary: "Delete an order", Tag: "orders", Responses: ok(messageBody{})},
+	"GET /api/orders/{id}/history": {Summary: "Get the change history of an order", Tag: "orders",
+		Responses: ok(dto.HistoryDTO{}), View: true},
+	"POST /api/orders/{id}/customers/{customerId}": {Summary: "Assign a customer to an order", Tag: "orders",
+		Responses: ok(messageBody{})},
+	"POST /api/orders/{id}/lines": {Summary: "Add a line to an order", Tag: "orders",
+		Body: dto.OrderLineDTO{}, Responses: created(messageBody{})},
+	"PUT /api/orders/{id}/lines/{sku}": {Summary: "Change a line of an order", Tag: "orders",
+		Body: dto.OrderLineDTO{}, Responses: ok(messageBody{})},
+	"DELETE /api/orders/{id}/lines/{sku}": {Summary: "Remove a line from an order", Tag: "orders",
+		Responses: ok(messageBody{})},
+	"POST /api/orders:import": {Summary: "Import orders from CSV or NDJSON", Tag: "orders",
+		Query: importParameters, Body: "", BodyMediaTypes: importMediaTypes, Responses: importResponses},
+	"GET /api/orders:export": {Summary: "Export orders", Tag: "orders",
+		Query:     append(append([]Parameter{integerParameter("customerId", "Only orders of the customer")}, orderFilterParameters...), exportParameters...),
+		Responses: map[int]Response{http.StatusOK: {Description: "Export file", Body: "", MediaTypes: exportMediaTypes}}},
+
+	// Imports
+	"GET /api/imports/{jobId}": {Summary: "Get a background import job", Tag: "imports", Responses: ok(dto.ImportJobDTO{})},
+
+	// Products
+	"POST /api/products": {Summary: "Add a product to the catalog", Tag: "products",
+		Body: dto.CreateProductRequest{}, Responses: created(createdBody{})},
+	"GET /api/products": {Summary: "List products", Tag: "products",
+		Query: pageParameters, Responses: ok([]dto.ProductDTO{}), View: true},
+	"GET /api/products/{id}": {Summary: "Get a product", Tag: "products", Responses: ok(dto.ProductDTO{}), View: true},
+	"PUT /api/products/{id}": {Summary: "Update a product", Tag: "products",
+		Body: dto.UpdateProductRequest{}, Responses: ok(messageBody{})},
+	"DELETE /api/products/{id}": {Summary: "Deactivate a product", Tag: "products", Responses: ok(messageBody{})},
+
+	// Inventory
+	"GET /api/inventory/{sku}": {Summary: "Get the stock level of a SKU", Tag: "inventory",
+		Responses: ok(dto.StockLevelDTO{}), View: true},
+	"PUT /api/inventory/{sku}": {Summary: "Record the stock on hand of a SKU", Tag: "inventory",
+		Body: dto.SetStockLevelRequest{}, Responses: ok(dto.StockLevelDTO{})},
+
+	// Batch
+	"POST /api/batch": {Summary: "Run an ordered list of commands", Tag: "batch",
+		Body: dto.BatchRequest{},
+		Responses: map[int]Response{
+			http.StatusOK:          {Description: "Every command succeeded", Body: dto.BatchResponseDTO{}},
+			http.StatusMultiStatus: {Description: "Some commands of a non-atomic batch failed", Body: dto.BatchResponseDTO{}},
+			http.StatusBadRequest:  {Description: "An atomic batch failed and was rolled back", Body: dto.BatchResponseDTO{}},
+		}},
+
+	// GraphQL
+	"POST /api/graphql": {Summary: "Run a GraphQL query or mutation over customers and orders", Tag: "graphql",
+		Body: dto.GraphQLRequest{},
+		Responses: map[int]Response{
+			http.StatusOK:         {Description: "The data, with the errors of any fields that failed", Body: graphQLBody{}},
+			http.StatusBadRequest: {Description: "The request did not parse, validate or stay within the depth and complexity limits", Body: graphQLBody{}},
+		}},
+
+	// Events
+	"GET /api/events/stream": {Summary: "Stream newly stored domain events as server-sent events", Tag: "events",
+		Query: []Parameter{
+			stringParameter("type", "Only events of these types, repeatable or comma-separated"),
+			stringParameter("aggregateType", "Only events of this aggregate type, e.g. order"),
+			stringParameter("aggregateId", "Only events of this aggregate"),
+			integerParameter("lastEventId", "Resume after this event ID; the Last-Event-ID header takes precedence"),
+		},
+		Responses: map[int]Response{
+			http.StatusOK: {Description: "A stream of events, each with its event ID as SSE id and its type as SSE event", Body: dto.EventDTO{}, MediaTypes: []string{"text/event-stream"}},
+		}},
+
+	// Webhooks
+	"POST /api/webhooks": {Summary: "Subscribe a URL to domain events; the response holds the signing secret", Tag: "webhooks",
+		Body: dto.CreateWebhookRequest{}, Responses: created(dto.WebhookDTO{})},
+	"GET /api/webhooks":      {Summary: "List webhooks", Tag: "webhooks", Responses: ok([]dto.WebhookDTO{}), View: true},
+	"GET /api/webhooks/{id}": {Summary: "Get a webhook", Tag: "webhooks", Responses: ok(dto.WebhookDTO{}), View: true},
+	"PUT /api/webhooks/{id}": {Summary: "Update a webhook; a new secret is returned once", Tag: "webhooks",
+		Body: dto.UpdateWebhookRequest{}, Responses: ok(dto.WebhookDTO{})},
+	"DELETE /api/webhooks/{id}": {Summary: "Delete a webhook and its delivery log", Tag: "webhooks", Responses: ok(messageBody{})},
+	"GET /api/webhooks/{id}/deliveries": {Summary: "List the deliveries of a webhook, newest first", Tag: "webhooks",
+		Query:     append([]Parameter{stringParameter("status", "Only deliveries with this status: pending, delivered or dead")}, pageParameters...),
+		Responses: ok([]dto.WebhookDeliveryDTO{}), View: true},
+	"GET /api/webhooks/{id}/deliveries/{deliveryId}": {Summary: "Get a delivery with its payload and attempts", Tag: "webhooks",
+		Responses: ok(dto.WebhookDeliveryDTO{}), View: true},
+	"POST /api/webhooks/{id}/deliveries/{deliveryId}/redeliver": {Summary: "Queue a delivery again with a fresh set of attempts", Tag: "webhooks",
+		Responses: map[int]Response{http.StatusAccepted: {Description: "Accepted", Body: messageBody{}}}},
+}
@@ -0,0 +1,108 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (draft 2020-12) as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaSet collects the component schemas of the struct types a document refers to
+type schemaSet map[string]*Schema
+
+// SchemaName is the component name of a struct type: its Go name without a DTO or Body suffix
+func SchemaName(t reflect.Type) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(t.Name(), "DTO"), "Body")
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// schemaOf returns the schema of a Go type, adding the struct types it refers to as components
+func (s schemaSet) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// Any JSON value
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return s.ref(t)
+	}
+	// Interfaces hold any JSON value
+	return &Schema{}
+}
+
+// ref adds a struct type to the components, unless it is already there, and refers to it
+func (s schemaSet) ref(t reflect.Type) *Schema {
+	name := SchemaName(t)
+	if _, ok := s[name]; !ok {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		// Register before walking the fields so that recursive types terminate
+		s[name] = schema
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			property, options, _ := strings.Cut(tag, ",")
+			if property == "" {
+				property = field.Name
+			}
+
+			fieldSchema := s.schemaOf(field.Type)
+			// A nil pointer that is not omitted encodes as null
+			if field.Type.Kind() == reflect.Pointer && !strings.Contains(options, "omitempty") && fieldSchema.Type != nil {
+				fieldSchema.Type = []interface{}{fieldSchema.Type, "null"}
+			}
+			schema.Properties[property] = fieldSchema
+		}
+		applyConstraints(name, schema)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
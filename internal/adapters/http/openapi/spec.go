@@ -0,0 +1,302 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.1.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Tags       []Tag                           `json:"tags"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem is one operation of a path
+type PathItem struct {
+	Summary     string                    `json:"summary"`
+	OperationID string                    `json:"operationId"`
+	Tags        []string                  `json:"tags"`
+	Parameters  []ParameterObject         `json:"parameters,omitempty"`
+	RequestBody *RequestBody              `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseObject `json:"responses"`
+}
+
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type ResponseObject struct {
+	Description string                  `json:"description"`
+	Headers     map[string]HeaderObject `json:"headers,omitempty"`
+	Content     map[string]MediaType    `json:"content,omitempty"`
+}
+
+type HeaderObject struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Route is a method and path template the router serves, with mux variable patterns removed
+type Route struct {
+	Method string
+	Path   string
+	// PathParameters maps the path variables to their mux patterns
+	PathParameters map[string]string
+	pathOrder      []string
+}
+
+var (
+	pathVariable    = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]+))?\}`)
+	nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// Routes lists the method and path of every route registered on a router
+func Routes(router *mux.Router) ([]Route, error) {
+	var routes []Route
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		// Subrouter prefixes have no methods of their own
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		parameters := map[string]string{}
+		var order []string
+		path := pathVariable.ReplaceAllStringFunc(template, func(variable string) string {
+			match := pathVariable.FindStringSubmatch(variable)
+			parameters[match[1]] = match[2]
+			order = append(order, match[1])
+			return "{" + match[1] + "}"
+		})
+		for _, method := range methods {
+			routes = append(routes, Route{Method: method, Path: path, PathParameters: parameters, pathOrder: order})
+		}
+		return nil
+	})
+	return routes, err
+}
+
+// Undocumented returns the keys of the routes of a router that have no operation in Operations,
+// and of the operations that no longer have a route
+func Undocumented(router *mux.Router) ([]string, error) {
+	routes, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	routed := map[string]bool{}
+	for _, route := range routes {
+		key := OperationKey(route.Method, route.Path)
+		routed[key] = true
+		if _, ok := Operations[key]; !ok {
+			problems = append(problems, "undocumented route "+key)
+		}
+	}
+	for key := range Operations {
+		if !routed[key] {
+			problems = append(problems, "documented operation without a route "+key)
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// Build generates the OpenAPI document of the documented routes of a router
+func Build(router *mux.Router) (*Document, error) {
+	routes, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := schemaSet{}
+	errorSchema := schemas.schemaOf(reflect.TypeOf(errorBody{}))
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "go-cqrs API", Version: "1.0.0"},
+		Paths:   map[string]map[string]*PathItem{},
+	}
+	tags := map[string]bool{}
+
+	for _, route := range routes {
+		op, ok := Operations[OperationKey(route.Method, route.Path)]
+		if !ok {
+			continue
+		}
+
+		item := &PathItem{
+			Summary:     op.Summary,
+			OperationID: operationID(route),
+			Tags:        []string{op.Tag},
+			Responses:   map[string]ResponseObject{},
+		}
+		tags[op.Tag] = true
+
+		for _, name := range route.pathOrder {
+			schema := &Schema{Type: "string"}
+			if route.PathParameters[name] == "[0-9]+" {
+				schema = &Schema{Type: "integer"}
+			}
+			item.Parameters = append(item.Parameters, ParameterObject{Name: name, In: "path", Required: true, Schema: schema})
+		}
+		for _, parameter := range op.Query {
+			item.Parameters = append(item.Parameters, ParameterObject{Name: parameter.Name, In: "query", Description: parameter.Description, Schema: parameter.Schema})
+		}
+		if op.View {
+			item.Parameters = append(item.Parameters, ParameterObject{
+				Name:        "fields",
+				In:          "query",
+				Description: "Comma separated fields to return, nested ones with dots, e.g. id,lines.sku",
+				Schema:      &Schema{Type: "string"},
+			})
+		}
+
+		if op.Body != nil {
+			item.RequestBody = &RequestBody{Required: true, Content: content(schemas, op.Body, op.BodyMediaTypes)}
+		}
+
+		for status, response := range op.Responses {
+			mediaTypes := response.MediaTypes
+			if op.View && mediaTypes == nil {
+				mediaTypes = []string{"application/json", "text/csv"}
+			}
+			object := ResponseObject{Description: response.Description}
+			if response.Body != nil || response.MediaTypes != nil {
+				object.Content = content(schemas, response.Body, mediaTypes)
+			}
+			for name, description := range response.Headers {
+				if object.Headers == nil {
+					object.Headers = map[string]HeaderObject{}
+				}
+				object.Headers[name] = HeaderObject{Description: description, Schema: &Schema{Type: "string"}}
+			}
+			item.Responses[strconv.Itoa(status)] = object
+		}
+		item.Responses["default"] = ResponseObject{
+			Description: "Error",
+			Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+		}
+
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = map[string]*PathItem{}
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = item
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = schemas
+	return doc, nil
+}
+
+// content describes a body in each of its media types, JSON by default
+func content(schemas schemaSet, body interface{}, mediaTypes []string) map[string]MediaType {
+	if mediaTypes == nil {
+		mediaTypes = []string{"application/json"}
+	}
+
+	var schema *Schema
+	if body != nil {
+		schema = schemas.schemaOf(reflect.TypeOf(body))
+	}
+
+	result := map[string]MediaType{}
+	for _, mediaType := range mediaTypes {
+		switch {
+		case mediaType == "application/json":
+			result[mediaType] = MediaType{Schema: schema}
+		case strings.HasPrefix(mediaType, "text/"):
+			result[mediaType] = MediaType{Schema: &Schema{Type: "string"}}
+		default:
+			result[mediaType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+	}
+	return result
+}
+
+// operationID names an operation after its method and path, e.g. getApiOrdersIdLines
+func operationID(route Route) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(route.Method))
+	for _, word := range nonAlphanumeric.Split(route.Path, -1) {
+		if word != "" {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+// Handler serves the OpenAPI document of a router as JSON. The document is generated on the
+// first request, when every route has been registered.
+func Handler(router *mux.Router) http.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc *Document
+			if doc, err = Build(router); err == nil {
+				body, err = json.MarshalIndent(doc, "", "  ")
+			}
+		})
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("failed to generate the OpenAPI document: %v", err)})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
@@ -1,10 +1,12 @@
 package router
 
 import (
+	"expvar"
 	"net/http"
 
 	"go-cqrs/internal/adapters/http/controllers"
 	"go-cqrs/internal/adapters/http/middleware"
+	"go-cqrs/internal/adapters/http/openapi"
 
 	"github.com/gorilla/mux"
 )
@@ -18,16 +20,36 @@ type Router interface {
 // MuxRouter implements Router using gorilla/mux
 type MuxRouter struct {
 	*mux.Router
-	customerController controllers.CustomerController
-	orderController    controllers.OrderController
+	customerController  controllers.CustomerController
+	orderController     controllers.OrderController
+	productController   controllers.ProductController
+	inventoryController controllers.InventoryController
+	importController    controllers.ImportController
+	exportController    controllers.ExportController
+	batchController     controllers.BatchController
+	graphqlController   controllers.GraphQLController
+	eventController     controllers.EventStreamController
+	webhookController   controllers.WebhookController
+	tenants             middleware.TenantResolver
+	cors                *middleware.CorsPolicy
 }
 
 // NewRouter creates a new router with the given controllers
-func NewRouter(customerController controllers.CustomerController, orderController controllers.OrderController) Router {
+func NewRouter(customerController controllers.CustomerController, orderController controllers.OrderController, productController controllers.ProductController, inventoryController controllers.InventoryController, importController controllers.ImportController, exportController controllers.ExportController, batchController controllers.BatchController, graphqlController controllers.GraphQLController, eventController controllers.EventStreamController, webhookController controllers.WebhookController, tenants middleware.TenantResolver, cors *middleware.CorsPolicy) Router {
 	r := &MuxRouter{
-		Router:             mux.NewRouter(),
-		customerController: customerController,
-		orderController:    orderController,
+		Router:              mux.NewRouter(),
+		customerController:  customerController,
+		orderController:     orderController,
+		productController:   productController,
+		inventoryController: inventoryController,
+		importController:    importController,
+		exportController:    exportController,
+		batchController:     batchController,
+		graphqlController:   graphqlController,
+		eventController:     eventController,
+		webhookController:   webhookController,
+		tenants:             tenants,
+		cors:                cors,
 	}
 	r.SetupRoutes()
 	return r
@@ -36,8 +58,10 @@ func NewRouter(customerController controllers.CustomerController, orderControlle
 // SetupRoutes configures all the routes for the application
 func (r *MuxRouter) SetupRoutes() {
 	// Middleware
+	r.Use(middleware.RequestContextMiddleware)
 	r.Use(middleware.LoggingMiddleware)
-	r.Use(middleware.CorsMiddleware)
+	r.Use(middleware.CorsMiddleware(r.cors))
+	r.Use(middleware.TenantMiddleware(r.tenants))
 
 	// API Routes
 	api := r.PathPrefix("/api").Subrouter()
@@ -48,6 +72,14 @@ func (r *MuxRouter) SetupRoutes() {
 		w.Write([]byte(`{"status":"ok"}`))
 	}).Methods(http.MethodGet)
 
+	// Command metrics
+	api.Handle("/metrics", expvar.Handler()).Methods(http.MethodGet)
+
+	// API documentation. Every route must have an operation in openapi.Operations.
+	api.Handle("/openapi.json", openapi.Handler(r.Router)).Methods(http.MethodGet)
+	api.Handle("/docs", openapi.SwaggerUIHandler()).Methods(http.MethodGet)
+	api.Handle("/docs/redoc", openapi.RedocHandler()).Methods(http.MethodGet)
+
 	// Customer routes
 	customers := api.PathPrefix("/customers").Subrouter()
 	customers.HandleFunc("", r.customerController.CreateCustomer).Methods(http.MethodPost)
@@ -55,6 +87,13 @@ func (r *MuxRouter) SetupRoutes() {
 	customers.HandleFunc("/{id:[0-9]+}", r.customerController.GetCustomer).Methods(http.MethodGet)
 	customers.HandleFunc("/{id:[0-9]+}", r.customerController.UpdateCustomer).Methods(http.MethodPut)
 	customers.HandleFunc("/{id:[0-9]+}", r.customerController.DeleteCustomer).Methods(http.MethodDelete)
+	customers.HandleFunc("/{id:[0-9]+}/history", r.customerController.GetCustomerHistory).Methods(http.MethodGet)
+	customers.HandleFunc("/{id:[0-9]+}/orders", r.customerController.ListCustomerOrders).Methods(http.MethodGet)
+	customers.HandleFunc("/{id:[0-9]+}/summary", r.customerController.GetCustomerSummary).Methods(http.MethodGet)
+	customers.HandleFunc("/{id:[0-9]+}/merge", r.customerController.MergeCustomers).Methods(http.MethodPost)
+	customers.HandleFunc("/{id:[0-9]+}/addresses", r.customerController.AddCustomerAddress).Methods(http.MethodPost)
+	customers.HandleFunc("/{id:[0-9]+}/addresses/{addressId:[0-9]+}", r.customerController.UpdateCustomerAddress).Methods(http.MethodPut)
+	customers.HandleFunc("/{id:[0-9]+}/addresses/{addressId:[0-9]+}", r.customerController.RemoveCustomerAddress).Methods(http.MethodDelete)
 
 	// Order routes
 	orders := api.PathPrefix("/orders").Subrouter()
@@ -63,5 +102,52 @@ func (r *MuxRouter) SetupRoutes() {
 	orders.HandleFunc("/{id:[0-9]+}", r.orderController.GetOrder).Methods(http.MethodGet)
 	orders.HandleFunc("/{id:[0-9]+}", r.orderController.UpdateOrder).Methods(http.MethodPut)
 	orders.HandleFunc("/{id:[0-9]+}", r.orderController.DeleteOrder).Methods(http.MethodDelete)
+	orders.HandleFunc("/{id:[0-9]+}/history", r.orderController.GetOrderHistory).Methods(http.MethodGet)
 	orders.HandleFunc("/{id:[0-9]+}/customers/{customerId:[0-9]+}", r.orderController.AssignCustomer).Methods(http.MethodPost)
+	orders.HandleFunc("/{id:[0-9]+}/lines", r.orderController.AddOrderLine).Methods(http.MethodPost)
+	orders.HandleFunc("/{id:[0-9]+}/lines/{sku}", r.orderController.ChangeOrderLine).Methods(http.MethodPut)
+	orders.HandleFunc("/{id:[0-9]+}/lines/{sku}", r.orderController.RemoveOrderLine).Methods(http.MethodDelete)
+
+	// Product routes
+	products := api.PathPrefix("/products").Subrouter()
+	products.HandleFunc("", r.productController.CreateProduct).Methods(http.MethodPost)
+	products.HandleFunc("", r.productController.ListProducts).Methods(http.MethodGet)
+	products.HandleFunc("/{id:[0-9]+}", r.productController.GetProduct).Methods(http.MethodGet)
+	products.HandleFunc("/{id:[0-9]+}", r.productController.UpdateProduct).Methods(http.MethodPut)
+	products.HandleFunc("/{id:[0-9]+}", r.productController.DeactivateProduct).Methods(http.MethodDelete)
+
+	// Inventory routes
+	inventory := api.PathPrefix("/inventory").Subrouter()
+	inventory.HandleFunc("/{sku}", r.inventoryController.GetStockLevel).Methods(http.MethodGet)
+	inventory.HandleFunc("/{sku}", r.inventoryController.SetStockLevel).Methods(http.MethodPut)
+
+	// Import routes. The custom method suffix is not a path segment, so these are not under the
+	// customers and orders subrouters.
+	api.HandleFunc("/customers:import", r.importController.ImportCustomers).Methods(http.MethodPost)
+	api.HandleFunc("/orders:import", r.importController.ImportOrders).Methods(http.MethodPost)
+	api.HandleFunc("/imports/{jobId}", r.importController.GetImportJob).Methods(http.MethodGet)
+
+	// Export routes
+	api.HandleFunc("/customers:export", r.exportController.ExportCustomers).Methods(http.MethodGet)
+	api.HandleFunc("/orders:export", r.exportController.ExportOrders).Methods(http.MethodGet)
+
+	// Batch route
+	api.HandleFunc("/batch", r.batchController.ExecuteBatch).Methods(http.MethodPost)
+
+	// GraphQL route
+	api.HandleFunc("/graphql", r.graphqlController.ExecuteGraphQL).Methods(http.MethodPost)
+
+	// Event stream route
+	api.HandleFunc("/events/stream", r.eventController.StreamEvents).Methods(http.MethodGet)
+
+	// Webhook routes
+	webhooks := api.PathPrefix("/webhooks").Subrouter()
+	webhooks.HandleFunc("", r.webhookController.CreateWebhook).Methods(http.MethodPost)
+	webhooks.HandleFunc("", r.webhookController.ListWebhooks).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id:[0-9]+}", r.webhookController.GetWebhook).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id:[0-9]+}", r.webhookController.UpdateWebhook).Methods(http.MethodPut)
+	webhooks.HandleFunc("/{id:[0-9]+}", r.webhookController.DeleteWebhook).Methods(http.MethodDelete)
+	webhooks.HandleFunc("/{id:[0-9]+}/deliveries", r.webhookController.ListWebhookDeliveries).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id:[0-9]+}/deliveries/{deliveryId:[0-9]+}", r.webhookController.GetWebhookDelivery).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id:[0-9]+}/deliveries/{deliveryId:[0-9]+}/redeliver", r.webhookController.RedeliverWebhookDelivery).Methods(http.MethodPost)
 }
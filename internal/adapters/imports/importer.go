@@ -0,0 +1,132 @@
+package imports
+
+import (
+	"context"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"io"
+)
+
+const (
+	// DefaultBatchSize is the number of rows committed together when no batch size is given
+	DefaultBatchSize = 500
+	// MaxBatchSize bounds how long a single import transaction may grow
+	MaxBatchSize = 5000
+)
+
+// Importer runs the rows of an upload through a row handler in batches. Each batch is one
+// transaction and each row a savepoint inside it, so a failing row only rolls back its own
+// writes and events while the rest of its batch is still committed.
+type Importer struct {
+	txManager ports.TransactionManager
+	batchSize int
+}
+
+// NewImporter creates an importer committing batchSize rows per transaction
+func NewImporter(txManager ports.TransactionManager, batchSize int) *Importer {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if batchSize > MaxBatchSize {
+		batchSize = MaxBatchSize
+	}
+	return &Importer{txManager: txManager, batchSize: batchSize}
+}
+
+// Run imports every row of the reader and reports the outcome of each. An error is only returned
+// when the upload cannot be read any further; the report then covers the rows read so far.
+func (i *Importer) Run(ctx context.Context, reader RowReader, handle RowHandler) (*dto.ImportReportDTO, error) {
+	report := &dto.ImportReportDTO{Rows: []dto.ImportRowDTO{}}
+
+	for {
+		batch, readErr := i.readBatch(reader)
+		if len(batch) > 0 {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			report.Rows = append(report.Rows, i.runBatch(ctx, batch, handle)...)
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			summarize(report)
+			return report, readErr
+		}
+	}
+
+	summarize(report)
+	return report, nil
+}
+
+// readBatch reads up to batchSize rows, returning io.EOF with the last ones
+func (i *Importer) readBatch(reader RowReader) ([]*Row, error) {
+	batch := make([]*Row, 0, i.batchSize)
+	for len(batch) < i.batchSize {
+		row, err := reader.Next()
+		if err != nil {
+			return batch, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, nil
+}
+
+// runBatch imports a batch of rows in one transaction
+func (i *Importer) runBatch(ctx context.Context, batch []*Row, handle RowHandler) []dto.ImportRowDTO {
+	results := make([]dto.ImportRowDTO, len(batch))
+
+	err := i.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for n, row := range batch {
+			results[n] = dto.ImportRowDTO{Row: row.Number, Status: dto.ImportRowFailed}
+			if row.Err != nil {
+				results[n].Error = row.Err.Error()
+				continue
+			}
+
+			var id int
+			err := i.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				var err error
+				id, err = handle(ctx, row)
+				return err
+			})
+			if err != nil {
+				results[n].Error = err.Error()
+				continue
+			}
+
+			results[n].Status = dto.ImportRowCreated
+			results[n].ID = id
+		}
+		return nil
+	})
+
+	// Nothing of the batch was kept when it could not be committed
+	if err != nil {
+		for n := range results {
+			if results[n].Status != dto.ImportRowFailed {
+				results[n] = dto.ImportRowDTO{
+					Row:    batch[n].Number,
+					Status: dto.ImportRowFailed,
+					Error:  fmt.Sprintf("batch rolled back: %v", err),
+				}
+			}
+		}
+	}
+
+	return results
+}
+
+// summarize counts the outcomes of the reported rows
+func summarize(report *dto.ImportReportDTO) {
+	report.Total = len(report.Rows)
+	report.Succeeded = 0
+	for _, row := range report.Rows {
+		if row.Status == dto.ImportRowCreated {
+			report.Succeeded++
+		}
+	}
+	report.Failed = report.Total - report.Succeeded
+}
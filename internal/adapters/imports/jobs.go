@@ -0,0 +1,110 @@
+package imports
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"sync"
+	"time"
+)
+
+// jobRetention is how long finished jobs stay available for polling
+const jobRetention = 24 * time.Hour
+
+// JobStore keeps track of the imports running in the background.
+// Jobs live in memory, so they do not survive a restart.
+type JobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*dto.ImportJobDTO
+}
+
+// NewJobStore creates an empty job store
+func NewJobStore() *JobStore {
+	return &JobStore{jobs: make(map[string]*dto.ImportJobDTO)}
+}
+
+// Create registers a new pending job of the given kind
+func (s *JobStore) Create(kind string) dto.ImportJobDTO {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	job := &dto.ImportJobDTO{
+		ID:        newJobID(),
+		Kind:      kind,
+		Status:    dto.ImportJobPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.jobs[job.ID] = job
+	return *job
+}
+
+// Get returns a copy of the job with the given ID
+func (s *JobStore) Get(id string) (dto.ImportJobDTO, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return dto.ImportJobDTO{}, false
+	}
+	return *job, true
+}
+
+// Start marks a job as running
+func (s *JobStore) Start(id string) {
+	s.update(id, func(job *dto.ImportJobDTO) {
+		job.Status = dto.ImportJobRunning
+	})
+}
+
+// Finish records the outcome of a job. A job whose upload could not be read to the end
+// fails but keeps the report of the rows imported before.
+func (s *JobStore) Finish(id string, report *dto.ImportReportDTO, err error) {
+	s.update(id, func(job *dto.ImportJobDTO) {
+		finishedAt := time.Now().UTC()
+		job.FinishedAt = &finishedAt
+		job.Report = report
+		job.Status = dto.ImportJobCompleted
+		if err != nil {
+			job.Status = dto.ImportJobFailed
+			job.Error = err.Error()
+		}
+	})
+}
+
+func (s *JobStore) update(id string, change func(job *dto.ImportJobDTO)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		change(job)
+	}
+}
+
+// remove forgets a job that never ran
+func (s *JobStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+}
+
+// prune forgets the jobs that finished longer than jobRetention ago
+func (s *JobStore) prune(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// newJobID generates a random job ID
+func newJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Format is the encoding of an import upload
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// maxNDJSONLine limits the size of a single NDJSON record
+const maxNDJSONLine = 1 << 20
+
+// DetectFormat picks the upload format from an explicit format parameter, or else from the content type
+func DetectFormat(contentType, explicit string) (Format, error) {
+	switch strings.ToLower(explicit) {
+	case "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported import format %q, use csv or ndjson", explicit)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/json-seq":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("unsupported content type %q, use text/csv or application/x-ndjson", contentType)
+}
+
+// Row is one record of an upload. CSV records carry their values by column name and
+// NDJSON records their raw JSON object. Err is set when the record itself could not be read.
+type Row struct {
+	Number int
+	Fields map[string]string
+	JSON   json.RawMessage
+	Err    error
+}
+
+// RowReader reads the records of an upload one at a time. Next returns io.EOF after the last record.
+type RowReader interface {
+	Next() (*Row, error)
+}
+
+// NewRowReader returns a reader for an upload in the given format
+func NewRowReader(format Format, r io.Reader) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+		return &ndjsonReader{scanner: scanner}, nil
+	}
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+// csvReader reads CSV records keyed by the column names of the header row
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+	number int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the CSV upload is empty, a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	reader.FieldsPerRecord = len(columns)
+
+	return &csvReader{reader: reader, header: columns}, nil
+}
+
+func (r *csvReader) Next() (*Row, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	r.number++
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return nil, err
+	}
+	if err != nil {
+		// A malformed record only fails its own row
+		return &Row{Number: r.number, Err: err}, nil
+	}
+
+	fields := make(map[string]string, len(r.header))
+	for i, name := range r.header {
+		fields[name] = strings.TrimSpace(record[i])
+	}
+	return &Row{Number: r.number, Fields: fields}, nil
+}
+
+// ndjsonReader reads one JSON object per line, skipping blank lines
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	number  int
+}
+
+func (r *ndjsonReader) Next() (*Row, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		r.number++
+		if !json.Valid(line) || line[0] != '{' {
+			return &Row{Number: r.number, Err: errors.New("the line is not a JSON object")}, nil
+		}
+
+		data := make(json.RawMessage, len(line))
+		copy(data, line)
+		return &Row{Number: r.number, JSON: data}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON upload: %w", err)
+	}
+	return nil, io.EOF
+}
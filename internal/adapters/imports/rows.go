@@ -0,0 +1,133 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/http/dto"
+	"strconv"
+)
+
+// RowHandler validates one row of an upload and runs the command it describes,
+// returning the ID of the created entity
+type RowHandler func(ctx context.Context, row *Row) (int, error)
+
+// CustomerRows creates a customer from each row. CSV uploads have the columns name, email and phone,
+// NDJSON uploads hold the same fields as POST /api/customers.
+func CustomerRows(commandBus *bus.CommandBus) RowHandler {
+	return func(ctx context.Context, row *Row) (int, error) {
+		var cmd commands.CreateCustomerCommand
+		if row.JSON != nil {
+			if err := json.Unmarshal(row.JSON, &cmd); err != nil {
+				return 0, fmt.Errorf("invalid customer: %w", err)
+			}
+		} else {
+			cmd = commands.CreateCustomerCommand{
+				Name:  row.Fields["name"],
+				Email: row.Fields["email"],
+				Phone: row.Fields["phone"],
+			}
+		}
+
+		// Check the row with the domain rules before touching the database
+		request := dto.CreateCustomerRequest{Name: cmd.Name, Email: cmd.Email, Phone: cmd.Phone}
+		if _, err := request.ToDomain(); err != nil {
+			return 0, err
+		}
+
+		return bus.Result[int](commandBus.Dispatch(ctx, cmd))
+	}
+}
+
+// OrderRows creates an order from each row. CSV uploads describe single-product orders with the columns
+// customerId, product, quantity, unitPrice, currency and shippingAddressId; NDJSON uploads hold the
+// same fields as POST /api/orders, lines included.
+func OrderRows(commandBus *bus.CommandBus) RowHandler {
+	return func(ctx context.Context, row *Row) (int, error) {
+		var cmd commands.CreateOrderCommand
+		if row.JSON != nil {
+			if err := json.Unmarshal(row.JSON, &cmd); err != nil {
+				return 0, fmt.Errorf("invalid order: %w", err)
+			}
+		} else {
+			var err error
+			if cmd, err = orderFromFields(row.Fields); err != nil {
+				return 0, err
+			}
+		}
+
+		// Check the row with the domain rules before touching the database
+		request := dto.CreateOrderRequest{
+			CustomerID: cmd.CustomerID,
+			Product:    cmd.Product,
+			Quantity:   cmd.Quantity,
+			Currency:   cmd.Currency,
+			Lines:      make([]dto.OrderLineDTO, len(cmd.Lines)),
+		}
+		for i, line := range cmd.Lines {
+			request.Lines[i] = dto.OrderLineDTO{
+				SKU:         line.SKU,
+				Description: line.Description,
+				Quantity:    line.Quantity,
+				UnitPrice:   line.UnitPrice,
+			}
+		}
+		if _, err := request.ToDomain(); err != nil {
+			return 0, err
+		}
+
+		return bus.Result[int](commandBus.Dispatch(ctx, cmd))
+	}
+}
+
+// orderFromFields builds a create order command from the columns of a CSV row.
+// A unit price turns the row into a single priced line.
+func orderFromFields(fields map[string]string) (commands.CreateOrderCommand, error) {
+	cmd := commands.CreateOrderCommand{
+		Product:  fields["product"],
+		Currency: fields["currency"],
+	}
+
+	var err error
+	if cmd.CustomerID, err = optionalInt(fields, "customerId"); err != nil {
+		return cmd, err
+	}
+	if cmd.ShippingAddressID, err = optionalInt(fields, "shippingAddressId"); err != nil {
+		return cmd, err
+	}
+	if value := fields["quantity"]; value != "" {
+		if cmd.Quantity, err = strconv.Atoi(value); err != nil {
+			return cmd, fmt.Errorf("invalid quantity %q", value)
+		}
+	}
+
+	if value := fields["unitPrice"]; value != "" {
+		unitPrice, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid unitPrice %q, expected minor units", value)
+		}
+		cmd.Lines = []commands.OrderLine{{
+			SKU:       cmd.Product,
+			Quantity:  cmd.Quantity,
+			UnitPrice: unitPrice,
+		}}
+	}
+
+	return cmd, nil
+}
+
+// optionalInt parses an integer column, returning nil when it is empty or missing
+func optionalInt(fields map[string]string, name string) (*int, error) {
+	value := fields[name]
+	if value == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return &n, nil
+}
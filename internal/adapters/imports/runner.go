@@ -0,0 +1,107 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"sync"
+)
+
+// ErrQueueFull is returned by JobRunner.Submit when every worker is busy and the queue is full
+var ErrQueueFull = errors.New("too many imports are waiting, try again later")
+
+// Work runs a background import. Its context is cancelled when the runner stops.
+type Work func(ctx context.Context) (*dto.ImportReportDTO, error)
+
+type queuedJob struct {
+	id   string
+	work Work
+}
+
+// JobRunner runs the background imports on a fixed number of workers, queueing a bounded number
+// of jobs until a worker is free
+type JobRunner struct {
+	jobs    *JobStore
+	workers int
+	queue   chan queuedJob
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+// NewJobRunner creates a runner of workers workers queueing up to queueSize jobs
+func NewJobRunner(jobs *JobStore, workers, queueSize int) *JobRunner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &JobRunner{
+		jobs:    jobs,
+		workers: workers,
+		queue:   make(chan queuedJob, queueSize),
+	}
+}
+
+// Submit registers a job of the given kind and queues its work. It returns ErrQueueFull, without
+// registering the job, when the queue is full, and an error once the runner has stopped.
+func (r *JobRunner) Submit(kind string, work Work) (dto.ImportJobDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return dto.ImportJobDTO{}, errors.New("imports are shutting down")
+	}
+
+	job := r.jobs.Create(kind)
+	select {
+	case r.queue <- queuedJob{id: job.ID, work: work}:
+		return job, nil
+	default:
+		r.jobs.remove(job.ID)
+		return dto.ImportJobDTO{}, ErrQueueFull
+	}
+}
+
+// Run runs the queued jobs until the context is done, which cancels the running imports. Run
+// returns once they have stopped, failing the jobs still queued.
+func (r *JobRunner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-r.queue:
+					r.run(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+
+	// The work of the queued jobs still runs, with the cancelled context, to release what it holds
+	for {
+		select {
+		case job := <-r.queue:
+			r.run(ctx, job)
+		default:
+			return
+		}
+	}
+}
+
+func (r *JobRunner) run(ctx context.Context, job queuedJob) {
+	r.jobs.Start(job.id)
+	report, err := job.work(ctx)
+	if ctx.Err() != nil && err != nil {
+		err = fmt.Errorf("import stopped by shutdown: %w", err)
+	}
+	r.jobs.Finish(job.id, report, err)
+}
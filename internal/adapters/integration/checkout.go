@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"errors"
+	"go-cqrs/internal/adapters/cqrs/commands"
+)
+
+// Message types of the checkout system
+const (
+	CheckoutOrderPlacedType      = "checkout.order_placed"
+	CheckoutCustomerAssignedType = "checkout.customer_assigned"
+)
+
+// CheckoutOrderPlaced is the data of a checkout.order_placed message. Amounts are in minor units.
+type CheckoutOrderPlaced struct {
+	CustomerID        *int                  `json:"customerId"`
+	Currency          string                `json:"currency"`
+	ShippingAddressID *int                  `json:"shippingAddressId"`
+	Lines             []CheckoutOrderedLine `json:"lines"`
+}
+
+// CheckoutOrderedLine is a line of a checkout.order_placed message
+type CheckoutOrderedLine struct {
+	SKU         string `json:"sku"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int64  `json:"unitPrice"`
+}
+
+// CheckoutCustomerAssigned is the data of a checkout.customer_assigned message, sent when a guest
+// checkout is linked to a customer account afterwards
+type CheckoutCustomerAssigned struct {
+	OrderID    int `json:"orderId"`
+	CustomerID int `json:"customerId"`
+}
+
+// MapCheckoutMessages registers the mappers of the checkout message types
+func MapCheckoutMessages(c *Consumer) {
+	c.Map(CheckoutOrderPlacedType, Schema(func(message CheckoutOrderPlaced) (interface{}, error) {
+		if len(message.Lines) == 0 {
+			return nil, errors.New("an order needs at least one line")
+		}
+		if message.Currency == "" {
+			return nil, errors.New("currency is required")
+		}
+
+		cmd := commands.CreateOrderCommand{
+			CustomerID:        message.CustomerID,
+			Currency:          message.Currency,
+			ShippingAddressID: message.ShippingAddressID,
+		}
+		for _, line := range message.Lines {
+			cmd.Lines = append(cmd.Lines, commands.OrderLine{
+				SKU:         line.SKU,
+				Description: line.Description,
+				Quantity:    line.Quantity,
+				UnitPrice:   line.UnitPrice,
+			})
+		}
+		return cmd, nil
+	}))
+
+	c.Map(CheckoutCustomerAssignedType, Schema(func(message CheckoutCustomerAssigned) (interface{}, error) {
+		if message.OrderID <= 0 || message.CustomerID <= 0 {
+			return nil, errors.New("orderId and customerId are required")
+		}
+		return commands.AssignCustomerCommand{OrderID: message.OrderID, CustomerID: message.CustomerID}, nil
+	}))
+}
@@ -0,0 +1,217 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/messaging"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"sync"
+	"time"
+)
+
+// resubscribeDelay is how long a consumer waits before subscribing again after its subscription failed
+const resubscribeDelay = 5 * time.Second
+
+// Envelope is the wire format of inbound integration messages, CloudEvents structured JSON
+// reduced to the attributes the consumers use. The tenantid extension names the tenant the
+// message is for; messages without it are for the default tenant.
+type Envelope struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	TenantID string          `json:"tenantid"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Mapper turns the data of a message into the command it drives
+type Mapper func(data json.RawMessage) (interface{}, error)
+
+// Schema returns a mapper decoding the data of a message into T before turning it into a command
+func Schema[T any](toCommand func(message T) (interface{}, error)) Mapper {
+	return func(data json.RawMessage) (interface{}, error) {
+		var message T
+		if err := json.Unmarshal(data, &message); err != nil {
+			return nil, err
+		}
+		return toCommand(message)
+	}
+}
+
+// Consumer drives commands from the messages of a broker topic. Each message is recorded in the
+// inbox in the transaction of its command, so a redelivered message is skipped. Messages that
+// cannot drive their command, and those that keep failing, are dead-lettered with the reason.
+type Consumer struct {
+	name        string
+	commandBus  *bus.CommandBus
+	inbox       ports.InboxRepository
+	txManager   ports.TransactionManager
+	logger      logger.Logger
+	maxAttempts int
+	mappers     map[string]Mapper
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// NewConsumer creates a consumer recording its messages in the inbox under its name. A message
+// whose command fails is delivered again up to maxAttempts times before it is dead-lettered.
+func NewConsumer(name string, commandBus *bus.CommandBus, inbox ports.InboxRepository, txManager ports.TransactionManager, logger logger.Logger, maxAttempts int) *Consumer {
+	return &Consumer{
+		name:        name,
+		commandBus:  commandBus,
+		inbox:       inbox,
+		txManager:   txManager,
+		logger:      logger,
+		maxAttempts: maxAttempts,
+		mappers:     make(map[string]Mapper),
+		failures:    make(map[string]int),
+	}
+}
+
+// Map registers the mapper of a message type
+func (c *Consumer) Map(messageType string, mapper Mapper) {
+	c.mappers[messageType] = mapper
+}
+
+// Run consumes the topic until the context is done, subscribing again when the subscription fails
+func (c *Consumer) Run(ctx context.Context, subscriber messaging.Subscriber, topic string) {
+	for {
+		err := subscriber.Subscribe(ctx, topic, c.Handle)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Error("Inbound subscription failed",
+			logger.String("consumer", c.name),
+			logger.String("topic", topic),
+			logger.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// Handle drives the command of a message. It returns an error, for the message to be delivered
+// again, only when the message may still succeed or could not be dead-lettered.
+func (c *Consumer) Handle(ctx context.Context, message messaging.InboundMessage) error {
+	var envelope Envelope
+	err := json.Unmarshal(message.Body, &envelope)
+
+	// Messages are deduplicated and dead-lettered for their tenant, the default one when they name none
+	tenant := envelope.TenantID
+	if tenant == "" {
+		tenant = requestctx.DefaultTenant
+	}
+	ctx = requestctx.WithTenant(ctx, tenant)
+
+	if err != nil {
+		return c.deadLetter(ctx, message, envelope, fmt.Sprintf("malformed message: %v", err))
+	}
+	if envelope.ID == "" || envelope.Type == "" {
+		return c.deadLetter(ctx, message, envelope, "malformed message: id and type are required")
+	}
+
+	mapper, ok := c.mappers[envelope.Type]
+	if !ok {
+		return c.deadLetter(ctx, message, envelope, fmt.Sprintf("unknown message type %s", envelope.Type))
+	}
+	cmd, err := mapper(envelope.Data)
+	if err != nil {
+		return c.deadLetter(ctx, message, envelope, fmt.Sprintf("invalid %s message: %v", envelope.Type, err))
+	}
+
+	err = c.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		first, err := c.inbox.Record(ctx, c.name, envelope.ID, envelope.Type, domain.InboxProcessed)
+		if err != nil || !first {
+			return err
+		}
+		_, err = c.commandBus.Dispatch(ctx, cmd)
+		return err
+	})
+	if err == nil {
+		c.forget(envelope.ID)
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if rejected(err) {
+		return c.deadLetter(ctx, message, envelope, fmt.Sprintf("%s rejected: %v", bus.CommandName(cmd), err))
+	}
+	if attempts := c.fail(envelope.ID); attempts < c.maxAttempts {
+		c.logger.Warn("Inbound message failed, waiting for redelivery",
+			logger.String("consumer", c.name),
+			logger.String("message_id", envelope.ID),
+			logger.Int("attempt", attempts),
+			logger.Error(err))
+		return err
+	}
+	return c.deadLetter(ctx, message, envelope, fmt.Sprintf("%s failed %d times: %v", bus.CommandName(cmd), c.maxAttempts, err))
+}
+
+// rejected reports whether the command failed on the content of the message, which no redelivery changes
+func rejected(err error) bool {
+	var domainErr *domainerrors.DomainError
+	if !errors.As(err, &domainErr) {
+		return false
+	}
+	switch domainErr.Code {
+	case domainerrors.ErrorCodeValidation, domainerrors.ErrorCodeInvalidInput, domainerrors.ErrorCodeNotFound, domainerrors.ErrorCodeConflict:
+		return true
+	}
+	return false
+}
+
+// deadLetter sets the message aside with the reason and records it in the inbox, so that a
+// redelivery of it is skipped. Messages without an ID cannot be recorded.
+func (c *Consumer) deadLetter(ctx context.Context, message messaging.InboundMessage, envelope Envelope, reason string) error {
+	err := c.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if envelope.ID != "" {
+			first, err := c.inbox.Record(ctx, c.name, envelope.ID, envelope.Type, domain.InboxDeadLettered)
+			if err != nil || !first {
+				return err
+			}
+		}
+		return c.inbox.DeadLetter(ctx, domain.DeadLetter{
+			Consumer:    c.name,
+			Topic:       message.Topic,
+			MessageID:   envelope.ID,
+			MessageType: envelope.Type,
+			Body:        message.Body,
+			Reason:      reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	c.forget(envelope.ID)
+	c.logger.Warn("Inbound message dead-lettered",
+		logger.String("consumer", c.name),
+		logger.String("message_id", envelope.ID),
+		logger.String("reason", reason))
+	return nil
+}
+
+// fail counts a failed attempt at a message and returns the number of attempts so far
+func (c *Consumer) fail(messageID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[messageID]++
+	return c.failures[messageID]
+}
+
+func (c *Consumer) forget(messageID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.failures, messageID)
+}
@@ -0,0 +1,76 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-cqrs/internal/domain"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxResponseDrain is how much of a response body is read so that the connection can be reused
+const maxResponseDrain = 64 << 10
+
+// Sender posts deliveries to the URLs of their webhooks
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender giving up on a receiver after the given timeout.
+// Redirects are not followed; a receiver answering one has moved and fails the attempt.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send makes one attempt at a delivery and returns its outcome. Any 2xx answer accepts the delivery.
+func (s *Sender) Send(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	attempt := domain.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts + 1,
+		AttemptedAt: time.Now().UTC(),
+	}
+
+	statusCode, err := s.post(ctx, webhook, delivery, attempt.AttemptedAt)
+	attempt.Duration = time.Since(attempt.AttemptedAt)
+	attempt.StatusCode = statusCode
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// post sends the signed payload of a delivery and returns the status code of the answer
+func (s *Sender) post(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery, sentAt time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-cqrs-webhooks")
+	req.Header.Set(HeaderDeliveryID, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrain))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
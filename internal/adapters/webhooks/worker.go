@@ -0,0 +1,249 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/logger"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"sync"
+	"time"
+)
+
+const (
+	// cursorName is the name under which the worker saves its position in the event log
+	cursorName = "webhooks"
+	// enqueueBatchSize is the number of events read from the log at a time
+	enqueueBatchSize = 500
+	// deliveryBatchSize is the number of deliveries attempted concurrently
+	deliveryBatchSize = 20
+)
+
+// RetryPolicy decides how often and when failed deliveries are attempted again
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay after the given failed attempt: BaseDelay doubled for every attempt
+// before it, capped at MaxDelay
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Worker turns stored events into deliveries for the webhooks subscribed to them, and attempts
+// the deliveries that are due. Its position in the event log is saved with the deliveries it
+// queues, so every event is queued once even across restarts; several workers may run at once.
+type Worker struct {
+	webhooks     ports.WebhookRepository
+	log          event_store.EventLog
+	cursors      event_store.CursorStore
+	txManager    ports.TransactionManager
+	sender       *Sender
+	policy       RetryPolicy
+	logger       logger.Logger
+	pollInterval time.Duration
+	lease        time.Duration
+
+	// cursor is nil until the saved position is loaded, and again after a failed enqueue
+	cursor *event_store.EventCursor
+}
+
+// NewWorker creates a worker polling for events and due deliveries at the given interval.
+// A claimed delivery is left alone by other workers for the lease, which must outlast an attempt.
+func NewWorker(webhooks ports.WebhookRepository, log event_store.EventLog, cursors event_store.CursorStore, txManager ports.TransactionManager, sender *Sender, policy RetryPolicy, logger logger.Logger, pollInterval, lease time.Duration) *Worker {
+	return &Worker{
+		webhooks:     webhooks,
+		log:          log,
+		cursors:      cursors,
+		txManager:    txManager,
+		sender:       sender,
+		policy:       policy,
+		logger:       logger,
+		pollInterval: pollInterval,
+		lease:        lease,
+	}
+}
+
+// Run queues and attempts deliveries until the context is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Enqueue(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Error("Failed to queue webhook deliveries", logger.Error(err))
+		}
+		if _, err := w.Deliver(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Error("Failed to attempt webhook deliveries", logger.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enqueue queues a delivery of every event stored since the last call to each active webhook
+// subscribed to its type. On first start the worker begins at the end of the log.
+func (w *Worker) Enqueue(ctx context.Context) error {
+	if w.cursor == nil {
+		if err := w.loadCursor(ctx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		ready, more, err := w.cursor.Next(ctx, w.log, enqueueBatchSize)
+		if err != nil {
+			// The cursor may have moved past events that were not read; start again from the saved position
+			w.cursor = nil
+			return err
+		}
+
+		if len(ready) > 0 {
+			err = w.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				return w.queue(ctx, ready)
+			})
+			if err != nil {
+				// The cursor moved past events that were not queued; start again from the saved position
+				w.cursor = nil
+				return err
+			}
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+func (w *Worker) loadCursor(ctx context.Context) error {
+	position, found, err := w.cursors.LoadPosition(ctx, cursorName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if position, err = w.log.LatestEventID(ctx); err != nil {
+			return err
+		}
+		if err := w.cursors.SavePosition(ctx, cursorName, position); err != nil {
+			return err
+		}
+	}
+	w.cursor = event_store.NewEventCursor(position)
+	return nil
+}
+
+// queue creates the deliveries of the events to the webhooks of their tenant and saves the checkpoint of the cursor
+func (w *Worker) queue(ctx context.Context, stored []event_store.StoredEvent) error {
+	webhooks, err := w.webhooks.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, event := range stored {
+		var payload json.RawMessage
+		for _, webhook := range webhooks {
+			if webhook.TenantID != event.TenantID || !webhook.Wants(event.EventType) {
+				continue
+			}
+			if payload == nil {
+				if payload, err = eventPayload(event); err != nil {
+					return err
+				}
+			}
+			err := w.webhooks.CreateDelivery(ctx, domain.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				TenantID:      webhook.TenantID,
+				EventID:       event.ID,
+				EventType:     event.EventType,
+				Payload:       payload,
+				Status:        domain.WebhookDeliveryPending,
+				NextAttemptAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.cursors.SavePosition(ctx, cursorName, w.cursor.Checkpoint())
+}
+
+// eventPayload is the body of the deliveries of an event, the same document the event stream sends
+func eventPayload(event event_store.StoredEvent) (json.RawMessage, error) {
+	return json.Marshal(dto.EventDTO{
+		ID:            event.ID,
+		EventType:     event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		TenantID:      event.TenantID,
+		Actor:         event.Actor,
+		RequestID:     event.RequestID,
+		OccurredAt:    event.OccurredAt,
+		Data:          event.Data,
+	})
+}
+
+// Deliver attempts the deliveries that are due and returns how many it attempted. A failed
+// attempt is retried after the backoff of the policy, until the delivery runs out of attempts.
+func (w *Worker) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := w.webhooks.ClaimDueDeliveries(ctx, deliveryBatchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery domain.WebhookDelivery) {
+			defer wg.Done()
+			if err := w.attempt(ctx, delivery); err != nil && ctx.Err() == nil {
+				w.logger.Error("Failed to attempt webhook delivery",
+					logger.Int("delivery_id", delivery.ID),
+					logger.Error(err))
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (w *Worker) attempt(ctx context.Context, delivery domain.WebhookDelivery) error {
+	ctx = requestctx.WithTenant(ctx, delivery.TenantID)
+	webhook, err := w.webhooks.GetByID(ctx, delivery.WebhookID)
+	if err != nil || webhook == nil {
+		return err
+	}
+
+	attempt := w.sender.Send(ctx, *webhook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the delivery is attempted again once its lease runs out
+		return nil
+	}
+	delivery.RecordAttempt(attempt, w.policy.MaxAttempts, w.policy.Backoff(delivery.Attempts+1))
+	if delivery.Status == domain.WebhookDeliveryDead {
+		w.logger.Warn("Webhook delivery ran out of attempts",
+			logger.Int("webhook_id", webhook.ID),
+			logger.Int("delivery_id", delivery.ID),
+			logger.String("error", attempt.Error))
+	}
+
+	return w.webhooks.SaveDelivery(ctx, delivery, &attempt)
+}
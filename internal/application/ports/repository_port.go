@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"go-cqrs/internal/domain"
+	"time"
 )
 
 // Repository is the base interface for all repositories
@@ -13,10 +14,27 @@ type CustomerRepository interface {
 	Repository
 	Create(ctx context.Context, customer domain.Customer) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.Customer, error)
-	GetByEmail(ctx context.Context, email string) (*domain.Customer, error)
+	// GetByIDs retrieves the customers with the given IDs in ID order, leaving out the IDs that do not exist
+	GetByIDs(ctx context.Context, ids []int) ([]domain.Customer, error)
+	GetByEmail(ctx context.Context, email domain.Email) (*domain.Customer, error)
 	Update(ctx context.Context, customer domain.Customer) error
+	// MarkMerged turns a customer into a tombstone pointing at the customer it was merged into
+	MarkMerged(ctx context.Context, id, survivorID int) error
+	// HasMergedCustomers reports whether other customers were merged into a customer
+	HasMergedCustomers(ctx context.Context, id int) (bool, error)
+	// SaveAddresses replaces the stored addresses of a customer and returns them with their IDs
+	SaveAddresses(ctx context.Context, customerID int, addresses []domain.Address) ([]domain.Address, error)
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, limit, offset int) ([]domain.Customer, error)
+	// Stream passes every customer matching the filter to fn in ID order, reading them through a cursor
+	Stream(ctx context.Context, filter CustomerFilter, fn func(customer domain.Customer) error) error
+}
+
+// CustomerFilter selects the customers read by CustomerRepository.Stream
+type CustomerFilter struct {
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	IncludeMerged bool
 }
 
 // OrderRepository defines operations for order persistence
@@ -25,7 +43,102 @@ type OrderRepository interface {
 	Create(ctx context.Context, order domain.Order) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.Order, error)
 	GetByCustomerID(ctx context.Context, customerID int) ([]domain.Order, error)
+	// GetByIDs retrieves the orders with the given IDs in ID order, leaving out the IDs that do not exist
+	GetByIDs(ctx context.Context, ids []int) ([]domain.Order, error)
+	// GetByCustomerIDs retrieves the orders of all the given customers, newest first
+	GetByCustomerIDs(ctx context.Context, customerIDs []int) ([]domain.Order, error)
 	Update(ctx context.Context, order domain.Order) error
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, limit, offset int) ([]domain.Order, error)
+	// Stream passes every order matching the filter to fn in ID order, reading them through a cursor
+	Stream(ctx context.Context, filter OrderFilter, fn func(order domain.Order) error) error
+	// Find returns a page of the orders matching the filter, newest first, with the number of matching orders
+	Find(ctx context.Context, filter OrderFilter, limit, offset int) ([]domain.Order, int, error)
+	// SummarizeByCustomer aggregates the orders of a customer
+	SummarizeByCustomer(ctx context.Context, customerID int) (CustomerOrderSummary, error)
+}
+
+// OrderFilter selects the orders read by OrderRepository.Stream and Find. Product matches orders with a line for that SKU.
+type OrderFilter struct {
+	CustomerID  *int
+	Product     string
+	Currency    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// CustomerOrderSummary aggregates the orders of a customer. The timestamps are nil when there are no orders.
+type CustomerOrderSummary struct {
+	OrderCount    int
+	TotalQuantity int
+	FirstOrderAt  *time.Time
+	LastOrderAt   *time.Time
+}
+
+// ProductRepository defines operations for product catalog persistence
+type ProductRepository interface {
+	Repository
+	Create(ctx context.Context, product domain.Product) (int, error)
+	GetByID(ctx context.Context, id int) (*domain.Product, error)
+	GetBySKU(ctx context.Context, sku string) (*domain.Product, error)
+	Update(ctx context.Context, product domain.Product) error
+	List(ctx context.Context, limit, offset int) ([]domain.Product, error)
+}
+
+// InventoryRepository defines operations for stock level and reservation persistence
+type InventoryRepository interface {
+	Repository
+	GetStockLevel(ctx context.Context, sku string) (*domain.StockLevel, error)
+	// LockStockLevels returns the stock levels of the given SKUs, locked until the surrounding
+	// transaction ends. SKUs without a stock level are not tracked and are left out.
+	LockStockLevels(ctx context.Context, skus []string) (map[string]*domain.StockLevel, error)
+	SaveStockLevel(ctx context.Context, level domain.StockLevel) error
+	GetReservations(ctx context.Context, orderID int) (map[string]int, error)
+	SetReservations(ctx context.Context, orderID int, quantities map[string]int) error
+}
+
+// WebhookRepository defines operations for webhook subscription and delivery persistence
+type WebhookRepository interface {
+	Repository
+	Create(ctx context.Context, webhook domain.Webhook) (int, error)
+	GetByID(ctx context.Context, id int) (*domain.Webhook, error)
+	List(ctx context.Context) ([]domain.Webhook, error)
+	// ListActive retrieves the webhooks of every tenant that receive events, for the delivery worker
+	ListActive(ctx context.Context) ([]domain.Webhook, error)
+	Update(ctx context.Context, webhook domain.Webhook) error
+	// Delete removes a webhook together with its deliveries
+	Delete(ctx context.Context, id int) error
+
+	// CreateDelivery queues a delivery, doing nothing when the event was already queued for the webhook
+	CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	GetDelivery(ctx context.Context, webhookID, id int) (*domain.WebhookDelivery, error)
+	// ListDeliveries retrieves the deliveries of a webhook, newest first, optionally only those with the given status
+	ListDeliveries(ctx context.Context, webhookID int, status domain.WebhookDeliveryStatus, limit, offset int) ([]domain.WebhookDelivery, error)
+	// ClaimDueDeliveries retrieves up to limit pending deliveries of active webhooks of every tenant whose next attempt is due and
+	// postpones them by lease, so that other workers leave them alone while they are attempted
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	// SaveDelivery stores the state of a delivery, together with the attempt that led to it if any
+	SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery, attempt *domain.WebhookAttempt) error
+	// ListAttempts retrieves the attempts of a delivery, oldest first
+	ListAttempts(ctx context.Context, deliveryID int) ([]domain.WebhookAttempt, error)
+}
+
+// InboxRepository records the inbound messages consumers have handled, so that redelivered
+// messages are handled once, and keeps the messages they could not handle
+type InboxRepository interface {
+	Repository
+	// Record marks a message as handled by the consumer with the given status. It returns false,
+	// changing nothing, when the consumer already handled the message for the tenant of the context.
+	Record(ctx context.Context, consumer, messageID, messageType string, status domain.InboxStatus) (bool, error)
+	DeadLetter(ctx context.Context, letter domain.DeadLetter) error
+}
+
+// TransactionManager runs work atomically across repositories
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// SnapshotManager runs reads across repositories against a single consistent snapshot
+type SnapshotManager interface {
+	WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error
 }
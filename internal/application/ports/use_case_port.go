@@ -16,6 +16,12 @@ type CustomerUseCase interface {
 	UpdateCustomer(ctx context.Context, request dto.UpdateCustomerRequest) error
 	DeleteCustomer(ctx context.Context, id int) error
 	ListCustomers(ctx context.Context, limit, offset int) ([]dto.CustomerDTO, error)
+	AddCustomerAddress(ctx context.Context, customerID int, request dto.AddressDTO) (*dto.AddressDTO, error)
+	UpdateCustomerAddress(ctx context.Context, customerID int, request dto.AddressDTO) (*dto.AddressDTO, error)
+	RemoveCustomerAddress(ctx context.Context, customerID, addressID int) error
+	// MergeCustomers moves the orders and details of a duplicate customer to the survivor and
+	// leaves the duplicate as a tombstone. A dry run reports the same changes without making them.
+	MergeCustomers(ctx context.Context, request dto.MergeCustomersRequest) (*dto.MergeReportDTO, error)
 }
 
 // OrderUseCase defines operations for order business logic
@@ -27,4 +33,46 @@ type OrderUseCase interface {
 	DeleteOrder(ctx context.Context, id int) error
 	ListOrders(ctx context.Context, limit, offset int) ([]dto.OrderDTO, error)
 	AssignCustomerToOrder(ctx context.Context, orderID, customerID int) error
+	AddOrderLine(ctx context.Context, orderID int, request dto.OrderLineDTO) error
+	ChangeOrderLine(ctx context.Context, orderID int, request dto.OrderLineDTO) error
+	RemoveOrderLine(ctx context.Context, orderID int, sku string) error
+}
+
+// ProductUseCase defines operations for product catalog business logic
+type ProductUseCase interface {
+	UseCase
+	CreateProduct(ctx context.Context, request dto.CreateProductRequest) (*dto.ProductDTO, error)
+	GetProduct(ctx context.Context, id int) (*dto.ProductDTO, error)
+	UpdateProduct(ctx context.Context, request dto.UpdateProductRequest) (*dto.ProductDTO, error)
+	DeactivateProduct(ctx context.Context, id int) error
+	ListProducts(ctx context.Context, limit, offset int) ([]dto.ProductDTO, error)
+}
+
+// InventoryUseCase defines operations for stock keeping and order reservations
+type InventoryUseCase interface {
+	UseCase
+	GetStockLevel(ctx context.Context, sku string) (*dto.StockLevelDTO, error)
+	SetStockLevel(ctx context.Context, request dto.SetStockLevelRequest) (*dto.StockLevelDTO, error)
+	// ReserveStock makes the reservations of an order match the given quantities per SKU.
+	// Calling it again with the same quantities changes nothing. SKUs without a stock level
+	// are not tracked: any quantity of them can be ordered, and none is reserved.
+	ReserveStock(ctx context.Context, orderID int, quantities map[string]int) error
+	ReleaseStock(ctx context.Context, orderID int) error
+}
+
+// WebhookUseCase defines operations for managing webhooks and inspecting their deliveries
+type WebhookUseCase interface {
+	UseCase
+	CreateWebhook(ctx context.Context, request dto.CreateWebhookRequest) (*dto.WebhookDTO, error)
+	GetWebhook(ctx context.Context, id int) (*dto.WebhookDTO, error)
+	ListWebhooks(ctx context.Context) ([]dto.WebhookDTO, error)
+	UpdateWebhook(ctx context.Context, request dto.UpdateWebhookRequest) (*dto.WebhookDTO, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	// ListWebhookDeliveries retrieves a page of the deliveries of a webhook, newest first,
+	// only those with the given status unless it is empty
+	ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]dto.WebhookDeliveryDTO, error)
+	// GetWebhookDelivery retrieves a delivery of a webhook together with its attempts
+	GetWebhookDelivery(ctx context.Context, webhookID, deliveryID int) (*dto.WebhookDeliveryDTO, error)
+	// RedeliverWebhookDelivery queues a delivery again with a fresh set of attempts, whatever its status
+	RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID int) error
 }
@@ -2,17 +2,25 @@ package services
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"go-cqrs/internal/adapters/http/dto"
 	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
 )
 
 type CustomerService struct {
 	customerRepo ports.CustomerRepository
+	orderRepo    ports.OrderRepository
+	txManager    ports.TransactionManager
 }
 
-func NewCustomerService(customerRepo ports.CustomerRepository) *CustomerService {
-	return &CustomerService{customerRepo: customerRepo}
+func NewCustomerService(customerRepo ports.CustomerRepository, orderRepo ports.OrderRepository, txManager ports.TransactionManager) *CustomerService {
+	return &CustomerService{
+		customerRepo: customerRepo,
+		orderRepo:    orderRepo,
+		txManager:    txManager,
+	}
 }
 
 func (s *CustomerService) CreateCustomer(ctx context.Context, request dto.CreateCustomerRequest) (*dto.CustomerDTO, error) {
@@ -22,16 +30,7 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, request dto.Create
 		return nil, err
 	}
 
-	// Check if email already exists
-	existingCustomer, err := s.customerRepo.GetByEmail(ctx, customer.Email)
-	if err != nil && !errors.Is(err, errors.New("not found")) {
-		return nil, err
-	}
-	if existingCustomer != nil {
-		return nil, errors.New("customer with this email already exists")
-	}
-
-	// Save to repository
+	// Save to repository; the unique email index reports a taken email as a conflict
 	customerID, err := s.customerRepo.Create(ctx, *customer)
 	if err != nil {
 		return nil, err
@@ -54,7 +53,7 @@ func (s *CustomerService) GetCustomer(ctx context.Context, id int) (*dto.Custome
 		return nil, err
 	}
 	if customer == nil {
-		return nil, errors.New("customer not found")
+		return nil, domainerrors.NewNotFoundError("customer", id)
 	}
 
 	customerDTO := dto.ToCustomerDTO(*customer)
@@ -68,18 +67,10 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, request dto.Update
 		return err
 	}
 	if existingCustomer == nil {
-		return errors.New("customer not found")
+		return domainerrors.NewNotFoundError("customer", request.ID)
 	}
-
-	// Check if email is being changed and if it's already taken
-	if existingCustomer.Email != request.Email {
-		customerWithEmail, err := s.customerRepo.GetByEmail(ctx, request.Email)
-		if err != nil && !errors.Is(err, errors.New("not found")) {
-			return err
-		}
-		if customerWithEmail != nil && customerWithEmail.ID != request.ID {
-			return errors.New("email already in use by another customer")
-		}
+	if err := existingCustomer.EnsureActive(); err != nil {
+		return err
 	}
 
 	// Convert DTO to domain entity and update
@@ -88,9 +79,84 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, request dto.Update
 		return err
 	}
 
+	// Keep existing phone number if not provided
+	if request.Phone == "" {
+		customer.Phone = existingCustomer.Phone
+	}
+
+	// The unique email index reports an email taken by another customer as a conflict
 	return s.customerRepo.Update(ctx, *customer)
 }
 
+func (s *CustomerService) AddCustomerAddress(ctx context.Context, customerID int, request dto.AddressDTO) (*dto.AddressDTO, error) {
+	address, err := request.ToDomain()
+	if err != nil {
+		return nil, err
+	}
+	address.ID = 0
+
+	addresses, err := s.changeAddresses(ctx, customerID, func(customer *domain.Customer) error {
+		return customer.AddAddress(*address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The new address is the only one without an ID before saving, and is stored last
+	addressDTO := dto.ToAddressDTO(addresses[len(addresses)-1])
+	return &addressDTO, nil
+}
+
+func (s *CustomerService) UpdateCustomerAddress(ctx context.Context, customerID int, request dto.AddressDTO) (*dto.AddressDTO, error) {
+	address, err := request.ToDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.changeAddresses(ctx, customerID, func(customer *domain.Customer) error {
+		return customer.UpdateAddress(*address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, saved := range addresses {
+		if saved.ID == address.ID {
+			addressDTO := dto.ToAddressDTO(saved)
+			return &addressDTO, nil
+		}
+	}
+	return nil, domainerrors.NewNotFoundError("address", address.ID)
+}
+
+func (s *CustomerService) RemoveCustomerAddress(ctx context.Context, customerID, addressID int) error {
+	_, err := s.changeAddresses(ctx, customerID, func(customer *domain.Customer) error {
+		return customer.RemoveAddress(addressID)
+	})
+	return err
+}
+
+// changeAddresses loads a customer, applies a change to their addresses and saves them
+func (s *CustomerService) changeAddresses(ctx context.Context, customerID int, change func(customer *domain.Customer) error) ([]domain.Address, error) {
+	// Check if customer exists
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, domainerrors.NewNotFoundError("customer", customerID)
+	}
+	if err := customer.EnsureActive(); err != nil {
+		return nil, err
+	}
+
+	if err := change(customer); err != nil {
+		return nil, err
+	}
+
+	return s.customerRepo.SaveAddresses(ctx, customerID, customer.Addresses)
+}
+
 func (s *CustomerService) DeleteCustomer(ctx context.Context, id int) error {
 	// Check if customer exists
 	existingCustomer, err := s.customerRepo.GetByID(ctx, id)
@@ -98,7 +164,16 @@ func (s *CustomerService) DeleteCustomer(ctx context.Context, id int) error {
 		return err
 	}
 	if existingCustomer == nil {
-		return errors.New("customer not found")
+		return domainerrors.NewNotFoundError("customer", id)
+	}
+
+	// The customers merged into it keep pointing at it as tombstones
+	merged, err := s.customerRepo.HasMergedCustomers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if merged {
+		return domainerrors.NewConflictError(fmt.Sprintf("customer %d cannot be deleted, other customers were merged into it", id), nil)
 	}
 
 	// Delete from repository
@@ -119,3 +194,108 @@ func (s *CustomerService) ListCustomers(ctx context.Context, limit, offset int)
 
 	return customerDTOs, nil
 }
+
+func (s *CustomerService) MergeCustomers(ctx context.Context, request dto.MergeCustomersRequest) (*dto.MergeReportDTO, error) {
+	var report *dto.MergeReportDTO
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		survivor, err := s.customerRepo.GetByID(ctx, request.SurvivorID)
+		if err != nil {
+			return err
+		}
+		if survivor == nil {
+			return domainerrors.NewNotFoundError("customer", request.SurvivorID)
+		}
+
+		duplicate, err := s.customerRepo.GetByID(ctx, request.DuplicateID)
+		if err != nil {
+			return err
+		}
+		if duplicate == nil {
+			return domainerrors.NewNotFoundError("customer", request.DuplicateID)
+		}
+
+		if err := duplicate.MergeInto(survivor); err != nil {
+			return err
+		}
+
+		orders, err := s.orderRepo.GetByCustomerID(ctx, duplicate.ID)
+		if err != nil {
+			return err
+		}
+
+		report = &dto.MergeReportDTO{
+			SurvivorID:         survivor.ID,
+			DuplicateID:        duplicate.ID,
+			DryRun:             request.DryRun,
+			ReassignedOrderIDs: make([]int, 0, len(orders)),
+			CopiedAddresses:    make([]dto.AddressDTO, 0),
+		}
+		for _, order := range orders {
+			report.ReassignedOrderIDs = append(report.ReassignedOrderIDs, order.ID)
+		}
+
+		// Copy the addresses the survivor does not know yet; its own defaults stay in place
+		copied := 0
+		for _, address := range duplicate.Addresses {
+			if hasAddress(survivor, address) {
+				continue
+			}
+			address.ID = 0
+			address.IsDefault = false
+			if err := survivor.AddAddress(address); err != nil {
+				return err
+			}
+			report.CopiedAddresses = append(report.CopiedAddresses, dto.ToAddressDTO(address))
+			copied++
+		}
+
+		// Keep the duplicate's phone number when the survivor has none
+		if survivor.Phone == "" && duplicate.Phone != "" {
+			survivor.Phone = duplicate.Phone
+			report.AdoptedPhone = duplicate.Phone
+		}
+
+		if request.DryRun {
+			return nil
+		}
+
+		for i := range orders {
+			if err := orders[i].AssignCustomer(survivor.ID); err != nil {
+				return err
+			}
+			if err := s.orderRepo.Update(ctx, orders[i]); err != nil {
+				return err
+			}
+		}
+
+		if report.AdoptedPhone != "" {
+			if err := s.customerRepo.Update(ctx, *survivor); err != nil {
+				return err
+			}
+		}
+
+		if copied > 0 {
+			if _, err := s.customerRepo.SaveAddresses(ctx, survivor.ID, survivor.Addresses); err != nil {
+				return err
+			}
+		}
+
+		return s.customerRepo.MarkMerged(ctx, duplicate.ID, survivor.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// hasAddress reports whether a customer already has an address at the same location
+func hasAddress(customer *domain.Customer, address domain.Address) bool {
+	for _, existing := range customer.Addresses {
+		if existing.SameLocation(address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"sort"
+)
+
+type InventoryService struct {
+	inventoryRepo ports.InventoryRepository
+	txManager     ports.TransactionManager
+}
+
+func NewInventoryService(inventoryRepo ports.InventoryRepository, txManager ports.TransactionManager) *InventoryService {
+	return &InventoryService{
+		inventoryRepo: inventoryRepo,
+		txManager:     txManager,
+	}
+}
+
+func (s *InventoryService) GetStockLevel(ctx context.Context, sku string) (*dto.StockLevelDTO, error) {
+	level, err := s.inventoryRepo.GetStockLevel(ctx, sku)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock level: %w", err)
+	}
+	if level == nil {
+		return nil, domainerrors.NewNotFoundError("stock level", sku)
+	}
+
+	levelDTO := dto.ToStockLevelDTO(*level)
+	return &levelDTO, nil
+}
+
+func (s *InventoryService) SetStockLevel(ctx context.Context, request dto.SetStockLevelRequest) (*dto.StockLevelDTO, error) {
+	if request.SKU == "" {
+		return nil, domainerrors.NewValidationError("SKU cannot be empty")
+	}
+
+	var result dto.StockLevelDTO
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		levels, err := s.inventoryRepo.LockStockLevels(ctx, []string{request.SKU})
+		if err != nil {
+			return fmt.Errorf("failed to lock stock level: %w", err)
+		}
+
+		// Setting the stock level of a SKU starts tracking it
+		level := levels[request.SKU]
+		if level == nil {
+			level = &domain.StockLevel{SKU: request.SKU}
+		}
+		if err := level.SetOnHand(request.OnHand); err != nil {
+			return err
+		}
+
+		if err := s.inventoryRepo.SaveStockLevel(ctx, *level); err != nil {
+			return err
+		}
+
+		result = dto.ToStockLevelDTO(*level)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (s *InventoryService) ReserveStock(ctx context.Context, orderID int, quantities map[string]int) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.inventoryRepo.GetReservations(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get reservations: %w", err)
+		}
+
+		// Lock every SKU that is or was reserved for the order
+		skus := make([]string, 0, len(current)+len(quantities))
+		for sku := range current {
+			skus = append(skus, sku)
+		}
+		for sku := range quantities {
+			if _, ok := current[sku]; !ok {
+				skus = append(skus, sku)
+			}
+		}
+		sort.Strings(skus)
+
+		levels, err := s.inventoryRepo.LockStockLevels(ctx, skus)
+		if err != nil {
+			return fmt.Errorf("failed to lock stock levels: %w", err)
+		}
+
+		// Apply only the difference to what is already reserved, so repeating a reservation is harmless.
+		// SKUs without a stock level are not tracked, so nothing is reserved for them.
+		reserved := make(map[string]int, len(quantities))
+		for _, sku := range skus {
+			level := levels[sku]
+			if level == nil {
+				continue
+			}
+			reserved[sku] = quantities[sku]
+			delta := quantities[sku] - current[sku]
+			if delta == 0 {
+				continue
+			}
+
+			if delta > 0 {
+				if err := level.Reserve(delta); err != nil {
+					return err
+				}
+			} else {
+				level.Release(-delta)
+			}
+
+			if err := s.inventoryRepo.SaveStockLevel(ctx, *level); err != nil {
+				return err
+			}
+		}
+
+		return s.inventoryRepo.SetReservations(ctx, orderID, reserved)
+	})
+}
+
+func (s *InventoryService) ReleaseStock(ctx context.Context, orderID int) error {
+	return s.ReserveStock(ctx, orderID, map[string]int{})
+}
+
+// orderQuantities sums the quantities of an order's lines per SKU
+func orderQuantities(order domain.Order) map[string]int {
+	quantities := make(map[string]int, len(order.Lines))
+	for _, line := range order.Lines {
+		quantities[line.SKU] += line.Quantity
+	}
+	return quantities
+}
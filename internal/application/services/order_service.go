@@ -12,25 +12,35 @@ import (
 type OrderService struct {
 	orderRepo    ports.OrderRepository
 	customerRepo ports.CustomerRepository
+	productRepo  ports.ProductRepository
+	inventory    ports.InventoryUseCase
+	txManager    ports.TransactionManager
 }
 
-func NewOrderService(orderRepo ports.OrderRepository, customerRepo ports.CustomerRepository) *OrderService {
+func NewOrderService(orderRepo ports.OrderRepository, customerRepo ports.CustomerRepository, productRepo ports.ProductRepository, inventory ports.InventoryUseCase, txManager ports.TransactionManager) *OrderService {
 	return &OrderService{
 		orderRepo:    orderRepo,
 		customerRepo: customerRepo,
+		productRepo:  productRepo,
+		inventory:    inventory,
+		txManager:    txManager,
 	}
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, request dto.CreateOrderRequest) (*dto.OrderDTO, error) {
-	// Create domain entity
-	order, err := domain.NewOrder(request.Product, request.Quantity)
+	// Create domain entity, with the customer assigned if provided
+	order, err := request.ToDomain()
 	if err != nil {
 		return nil, err
 	}
 
-	// Assign customer if provided
+	// Check the lines against the catalog
+	if err := s.applyCatalog(ctx, order, nil); err != nil {
+		return nil, err
+	}
+
+	// Check if customer exists
 	if request.CustomerID != nil {
-		// Check if customer exists
 		customer, err := s.customerRepo.GetByID(ctx, *request.CustomerID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check customer: %w", err)
@@ -38,16 +48,30 @@ func (s *OrderService) CreateOrder(ctx context.Context, request dto.CreateOrderR
 		if customer == nil {
 			return nil, domainerrors.NewNotFoundError("customer", *request.CustomerID)
 		}
+		if err := customer.EnsureActive(); err != nil {
+			return nil, err
+		}
 
-		if err := order.AssignCustomer(*request.CustomerID); err != nil {
+		// Ship to the chosen address, or the customer's default shipping address
+		if err := shipToCustomer(order, customer, request.ShippingAddressID); err != nil {
 			return nil, err
 		}
+	} else if request.ShippingAddressID != nil {
+		return nil, domainerrors.NewValidationError("a shipping address requires a customer")
 	}
 
-	// Save to repository
-	orderID, err := s.orderRepo.Create(ctx, *order)
+	// Save to repository and reserve its stock together
+	var orderID int
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		orderID, err = s.orderRepo.Create(ctx, *order)
+		if err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+
+		return s.inventory.ReserveStock(ctx, orderID, orderQuantities(*order))
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create order: %w", err)
+		return nil, err
 	}
 
 	// Retrieve the created order to return
@@ -83,14 +107,18 @@ func (s *OrderService) UpdateOrder(ctx context.Context, request dto.UpdateOrderR
 		return domainerrors.NewNotFoundError("order", request.ID)
 	}
 
-	// Create domain entity with updated values
-	updatedOrder, err := domain.NewOrder(request.Product, request.Quantity)
+	// Create domain entity with updated values, with the customer assigned if provided
+	updatedOrder, err := request.ToDomain()
 	if err != nil {
 		return err
 	}
-	updatedOrder.ID = request.ID
 
-	// Check and assign customer if provided
+	// Check the lines against the catalog, keeping the prices the order was placed at
+	if err := s.applyCatalog(ctx, updatedOrder, existingOrder); err != nil {
+		return err
+	}
+
+	// Check the customer if provided
 	if request.CustomerID != nil {
 		customer, err := s.customerRepo.GetByID(ctx, *request.CustomerID)
 		if err != nil {
@@ -99,8 +127,7 @@ func (s *OrderService) UpdateOrder(ctx context.Context, request dto.UpdateOrderR
 		if customer == nil {
 			return domainerrors.NewNotFoundError("customer", *request.CustomerID)
 		}
-
-		if err := updatedOrder.AssignCustomer(*request.CustomerID); err != nil {
+		if err := customer.EnsureActive(); err != nil {
 			return err
 		}
 	} else if existingOrder.CustomerID != nil {
@@ -110,8 +137,11 @@ func (s *OrderService) UpdateOrder(ctx context.Context, request dto.UpdateOrderR
 		}
 	}
 
+	// The shipping address was fixed when the order was placed
+	updatedOrder.ShippingAddress = existingOrder.ShippingAddress
+
 	// Update in repository
-	return s.orderRepo.Update(ctx, *updatedOrder)
+	return s.saveOrder(ctx, *updatedOrder)
 }
 
 func (s *OrderService) DeleteOrder(ctx context.Context, id int) error {
@@ -124,8 +154,14 @@ func (s *OrderService) DeleteOrder(ctx context.Context, id int) error {
 		return domainerrors.NewNotFoundError("order", id)
 	}
 
-	// Delete from repository
-	return s.orderRepo.Delete(ctx, id)
+	// Release its stock and delete from repository together
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.inventory.ReleaseStock(ctx, id); err != nil {
+			return err
+		}
+
+		return s.orderRepo.Delete(ctx, id)
+	})
 }
 
 func (s *OrderService) ListOrders(ctx context.Context, limit, offset int) ([]dto.OrderDTO, error) {
@@ -143,6 +179,141 @@ func (s *OrderService) ListOrders(ctx context.Context, limit, offset int) ([]dto
 	return result, nil
 }
 
+func (s *OrderService) AddOrderLine(ctx context.Context, orderID int, request dto.OrderLineDTO) error {
+	return s.changeOrderLines(ctx, orderID, func(order *domain.Order) error {
+		line, err := request.ToDomain(order.Currency)
+		if err != nil {
+			return err
+		}
+		if line, err = s.catalogLine(ctx, line, order); err != nil {
+			return err
+		}
+		return order.AddLine(line)
+	})
+}
+
+func (s *OrderService) ChangeOrderLine(ctx context.Context, orderID int, request dto.OrderLineDTO) error {
+	return s.changeOrderLines(ctx, orderID, func(order *domain.Order) error {
+		line, err := request.ToDomain(order.Currency)
+		if err != nil {
+			return err
+		}
+		if line, err = s.catalogLine(ctx, line, order); err != nil {
+			return err
+		}
+		return order.ChangeLine(line)
+	})
+}
+
+func (s *OrderService) RemoveOrderLine(ctx context.Context, orderID int, sku string) error {
+	return s.changeOrderLines(ctx, orderID, func(order *domain.Order) error {
+		return order.RemoveLine(sku)
+	})
+}
+
+// shipToCustomer snapshots the customer address with the given ID onto the order, or their
+// default shipping address when no ID is given. Customers without addresses leave it empty.
+func shipToCustomer(order *domain.Order, customer *domain.Customer, addressID *int) error {
+	if addressID == nil {
+		if address := customer.DefaultAddress(domain.AddressTypeShipping); address != nil {
+			return order.ShipTo(*address)
+		}
+		return nil
+	}
+
+	address := customer.Address(*addressID)
+	if address == nil {
+		return domainerrors.NewNotFoundError("address", *addressID)
+	}
+	return order.ShipTo(*address)
+}
+
+// applyCatalog checks every line of an order against the product catalog, accepting the prices
+// of the lines of the current version of the order, if any
+func (s *OrderService) applyCatalog(ctx context.Context, order *domain.Order, current *domain.Order) error {
+	lines := make([]domain.OrderLine, len(order.Lines))
+	for i, line := range order.Lines {
+		catalogLine, err := s.catalogLine(ctx, line, current)
+		if err != nil {
+			return err
+		}
+		lines[i] = catalogLine
+	}
+
+	return order.ReplaceLines(lines)
+}
+
+// catalogLine checks that a line refers to an orderable product at its catalog price, and fills in
+// the product name and catalog price when the line does not state them. A line of an existing
+// order may keep the price it was ordered at, as the catalog price may have changed since.
+func (s *OrderService) catalogLine(ctx context.Context, line domain.OrderLine, current *domain.Order) (domain.OrderLine, error) {
+	product, err := s.productRepo.GetBySKU(ctx, line.SKU)
+	if err != nil {
+		return domain.OrderLine{}, fmt.Errorf("failed to check product: %w", err)
+	}
+	if product == nil {
+		return domain.OrderLine{}, domainerrors.NewNotFoundError("product", line.SKU)
+	}
+	if err := product.EnsureOrderable(); err != nil {
+		return domain.OrderLine{}, err
+	}
+
+	if line.Description == "" || line.Description == line.SKU {
+		line.Description = product.Name
+	}
+	if line.UnitPrice.IsZero() {
+		line.UnitPrice = product.Price
+	} else if line.UnitPrice != product.Price && !orderedAt(current, line.SKU, line.UnitPrice) {
+		return domain.OrderLine{}, domainerrors.NewValidationError(
+			fmt.Sprintf("unit price of %s must be its catalog price %s", line.SKU, product.Price))
+	}
+
+	return line, nil
+}
+
+// orderedAt reports whether an order, when given, has a line for the SKU at the given price
+func orderedAt(order *domain.Order, sku string, price domain.Money) bool {
+	if order == nil {
+		return false
+	}
+	for _, line := range order.Lines {
+		if line.SKU == sku && line.UnitPrice == price {
+			return true
+		}
+	}
+	return false
+}
+
+// changeOrderLines loads an order, applies a change to its lines and saves it
+func (s *OrderService) changeOrderLines(ctx context.Context, orderID int, change func(order *domain.Order) error) error {
+	// Check if order exists
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to find order: %w", err)
+	}
+	if order == nil {
+		return domainerrors.NewNotFoundError("order", orderID)
+	}
+
+	if err := change(order); err != nil {
+		return err
+	}
+
+	// Update in repository
+	return s.saveOrder(ctx, *order)
+}
+
+// saveOrder updates an order and adjusts its stock reservations to its lines together
+func (s *OrderService) saveOrder(ctx context.Context, order domain.Order) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return err
+		}
+
+		return s.inventory.ReserveStock(ctx, order.ID, orderQuantities(order))
+	})
+}
+
 func (s *OrderService) AssignCustomerToOrder(ctx context.Context, orderID, customerID int) error {
 	// Check if order exists
 	order, err := s.orderRepo.GetByID(ctx, orderID)
@@ -161,6 +332,9 @@ func (s *OrderService) AssignCustomerToOrder(ctx context.Context, orderID, custo
 	if customer == nil {
 		return domainerrors.NewNotFoundError("customer", customerID)
 	}
+	if err := customer.EnsureActive(); err != nil {
+		return err
+	}
 
 	// Assign customer
 	if err := order.AssignCustomer(customerID); err != nil {
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+type ProductService struct {
+	productRepo ports.ProductRepository
+}
+
+func NewProductService(productRepo ports.ProductRepository) *ProductService {
+	return &ProductService{productRepo: productRepo}
+}
+
+func (s *ProductService) CreateProduct(ctx context.Context, request dto.CreateProductRequest) (*dto.ProductDTO, error) {
+	// Convert DTO to domain entity
+	product, err := request.ToDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if SKU already exists
+	existingProduct, err := s.productRepo.GetBySKU(ctx, product.SKU)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product: %w", err)
+	}
+	if existingProduct != nil {
+		return nil, domainerrors.NewValidationError("product with this SKU already exists")
+	}
+
+	// Save to repository
+	productID, err := s.productRepo.Create(ctx, *product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+	product.ID = productID
+
+	productDTO := dto.ToProductDTO(*product)
+	return &productDTO, nil
+}
+
+func (s *ProductService) GetProduct(ctx context.Context, id int) (*dto.ProductDTO, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, domainerrors.NewNotFoundError("product", id)
+	}
+
+	productDTO := dto.ToProductDTO(*product)
+	return &productDTO, nil
+}
+
+func (s *ProductService) UpdateProduct(ctx context.Context, request dto.UpdateProductRequest) (*dto.ProductDTO, error) {
+	// Check if product exists
+	product, err := s.productRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if product == nil {
+		return nil, domainerrors.NewNotFoundError("product", request.ID)
+	}
+
+	price, err := domain.NewMoney(request.Price, request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := product.Update(request.Name, price); err != nil {
+		return nil, err
+	}
+
+	// Update in repository
+	if err := s.productRepo.Update(ctx, *product); err != nil {
+		return nil, err
+	}
+
+	productDTO := dto.ToProductDTO(*product)
+	return &productDTO, nil
+}
+
+func (s *ProductService) DeactivateProduct(ctx context.Context, id int) error {
+	// Check if product exists
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find product: %w", err)
+	}
+	if product == nil {
+		return domainerrors.NewNotFoundError("product", id)
+	}
+
+	product.Deactivate()
+
+	// Update in repository
+	return s.productRepo.Update(ctx, *product)
+}
+
+func (s *ProductService) ListProducts(ctx context.Context, limit, offset int) ([]dto.ProductDTO, error) {
+	products, err := s.productRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	// Convert domain entities to DTOs
+	result := make([]dto.ProductDTO, len(products))
+	for i, product := range products {
+		result[i] = dto.ToProductDTO(product)
+	}
+
+	return result, nil
+}
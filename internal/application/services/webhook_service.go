@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"time"
+)
+
+type WebhookService struct {
+	webhookRepo ports.WebhookRepository
+}
+
+func NewWebhookService(webhookRepo ports.WebhookRepository) *WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, request dto.CreateWebhookRequest) (*dto.WebhookDTO, error) {
+	if request.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		request.Secret = secret
+	}
+
+	// Convert DTO to domain entity
+	webhook, err := request.ToDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	webhookID, err := s.webhookRepo.Create(ctx, *webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	createdWebhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get created webhook: %w", err)
+	}
+	if createdWebhook == nil {
+		return nil, domainerrors.NewNotFoundError("webhook", webhookID)
+	}
+
+	// The caller needs the secret to verify deliveries; it is not shown again
+	webhookDTO := dto.ToWebhookDTO(*createdWebhook)
+	webhookDTO.Secret = createdWebhook.Secret
+	return &webhookDTO, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id int) (*dto.WebhookDTO, error) {
+	webhook, err := s.findWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDTO := dto.ToWebhookDTO(*webhook)
+	return &webhookDTO, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]dto.WebhookDTO, error) {
+	webhooks, err := s.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]dto.WebhookDTO, len(webhooks))
+	for i, webhook := range webhooks {
+		result[i] = dto.ToWebhookDTO(webhook)
+	}
+
+	return result, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, request dto.UpdateWebhookRequest) (*dto.WebhookDTO, error) {
+	webhook, err := s.findWebhook(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = request.URL
+	webhook.EventTypes = request.EventTypes
+	webhook.Active = request.Active
+	if request.Secret != "" {
+		webhook.Secret = request.Secret
+	}
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Update(ctx, *webhook); err != nil {
+		return nil, err
+	}
+
+	webhookDTO := dto.ToWebhookDTO(*webhook)
+	if request.Secret != "" {
+		webhookDTO.Secret = webhook.Secret
+	}
+	return &webhookDTO, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if _, err := s.findWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]dto.WebhookDeliveryDTO, error) {
+	switch domain.WebhookDeliveryStatus(status) {
+	case "", domain.WebhookDeliveryPending, domain.WebhookDeliveryDelivered, domain.WebhookDeliveryDead:
+	default:
+		return nil, domainerrors.NewValidationError("status must be one of pending, delivered or dead")
+	}
+
+	if _, err := s.findWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, webhookID, domain.WebhookDeliveryStatus(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	result := make([]dto.WebhookDeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		result[i] = dto.ToWebhookDeliveryDTO(delivery)
+	}
+
+	return result, nil
+}
+
+func (s *WebhookService) GetWebhookDelivery(ctx context.Context, webhookID, deliveryID int) (*dto.WebhookDeliveryDTO, error) {
+	delivery, err := s.findDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.webhookRepo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+
+	deliveryDTO := dto.ToWebhookDeliveryDTO(*delivery)
+	deliveryDTO.Payload = delivery.Payload
+	for _, attempt := range attempts {
+		deliveryDTO.AttemptLog = append(deliveryDTO.AttemptLog, dto.ToWebhookAttemptDTO(attempt))
+	}
+	return &deliveryDTO, nil
+}
+
+func (s *WebhookService) RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID int) error {
+	delivery, err := s.findDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	delivery.Redeliver(time.Now().UTC())
+	return s.webhookRepo.SaveDelivery(ctx, *delivery, nil)
+}
+
+func (s *WebhookService) findWebhook(ctx context.Context, id int) (*domain.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	if webhook == nil {
+		return nil, domainerrors.NewNotFoundError("webhook", id)
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) findDelivery(ctx context.Context, webhookID, deliveryID int) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+	if delivery == nil {
+		return nil, domainerrors.NewNotFoundError("webhook delivery", deliveryID)
+	}
+	return delivery, nil
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
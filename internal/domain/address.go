@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// AddressType tells what a customer address is used for
+type AddressType string
+
+const (
+	AddressTypeBilling  AddressType = "billing"
+	AddressTypeShipping AddressType = "shipping"
+)
+
+// MaxCustomerAddresses limits how many addresses a customer can keep
+const MaxCustomerAddresses = 20
+
+// CountryCodePattern matches an ISO 3166-1 alpha-2 country code
+const CountryCodePattern = `^[A-Z]{2}$`
+
+var countryCodePattern = regexp.MustCompile(CountryCodePattern)
+
+// Address is a postal address of a customer
+type Address struct {
+	ID         int
+	Type       AddressType
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+	IsDefault  bool
+}
+
+// NewAddress creates a validated address
+func NewAddress(addressType AddressType, line1, line2, city, region, postalCode, country string, isDefault bool) (*Address, error) {
+	address := &Address{
+		Type:       addressType,
+		Line1:      strings.TrimSpace(line1),
+		Line2:      strings.TrimSpace(line2),
+		City:       strings.TrimSpace(city),
+		Region:     strings.TrimSpace(region),
+		PostalCode: strings.TrimSpace(postalCode),
+		Country:    strings.ToUpper(strings.TrimSpace(country)),
+		IsDefault:  isDefault,
+	}
+
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func (a Address) Validate() error {
+	if a.Type != AddressTypeBilling && a.Type != AddressTypeShipping {
+		return domainerrors.NewValidationError("address type must be billing or shipping")
+	}
+
+	if a.Line1 == "" {
+		return domainerrors.NewValidationError("address line 1 cannot be empty")
+	}
+
+	if a.City == "" {
+		return domainerrors.NewValidationError("address city cannot be empty")
+	}
+
+	if !countryCodePattern.MatchString(a.Country) {
+		return domainerrors.NewValidationError("address country must be an ISO 3166-1 alpha-2 code")
+	}
+
+	return nil
+}
+
+// SameLocation reports whether two addresses of the same type point at the same place,
+// ignoring differences in case and IDs
+func (a Address) SameLocation(other Address) bool {
+	return a.Type == other.Type &&
+		strings.EqualFold(a.Line1, other.Line1) &&
+		strings.EqualFold(a.Line2, other.Line2) &&
+		strings.EqualFold(a.City, other.City) &&
+		strings.EqualFold(a.Region, other.Region) &&
+		strings.EqualFold(a.PostalCode, other.PostalCode) &&
+		a.Country == other.Country
+}
@@ -1,27 +1,28 @@
 package domain
 
 import (
-	"regexp"
+	"fmt"
 
 	domainerrors "go-cqrs/internal/domain/errors"
 )
 
 // Customer represents a customer in the domain
 type Customer struct {
-	ID    int
-	Name  string
-	Email string
+	ID        int
+	Name      string
+	Email     Email
+	Phone     string
+	Addresses []Address
+	// MergedInto is the ID of the customer this one was merged into; merged customers are kept as tombstones
+	MergedInto *int
 	//CreatedAt time.Time
 	//UpdatedAt time.Time
 }
 
 func NewCustomer(name string, email string) (*Customer, error) {
-	customer := &Customer{
-		Name:  name,
-		Email: email,
-	}
+	customer := &Customer{}
 
-	if err := customer.Validate(); err != nil {
+	if err := customer.Update(name, email); err != nil {
 		return nil, err
 	}
 
@@ -33,22 +34,181 @@ func (c *Customer) Validate() error {
 		return domainerrors.NewValidationError("customer name cannot be empty")
 	}
 
-	if !isValidEmail(c.Email) {
-		return domainerrors.NewValidationError("invalid email format")
+	if _, err := NewEmail(c.Email.String()); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 func (c *Customer) Update(name string, email string) error {
+	normalized, err := NewEmail(email)
+	if err != nil {
+		return err
+	}
+
+	// Validate the changes on a copy so a rejected update leaves the customer as it was
+	updated := *c
+	updated.Name = name
+	updated.Email = normalized
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+
 	c.Name = name
-	c.Email = email
-	return c.Validate()
+	c.Email = normalized
+	return nil
+}
+
+// EnsureActive checks that the customer has not been merged into another one
+func (c *Customer) EnsureActive() error {
+	if c.MergedInto != nil {
+		return domainerrors.NewValidationError(fmt.Sprintf("customer %d has been merged into customer %d", c.ID, *c.MergedInto))
+	}
+	return nil
+}
+
+// MergeInto turns the customer into a tombstone pointing at the survivor of a merge
+func (c *Customer) MergeInto(survivor *Customer) error {
+	if survivor.ID == c.ID {
+		return domainerrors.NewValidationError("a customer cannot be merged into itself")
+	}
+	if err := c.EnsureActive(); err != nil {
+		return err
+	}
+	if err := survivor.EnsureActive(); err != nil {
+		return err
+	}
+
+	survivorID := survivor.ID
+	c.MergedInto = &survivorID
+	return nil
+}
+
+// SetPhone records the customer's phone number in E.164 format; an empty number removes it
+func (c *Customer) SetPhone(phone string) error {
+	if phone == "" {
+		c.Phone = ""
+		return nil
+	}
+
+	normalized, err := NormalizePhoneNumber(phone)
+	if err != nil {
+		return err
+	}
+
+	c.Phone = normalized
+	return nil
+}
+
+// AddAddress adds an address to the customer. The first address of a type becomes
+// the default for that type, and a new default replaces the previous one.
+func (c *Customer) AddAddress(address Address) error {
+	if err := address.Validate(); err != nil {
+		return err
+	}
+
+	if len(c.Addresses) >= MaxCustomerAddresses {
+		return domainerrors.NewValidationError("a customer cannot have more than 20 addresses")
+	}
+
+	if c.DefaultAddress(address.Type) == nil {
+		address.IsDefault = true
+	}
+
+	c.Addresses = append(c.Addresses, address)
+	c.keepSingleDefault(len(c.Addresses) - 1)
+	return nil
 }
 
-// isValidEmail validates email format using simple regex
-func isValidEmail(email string) bool {
-	pattern := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-	match, _ := regexp.MatchString(pattern, email)
-	return match
+// UpdateAddress replaces the address with the same ID
+func (c *Customer) UpdateAddress(address Address) error {
+	if err := address.Validate(); err != nil {
+		return err
+	}
+
+	index := c.addressIndex(address.ID)
+	if index < 0 {
+		return domainerrors.NewNotFoundError("address", address.ID)
+	}
+
+	previous := c.Addresses[index]
+	c.Addresses[index] = address
+	c.keepSingleDefault(index)
+
+	// Moving or un-marking the default leaves the previous type to fall back to another address
+	if previous.IsDefault && (previous.Type != address.Type || !address.IsDefault) {
+		c.ensureDefault(previous.Type)
+	}
+	c.ensureDefault(address.Type)
+	return nil
+}
+
+// RemoveAddress removes an address. When it was a default, another address of the same type takes over.
+func (c *Customer) RemoveAddress(addressID int) error {
+	index := c.addressIndex(addressID)
+	if index < 0 {
+		return domainerrors.NewNotFoundError("address", addressID)
+	}
+
+	removed := c.Addresses[index]
+	c.Addresses = append(c.Addresses[:index], c.Addresses[index+1:]...)
+
+	if removed.IsDefault {
+		c.ensureDefault(removed.Type)
+	}
+	return nil
+}
+
+// Address returns the address with the given ID, or nil when the customer has none
+func (c *Customer) Address(addressID int) *Address {
+	if index := c.addressIndex(addressID); index >= 0 {
+		return &c.Addresses[index]
+	}
+	return nil
+}
+
+// DefaultAddress returns the default address of a type, or nil when the customer has none
+func (c *Customer) DefaultAddress(addressType AddressType) *Address {
+	for i := range c.Addresses {
+		if c.Addresses[i].Type == addressType && c.Addresses[i].IsDefault {
+			return &c.Addresses[i]
+		}
+	}
+	return nil
+}
+
+func (c *Customer) addressIndex(addressID int) int {
+	for i, address := range c.Addresses {
+		if address.ID == addressID {
+			return i
+		}
+	}
+	return -1
+}
+
+// keepSingleDefault clears the default flag of the other addresses of the same type
+// when the address at index is a default
+func (c *Customer) keepSingleDefault(index int) {
+	if !c.Addresses[index].IsDefault {
+		return
+	}
+	for i := range c.Addresses {
+		if i != index && c.Addresses[i].Type == c.Addresses[index].Type {
+			c.Addresses[i].IsDefault = false
+		}
+	}
+}
+
+// ensureDefault makes the first address of a type its default when none is
+func (c *Customer) ensureDefault(addressType AddressType) {
+	if c.DefaultAddress(addressType) != nil {
+		return
+	}
+	for i := range c.Addresses {
+		if c.Addresses[i].Type == addressType {
+			c.Addresses[i].IsDefault = true
+			return
+		}
+	}
 }
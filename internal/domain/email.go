@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+
+	"golang.org/x/net/idna"
+)
+
+// Length limits of an email address from RFC 5321, counted in octets
+const (
+	maxEmailLength       = 254
+	maxEmailLocalLength  = 64
+	maxEmailDomainLength = 253
+	maxEmailLabelLength  = 63
+)
+
+// Email is a validated email address in its normalized form: trimmed, lower case,
+// and with an internationalized domain converted to its ASCII (punycode) form.
+// Two addresses that differ only in case or domain spelling normalize to the same Email.
+type Email string
+
+// NewEmail validates an email address and returns its normalized form
+func NewEmail(address string) (Email, error) {
+	address = strings.TrimSpace(address)
+
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return "", domainerrors.NewValidationError("invalid email format")
+	}
+
+	local, err := normalizeEmailLocalPart(address[:at])
+	if err != nil {
+		return "", err
+	}
+
+	domain, err := normalizeEmailDomain(address[at+1:])
+	if err != nil {
+		return "", err
+	}
+
+	email := local + "@" + domain
+	if len(email) > maxEmailLength {
+		return "", domainerrors.NewValidationError("email address cannot be longer than 254 characters")
+	}
+
+	return Email(email), nil
+}
+
+// String returns the normalized address
+func (e Email) String() string {
+	return string(e)
+}
+
+// normalizeEmailLocalPart lower-cases the part before the @ and checks that it is a dot-atom.
+// Letters and digits outside ASCII are accepted for internationalized addresses.
+func normalizeEmailLocalPart(local string) (string, error) {
+	local = strings.ToLower(local)
+
+	if len(local) > maxEmailLocalLength {
+		return "", domainerrors.NewValidationError("email local part cannot be longer than 64 characters")
+	}
+
+	if !utf8.ValidString(local) || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return "", domainerrors.NewValidationError("invalid email format")
+	}
+
+	for _, r := range local {
+		if r == '.' || isEmailAtomChar(r) {
+			continue
+		}
+		return "", domainerrors.NewValidationError("invalid email format")
+	}
+
+	return local, nil
+}
+
+// isEmailAtomChar reports whether r may appear in an unquoted local part
+func isEmailAtomChar(r rune) bool {
+	if r > unicode.MaxASCII {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+/=?^_`{|}~-", r)
+}
+
+// normalizeEmailDomain converts the part after the @ to its lower case ASCII form
+// and checks that it is a host name with at least two labels
+func normalizeEmailDomain(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", domainerrors.NewValidationError("invalid email domain")
+	}
+	ascii = strings.ToLower(ascii)
+
+	if len(ascii) > maxEmailDomainLength {
+		return "", domainerrors.NewValidationError("email domain cannot be longer than 253 characters")
+	}
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", domainerrors.NewValidationError("invalid email domain")
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > maxEmailLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", domainerrors.NewValidationError("invalid email domain")
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return "", domainerrors.NewValidationError("invalid email domain")
+			}
+		}
+	}
+
+	// A top-level domain is never all digits, which rules out addresses at bare IP numbers
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", domainerrors.NewValidationError("invalid email domain")
+	}
+
+	return ascii, nil
+}
@@ -8,11 +8,13 @@ import (
 type ErrorCode string
 
 const (
-	ErrorCodeNotFound      ErrorCode = "NOT_FOUND"
-	ErrorCodeValidation    ErrorCode = "VALIDATION_ERROR"
-	ErrorCodeUnauthorized  ErrorCode = "UNAUTHORIZED"
-	ErrorCodeDatabaseError ErrorCode = "DATABASE_ERROR"
-	ErrorCodeInvalidInput  ErrorCode = "INVALID_INPUT"
+	ErrorCodeNotFound          ErrorCode = "NOT_FOUND"
+	ErrorCodeValidation        ErrorCode = "VALIDATION_ERROR"
+	ErrorCodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	ErrorCodeDatabaseError     ErrorCode = "DATABASE_ERROR"
+	ErrorCodeInvalidInput      ErrorCode = "INVALID_INPUT"
+	ErrorCodeInsufficientStock ErrorCode = "INSUFFICIENT_STOCK"
+	ErrorCodeConflict          ErrorCode = "CONFLICT"
 )
 
 // DomainError represents an error in the domain layer
@@ -54,6 +56,21 @@ func NewInvalidInputError(message string) *DomainError {
 	}
 }
 
+func NewInsufficientStockError(sku string, requested, available int) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeInsufficientStock,
+		Message: fmt.Sprintf("insufficient stock for %s: requested %d, available %d", sku, requested, available),
+	}
+}
+
+func NewConflictError(message string, err error) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeConflict,
+		Message: message,
+		Err:     err,
+	}
+}
+
 func NewDatabaseError(err error, operation string) *DomainError {
 	return &DomainError{
 		Code:    ErrorCodeDatabaseError,
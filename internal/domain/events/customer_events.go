@@ -9,15 +9,17 @@ type CustomerCreatedEvent struct {
 	ID        string
 	Name      string
 	Email     string
+	Phone     string
 	CreatedAt time.Time
 }
 
 // NewCustomerCreatedEvent creates a new CustomerCreatedEvent
-func NewCustomerCreatedEvent(id, name, email string) *CustomerCreatedEvent {
+func NewCustomerCreatedEvent(id, name, email, phone string) *CustomerCreatedEvent {
 	return &CustomerCreatedEvent{
 		ID:        id,
 		Name:      name,
 		Email:     email,
+		Phone:     phone,
 		CreatedAt: time.Now(),
 	}
 }
@@ -27,15 +29,17 @@ type CustomerUpdatedEvent struct {
 	ID        string
 	Name      string
 	Email     string
+	Phone     string
 	UpdatedAt time.Time
 }
 
 // NewCustomerUpdatedEvent creates a new CustomerUpdatedEvent
-func NewCustomerUpdatedEvent(id, name, email string) *CustomerUpdatedEvent {
+func NewCustomerUpdatedEvent(id, name, email, phone string) *CustomerUpdatedEvent {
 	return &CustomerUpdatedEvent{
 		ID:        id,
 		Name:      name,
 		Email:     email,
+		Phone:     phone,
 		UpdatedAt: time.Now(),
 	}
 }
@@ -53,3 +57,83 @@ func NewCustomerDeletedEvent(id string) *CustomerDeletedEvent {
 		DeletedAt: time.Now(),
 	}
 }
+
+// CustomerAddress describes an address of a customer in address events
+type CustomerAddress struct {
+	AddressID  int
+	Type       string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+	IsDefault  bool
+}
+
+// CustomerAddressAddedEvent represents an event when an address is added to a customer
+type CustomerAddressAddedEvent struct {
+	CustomerID string
+	CustomerAddress
+	AddedAt time.Time
+}
+
+// NewCustomerAddressAddedEvent creates a new CustomerAddressAddedEvent
+func NewCustomerAddressAddedEvent(customerID string, address CustomerAddress) *CustomerAddressAddedEvent {
+	return &CustomerAddressAddedEvent{
+		CustomerID:      customerID,
+		CustomerAddress: address,
+		AddedAt:         time.Now(),
+	}
+}
+
+// CustomerAddressUpdatedEvent represents an event when an address of a customer is changed
+type CustomerAddressUpdatedEvent struct {
+	CustomerID string
+	CustomerAddress
+	UpdatedAt time.Time
+}
+
+// NewCustomerAddressUpdatedEvent creates a new CustomerAddressUpdatedEvent
+func NewCustomerAddressUpdatedEvent(customerID string, address CustomerAddress) *CustomerAddressUpdatedEvent {
+	return &CustomerAddressUpdatedEvent{
+		CustomerID:      customerID,
+		CustomerAddress: address,
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// CustomerAddressRemovedEvent represents an event when an address is removed from a customer
+type CustomerAddressRemovedEvent struct {
+	CustomerID string
+	AddressID  int
+	RemovedAt  time.Time
+}
+
+// NewCustomerAddressRemovedEvent creates a new CustomerAddressRemovedEvent
+func NewCustomerAddressRemovedEvent(customerID string, addressID int) *CustomerAddressRemovedEvent {
+	return &CustomerAddressRemovedEvent{
+		CustomerID: customerID,
+		AddressID:  addressID,
+		RemovedAt:  time.Now(),
+	}
+}
+
+// CustomerMergedEvent represents an event when a duplicate customer is merged into a survivor.
+// It belongs to the duplicate, which is left as a tombstone pointing at the survivor.
+type CustomerMergedEvent struct {
+	ID                 string
+	SurvivorID         string
+	ReassignedOrderIDs []int
+	MergedAt           time.Time
+}
+
+// NewCustomerMergedEvent creates a new CustomerMergedEvent
+func NewCustomerMergedEvent(id, survivorID string, reassignedOrderIDs []int) *CustomerMergedEvent {
+	return &CustomerMergedEvent{
+		ID:                 id,
+		SurvivorID:         survivorID,
+		ReassignedOrderIDs: reassignedOrderIDs,
+		MergedAt:           time.Now(),
+	}
+}
@@ -8,6 +8,7 @@ import (
 type Event interface {
 	EventType() string
 	OccurredAt() time.Time
+	AggregateID() string
 }
 
 // EventType constants
@@ -15,10 +16,21 @@ const (
 	CustomerCreatedEventType         = "customer.created"
 	CustomerUpdatedEventType         = "customer.updated"
 	CustomerDeletedEventType         = "customer.deleted"
+	CustomerAddressAddedEventType    = "customer.address_added"
+	CustomerAddressUpdatedEventType  = "customer.address_updated"
+	CustomerAddressRemovedEventType  = "customer.address_removed"
+	CustomerMergedEventType          = "customer.merged"
 	OrderCreatedEventType            = "order.created"
 	OrderUpdatedEventType            = "order.updated"
 	OrderDeletedEventType            = "order.deleted"
 	CustomerAssignedToOrderEventType = "order.customer_assigned"
+	OrderLineAddedEventType          = "order.line_added"
+	OrderLineChangedEventType        = "order.line_changed"
+	OrderLineRemovedEventType        = "order.line_removed"
+	ProductCreatedEventType          = "product.created"
+	ProductUpdatedEventType          = "product.updated"
+	ProductDeactivatedEventType      = "product.deactivated"
+	StockLevelSetEventType           = "inventory.stock_set"
 )
 
 // EventType implementations
@@ -30,6 +42,10 @@ func (e *CustomerCreatedEvent) OccurredAt() time.Time {
 	return e.CreatedAt
 }
 
+func (e *CustomerCreatedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *CustomerUpdatedEvent) EventType() string {
 	return CustomerUpdatedEventType
 }
@@ -38,6 +54,10 @@ func (e *CustomerUpdatedEvent) OccurredAt() time.Time {
 	return e.UpdatedAt
 }
 
+func (e *CustomerUpdatedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *CustomerDeletedEvent) EventType() string {
 	return CustomerDeletedEventType
 }
@@ -46,6 +66,58 @@ func (e *CustomerDeletedEvent) OccurredAt() time.Time {
 	return e.DeletedAt
 }
 
+func (e *CustomerDeletedEvent) AggregateID() string {
+	return e.ID
+}
+
+func (e *CustomerAddressAddedEvent) EventType() string {
+	return CustomerAddressAddedEventType
+}
+
+func (e *CustomerAddressAddedEvent) OccurredAt() time.Time {
+	return e.AddedAt
+}
+
+func (e *CustomerAddressAddedEvent) AggregateID() string {
+	return e.CustomerID
+}
+
+func (e *CustomerAddressUpdatedEvent) EventType() string {
+	return CustomerAddressUpdatedEventType
+}
+
+func (e *CustomerAddressUpdatedEvent) OccurredAt() time.Time {
+	return e.UpdatedAt
+}
+
+func (e *CustomerAddressUpdatedEvent) AggregateID() string {
+	return e.CustomerID
+}
+
+func (e *CustomerAddressRemovedEvent) EventType() string {
+	return CustomerAddressRemovedEventType
+}
+
+func (e *CustomerAddressRemovedEvent) OccurredAt() time.Time {
+	return e.RemovedAt
+}
+
+func (e *CustomerAddressRemovedEvent) AggregateID() string {
+	return e.CustomerID
+}
+
+func (e *CustomerMergedEvent) EventType() string {
+	return CustomerMergedEventType
+}
+
+func (e *CustomerMergedEvent) OccurredAt() time.Time {
+	return e.MergedAt
+}
+
+func (e *CustomerMergedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *OrderCreatedEvent) EventType() string {
 	return OrderCreatedEventType
 }
@@ -54,6 +126,10 @@ func (e *OrderCreatedEvent) OccurredAt() time.Time {
 	return e.CreatedAt
 }
 
+func (e *OrderCreatedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *OrderUpdatedEvent) EventType() string {
 	return OrderUpdatedEventType
 }
@@ -62,6 +138,10 @@ func (e *OrderUpdatedEvent) OccurredAt() time.Time {
 	return e.UpdatedAt
 }
 
+func (e *OrderUpdatedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *OrderDeletedEvent) EventType() string {
 	return OrderDeletedEventType
 }
@@ -70,6 +150,10 @@ func (e *OrderDeletedEvent) OccurredAt() time.Time {
 	return e.DeletedAt
 }
 
+func (e *OrderDeletedEvent) AggregateID() string {
+	return e.ID
+}
+
 func (e *CustomerAssignedToOrderEvent) EventType() string {
 	return CustomerAssignedToOrderEventType
 }
@@ -77,3 +161,91 @@ func (e *CustomerAssignedToOrderEvent) EventType() string {
 func (e *CustomerAssignedToOrderEvent) OccurredAt() time.Time {
 	return e.AssignedAt
 }
+
+func (e *CustomerAssignedToOrderEvent) AggregateID() string {
+	return e.OrderID
+}
+
+func (e *OrderLineAddedEvent) EventType() string {
+	return OrderLineAddedEventType
+}
+
+func (e *OrderLineAddedEvent) OccurredAt() time.Time {
+	return e.AddedAt
+}
+
+func (e *OrderLineAddedEvent) AggregateID() string {
+	return e.OrderID
+}
+
+func (e *OrderLineChangedEvent) EventType() string {
+	return OrderLineChangedEventType
+}
+
+func (e *OrderLineChangedEvent) OccurredAt() time.Time {
+	return e.ChangedAt
+}
+
+func (e *OrderLineChangedEvent) AggregateID() string {
+	return e.OrderID
+}
+
+func (e *OrderLineRemovedEvent) EventType() string {
+	return OrderLineRemovedEventType
+}
+
+func (e *OrderLineRemovedEvent) OccurredAt() time.Time {
+	return e.RemovedAt
+}
+
+func (e *OrderLineRemovedEvent) AggregateID() string {
+	return e.OrderID
+}
+
+func (e *ProductCreatedEvent) EventType() string {
+	return ProductCreatedEventType
+}
+
+func (e *ProductCreatedEvent) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+func (e *ProductCreatedEvent) AggregateID() string {
+	return e.ID
+}
+
+func (e *ProductUpdatedEvent) EventType() string {
+	return ProductUpdatedEventType
+}
+
+func (e *ProductUpdatedEvent) OccurredAt() time.Time {
+	return e.UpdatedAt
+}
+
+func (e *ProductUpdatedEvent) AggregateID() string {
+	return e.ID
+}
+
+func (e *ProductDeactivatedEvent) EventType() string {
+	return ProductDeactivatedEventType
+}
+
+func (e *ProductDeactivatedEvent) OccurredAt() time.Time {
+	return e.DeactivatedAt
+}
+
+func (e *ProductDeactivatedEvent) AggregateID() string {
+	return e.ID
+}
+
+func (e *StockLevelSetEvent) EventType() string {
+	return StockLevelSetEventType
+}
+
+func (e *StockLevelSetEvent) OccurredAt() time.Time {
+	return e.SetAt
+}
+
+func (e *StockLevelSetEvent) AggregateID() string {
+	return e.SKU
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+)
+
+// StockLevelSetEvent represents an event when the stock on hand for a SKU is recorded
+type StockLevelSetEvent struct {
+	SKU      string
+	OnHand   int
+	Reserved int
+	SetAt    time.Time
+}
+
+// NewStockLevelSetEvent creates a new StockLevelSetEvent
+func NewStockLevelSetEvent(sku string, onHand, reserved int) *StockLevelSetEvent {
+	return &StockLevelSetEvent{
+		SKU:      sku,
+		OnHand:   onHand,
+		Reserved: reserved,
+		SetAt:    time.Now(),
+	}
+}
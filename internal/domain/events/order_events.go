@@ -4,20 +4,36 @@ import (
 	"time"
 )
 
+// OrderLine describes a line of an order as recorded in order events.
+// Amounts are in minor units of the order currency.
+type OrderLine struct {
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+	Subtotal    int64
+}
+
 // OrderCreatedEvent represents an event when an order is created
 type OrderCreatedEvent struct {
 	ID        string
 	Product   string
 	Quantity  int
+	Currency  string
+	Total     int64
+	Lines     []OrderLine
 	CreatedAt time.Time
 }
 
 // NewOrderCreatedEvent creates a new OrderCreatedEvent
-func NewOrderCreatedEvent(id, product string, quantity int) *OrderCreatedEvent {
+func NewOrderCreatedEvent(id, product string, quantity int, currency string, total int64, lines []OrderLine) *OrderCreatedEvent {
 	return &OrderCreatedEvent{
 		ID:        id,
 		Product:   product,
 		Quantity:  quantity,
+		Currency:  currency,
+		Total:     total,
+		Lines:     lines,
 		CreatedAt: time.Now(),
 	}
 }
@@ -27,16 +43,22 @@ type OrderUpdatedEvent struct {
 	ID         string
 	Product    string
 	Quantity   int
+	Currency   string
+	Total      int64
+	Lines      []OrderLine
 	CustomerID *string
 	UpdatedAt  time.Time
 }
 
 // NewOrderUpdatedEvent creates a new OrderUpdatedEvent
-func NewOrderUpdatedEvent(id, product string, quantity int, customerID *string) *OrderUpdatedEvent {
+func NewOrderUpdatedEvent(id, product string, quantity int, currency string, total int64, lines []OrderLine, customerID *string) *OrderUpdatedEvent {
 	return &OrderUpdatedEvent{
 		ID:         id,
 		Product:    product,
 		Quantity:   quantity,
+		Currency:   currency,
+		Total:      total,
+		Lines:      lines,
 		CustomerID: customerID,
 		UpdatedAt:  time.Now(),
 	}
@@ -71,3 +93,79 @@ func NewCustomerAssignedToOrderEvent(orderID, customerID string) *CustomerAssign
 		AssignedAt: time.Now(),
 	}
 }
+
+// OrderLineAddedEvent represents an event when a line is added to an order
+type OrderLineAddedEvent struct {
+	OrderID     string
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+	Subtotal    int64
+	Currency    string
+	OrderTotal  int64
+	AddedAt     time.Time
+}
+
+// NewOrderLineAddedEvent creates a new OrderLineAddedEvent
+func NewOrderLineAddedEvent(orderID string, line OrderLine, currency string, orderTotal int64) *OrderLineAddedEvent {
+	return &OrderLineAddedEvent{
+		OrderID:     orderID,
+		SKU:         line.SKU,
+		Description: line.Description,
+		Quantity:    line.Quantity,
+		UnitPrice:   line.UnitPrice,
+		Subtotal:    line.Subtotal,
+		Currency:    currency,
+		OrderTotal:  orderTotal,
+		AddedAt:     time.Now(),
+	}
+}
+
+// OrderLineChangedEvent represents an event when a line of an order is changed
+type OrderLineChangedEvent struct {
+	OrderID     string
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+	Subtotal    int64
+	Currency    string
+	OrderTotal  int64
+	ChangedAt   time.Time
+}
+
+// NewOrderLineChangedEvent creates a new OrderLineChangedEvent
+func NewOrderLineChangedEvent(orderID string, line OrderLine, currency string, orderTotal int64) *OrderLineChangedEvent {
+	return &OrderLineChangedEvent{
+		OrderID:     orderID,
+		SKU:         line.SKU,
+		Description: line.Description,
+		Quantity:    line.Quantity,
+		UnitPrice:   line.UnitPrice,
+		Subtotal:    line.Subtotal,
+		Currency:    currency,
+		OrderTotal:  orderTotal,
+		ChangedAt:   time.Now(),
+	}
+}
+
+// OrderLineRemovedEvent represents an event when a line is removed from an order
+type OrderLineRemovedEvent struct {
+	OrderID    string
+	SKU        string
+	Currency   string
+	OrderTotal int64
+	RemovedAt  time.Time
+}
+
+// NewOrderLineRemovedEvent creates a new OrderLineRemovedEvent
+func NewOrderLineRemovedEvent(orderID, sku string, currency string, orderTotal int64) *OrderLineRemovedEvent {
+	return &OrderLineRemovedEvent{
+		OrderID:    orderID,
+		SKU:        sku,
+		Currency:   currency,
+		OrderTotal: orderTotal,
+		RemovedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"time"
+)
+
+// ProductCreatedEvent represents an event when a product is added to the catalog
+type ProductCreatedEvent struct {
+	ID        string
+	SKU       string
+	Name      string
+	Price     int64
+	Currency  string
+	Active    bool
+	CreatedAt time.Time
+}
+
+// NewProductCreatedEvent creates a new ProductCreatedEvent
+func NewProductCreatedEvent(id, sku, name string, price int64, currency string) *ProductCreatedEvent {
+	return &ProductCreatedEvent{
+		ID:        id,
+		SKU:       sku,
+		Name:      name,
+		Price:     price,
+		Currency:  currency,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ProductUpdatedEvent represents an event when a product is updated
+type ProductUpdatedEvent struct {
+	ID        string
+	Name      string
+	Price     int64
+	Currency  string
+	UpdatedAt time.Time
+}
+
+// NewProductUpdatedEvent creates a new ProductUpdatedEvent
+func NewProductUpdatedEvent(id, name string, price int64, currency string) *ProductUpdatedEvent {
+	return &ProductUpdatedEvent{
+		ID:        id,
+		Name:      name,
+		Price:     price,
+		Currency:  currency,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// ProductDeactivatedEvent represents an event when a product is withdrawn from the catalog
+type ProductDeactivatedEvent struct {
+	ID            string
+	Active        bool
+	DeactivatedAt time.Time
+}
+
+// NewProductDeactivatedEvent creates a new ProductDeactivatedEvent
+func NewProductDeactivatedEvent(id string) *ProductDeactivatedEvent {
+	return &ProductDeactivatedEvent{
+		ID:            id,
+		Active:        false,
+		DeactivatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// InboxStatus is the outcome of the handling of an inbound message
+type InboxStatus string
+
+const (
+	// InboxProcessed messages drove their command
+	InboxProcessed InboxStatus = "processed"
+	// InboxDeadLettered messages could not be handled and were set aside with the reason
+	InboxDeadLettered InboxStatus = "dead_lettered"
+)
+
+// DeadLetter is an inbound message a consumer could not handle, kept for inspection and replay
+type DeadLetter struct {
+	ID          int
+	Consumer    string
+	Topic       string
+	MessageID   string // empty when the message could not be read
+	MessageType string
+	Body        []byte
+	Reason      string
+	CreatedAt   time.Time
+}
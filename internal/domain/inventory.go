@@ -0,0 +1,49 @@
+package domain
+
+import (
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// StockLevel is the stock held for one SKU and how much of it is set aside for orders
+type StockLevel struct {
+	SKU      string
+	OnHand   int
+	Reserved int
+}
+
+// Available returns the stock that can still be reserved
+func (s StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// SetOnHand records a new physical stock count
+func (s *StockLevel) SetOnHand(onHand int) error {
+	if onHand < 0 {
+		return domainerrors.NewValidationError("stock on hand cannot be negative")
+	}
+
+	if onHand < s.Reserved {
+		return domainerrors.NewValidationError("stock on hand cannot be lower than the reserved quantity")
+	}
+
+	s.OnHand = onHand
+	return nil
+}
+
+// Reserve sets aside stock for an order
+func (s *StockLevel) Reserve(quantity int) error {
+	if quantity > s.Available() {
+		return domainerrors.NewInsufficientStockError(s.SKU, quantity, s.Available())
+	}
+
+	s.Reserved += quantity
+	return nil
+}
+
+// Release returns reserved stock to what is available
+func (s *StockLevel) Release(quantity int) {
+	s.Reserved -= quantity
+	if s.Reserved < 0 {
+		s.Reserved = 0
+	}
+}
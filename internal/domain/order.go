@@ -7,9 +7,14 @@ import (
 // Order represents an order in the domain
 type Order struct {
 	ID         int
-	CustomerID *int // Using pointer instead of sql.NullInt64 to represent optional value
-	Product    string
-	Quantity   int
+	CustomerID *int   // Using pointer instead of sql.NullInt64 to represent optional value
+	Product    string // SKU of the first line, kept for single-product clients
+	Quantity   int    // Total quantity over all lines, kept for single-product clients
+	Currency   string // Currency shared by the prices of all lines
+	Lines      []OrderLine
+	// ShippingAddress is a copy of the customer address taken when the order was placed,
+	// so later changes to the customer's addresses do not move the order
+	ShippingAddress *Address
 	// Could add other domain-related fields like:
 	// Status    OrderStatus
 	// CreatedAt time.Time
@@ -26,13 +31,24 @@ const (
 	OrderStatusCancelled OrderStatus = "CANCELLED"
 )
 
+// NewOrder creates a single-product order without a price
 func NewOrder(product string, quantity int) (*Order, error) {
-	order := &Order{
-		Product:  product,
-		Quantity: quantity,
+	if product == "" {
+		return nil, domainerrors.NewValidationError("product cannot be empty")
 	}
 
-	if err := order.Validate(); err != nil {
+	if quantity <= 0 {
+		return nil, domainerrors.NewValidationError("quantity must be greater than zero")
+	}
+
+	return NewOrderWithLines([]OrderLine{singleProductLine(product, quantity, DefaultCurrency)})
+}
+
+// NewOrderWithLines creates an order holding the given lines
+func NewOrderWithLines(lines []OrderLine) (*Order, error) {
+	order := &Order{}
+
+	if err := order.ReplaceLines(lines); err != nil {
 		return nil, err
 	}
 
@@ -40,28 +56,127 @@ func NewOrder(product string, quantity int) (*Order, error) {
 }
 
 func (o *Order) Validate() error {
-	if o.Product == "" {
+	return validateLines(o.Lines)
+}
+
+func (o *Order) AssignCustomer(customerID int) error {
+	if customerID <= 0 {
+		return domainerrors.NewValidationError("customer ID must be greater than zero")
+	}
+
+	o.CustomerID = &customerID
+	return nil
+}
+
+// ShipTo records a snapshot of the address the order is shipped to
+func (o *Order) ShipTo(address Address) error {
+	if address.Type != AddressTypeShipping {
+		return domainerrors.NewValidationError("orders can only be shipped to a shipping address")
+	}
+
+	if err := address.Validate(); err != nil {
+		return err
+	}
+
+	address.IsDefault = false
+	o.ShippingAddress = &address
+	return nil
+}
+
+// Update replaces all lines of the order with a single product without a price
+func (o *Order) Update(product string, quantity int) error {
+	if product == "" {
 		return domainerrors.NewValidationError("product cannot be empty")
 	}
 
-	if o.Quantity <= 0 {
+	if quantity <= 0 {
 		return domainerrors.NewValidationError("quantity must be greater than zero")
 	}
 
-	return nil
+	currency := o.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return o.ReplaceLines([]OrderLine{singleProductLine(product, quantity, currency)})
 }
 
-func (o *Order) AssignCustomer(customerID int) error {
-	if customerID <= 0 {
-		return domainerrors.NewValidationError("customer ID must be greater than zero")
+// Total returns the sum of the subtotals of all lines
+func (o *Order) Total() Money {
+	total := Money{Currency: o.Currency}
+	for _, line := range o.Lines {
+		// Validate guarantees the lines share a currency and the sum does not overflow
+		total, _ = total.Add(line.Subtotal())
 	}
+	return total
+}
 
-	o.CustomerID = &customerID
+// ReplaceLines replaces all lines of the order
+func (o *Order) ReplaceLines(lines []OrderLine) error {
+	if err := validateLines(lines); err != nil {
+		return err
+	}
+
+	o.Lines = append([]OrderLine(nil), lines...)
+	o.syncSummary()
 	return nil
 }
 
-func (o *Order) Update(product string, quantity int) error {
-	o.Product = product
-	o.Quantity = quantity
-	return o.Validate()
+// AddLine adds a new line to the order
+func (o *Order) AddLine(line OrderLine) error {
+	lines := append(append([]OrderLine(nil), o.Lines...), line)
+	return o.ReplaceLines(lines)
+}
+
+// ChangeLine replaces the line with the same SKU
+func (o *Order) ChangeLine(line OrderLine) error {
+	index := o.lineIndex(line.SKU)
+	if index < 0 {
+		return domainerrors.NewNotFoundError("order line", line.SKU)
+	}
+
+	lines := append([]OrderLine(nil), o.Lines...)
+	lines[index] = line
+	return o.ReplaceLines(lines)
+}
+
+// RemoveLine removes the line with the given SKU
+func (o *Order) RemoveLine(sku string) error {
+	index := o.lineIndex(sku)
+	if index < 0 {
+		return domainerrors.NewNotFoundError("order line", sku)
+	}
+
+	lines := append(append([]OrderLine(nil), o.Lines[:index]...), o.Lines[index+1:]...)
+	return o.ReplaceLines(lines)
+}
+
+// lineIndex returns the position of the line with the given SKU, or -1
+func (o *Order) lineIndex(sku string) int {
+	for i, line := range o.Lines {
+		if line.SKU == sku {
+			return i
+		}
+	}
+	return -1
+}
+
+// syncSummary keeps the single-product fields and the currency in step with the lines
+func (o *Order) syncSummary() {
+	o.Product = o.Lines[0].SKU
+	o.Currency = o.Lines[0].UnitPrice.Currency
+	o.Quantity = 0
+	for _, line := range o.Lines {
+		o.Quantity += line.Quantity
+	}
+}
+
+// singleProductLine builds the line used by single-product orders
+func singleProductLine(product string, quantity int, currency string) OrderLine {
+	return OrderLine{
+		SKU:         product,
+		Description: product,
+		Quantity:    quantity,
+		UnitPrice:   Money{Currency: currency},
+	}
 }
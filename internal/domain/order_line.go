@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"fmt"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// MaxOrderLines is the maximum number of lines a single order may hold
+const MaxOrderLines = 50
+
+// OrderLine represents a single product line on an order
+type OrderLine struct {
+	SKU         string
+	Description string
+	Quantity    int
+	UnitPrice   Money
+}
+
+func NewOrderLine(sku string, description string, quantity int, unitPrice Money) (OrderLine, error) {
+	line := OrderLine{
+		SKU:         sku,
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+	}
+
+	if err := line.Validate(); err != nil {
+		return OrderLine{}, err
+	}
+
+	return line, nil
+}
+
+func (l OrderLine) Validate() error {
+	if l.SKU == "" {
+		return domainerrors.NewValidationError("line SKU cannot be empty")
+	}
+
+	if l.Quantity <= 0 {
+		return domainerrors.NewValidationError("line quantity must be greater than zero")
+	}
+
+	if err := l.UnitPrice.Validate(); err != nil {
+		return err
+	}
+
+	if l.UnitPrice.Amount < 0 {
+		return domainerrors.NewValidationError("line unit price cannot be negative")
+	}
+
+	if _, err := l.UnitPrice.Multiply(l.Quantity); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Subtotal returns the unit price multiplied by the quantity
+func (l OrderLine) Subtotal() Money {
+	// Validate guarantees the multiplication does not overflow
+	subtotal, _ := l.UnitPrice.Multiply(l.Quantity)
+	return subtotal
+}
+
+// validateLines checks the order-level invariants that span several lines
+func validateLines(lines []OrderLine) error {
+	if len(lines) == 0 {
+		return domainerrors.NewValidationError("order must have at least one line")
+	}
+
+	if len(lines) > MaxOrderLines {
+		return domainerrors.NewValidationError(fmt.Sprintf("order cannot have more than %d lines", MaxOrderLines))
+	}
+
+	seen := make(map[string]struct{}, len(lines))
+	total := Money{Currency: lines[0].UnitPrice.Currency}
+	for _, line := range lines {
+		if err := line.Validate(); err != nil {
+			return err
+		}
+		if _, ok := seen[line.SKU]; ok {
+			return domainerrors.NewValidationError("duplicate SKU on order: " + line.SKU)
+		}
+		seen[line.SKU] = struct{}{}
+
+		if line.UnitPrice.Currency != total.Currency {
+			return domainerrors.NewValidationError(fmt.Sprintf(
+				"line %s is priced in %s but the order is in %s", line.SKU, line.UnitPrice.Currency, total.Currency))
+		}
+
+		var err error
+		if total, err = total.Add(line.Subtotal()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// PhoneNumberPattern matches a phone number in E.164 format: a plus sign and up to 15 digits
+const PhoneNumberPattern = `^\+[1-9][0-9]{1,14}$`
+
+var e164Pattern = regexp.MustCompile(PhoneNumberPattern)
+
+// NormalizePhoneNumber removes the spaces, dots, dashes and parentheses people use to
+// group digits and checks that what remains is an E.164 number such as +4930123456
+func NormalizePhoneNumber(phone string) (string, error) {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	if !e164Pattern.MatchString(normalized) {
+		return "", domainerrors.NewValidationError("phone number must be in E.164 format, e.g. +14155550123")
+	}
+
+	return normalized, nil
+}
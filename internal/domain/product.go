@@ -0,0 +1,68 @@
+package domain
+
+import (
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// Product represents an orderable item in the catalog
+type Product struct {
+	ID     int
+	SKU    string
+	Name   string
+	Active bool
+	Price  Money
+}
+
+func NewProduct(sku string, name string, price Money) (*Product, error) {
+	product := &Product{
+		SKU:    sku,
+		Name:   name,
+		Active: true,
+		Price:  price,
+	}
+
+	if err := product.Validate(); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (p *Product) Validate() error {
+	if p.SKU == "" {
+		return domainerrors.NewValidationError("product SKU cannot be empty")
+	}
+
+	if p.Name == "" {
+		return domainerrors.NewValidationError("product name cannot be empty")
+	}
+
+	if err := p.Price.Validate(); err != nil {
+		return err
+	}
+
+	if p.Price.Amount < 0 {
+		return domainerrors.NewValidationError("product price cannot be negative")
+	}
+
+	return nil
+}
+
+func (p *Product) Update(name string, price Money) error {
+	p.Name = name
+	p.Price = price
+	return p.Validate()
+}
+
+// Deactivate removes the product from what can be ordered
+func (p *Product) Deactivate() {
+	p.Active = false
+}
+
+// EnsureOrderable returns an error if the product cannot be put on an order
+func (p *Product) EnsureOrderable() error {
+	if !p.Active {
+		return domainerrors.NewValidationError("product " + p.SKU + " is not available")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+// minWebhookSecretLength keeps signing secrets from being guessable
+const minWebhookSecretLength = 16
+
+// WebhookDeliveryStatus is the state of the delivery of an event to a webhook
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their next attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered deliveries were accepted by the receiver
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead deliveries ran out of attempts and wait for a manual redelivery
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// Webhook is a partner's subscription to the domain events of its tenant, delivered to its URL
+// and signed with its secret
+type Webhook struct {
+	ID         int
+	TenantID   string
+	URL        string
+	EventTypes []string // empty for every event type
+	Secret     string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+func NewWebhook(targetURL string, eventTypes []string, secret string) (*Webhook, error) {
+	webhook := &Webhook{
+		URL:        targetURL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhook) Validate() error {
+	target, err := url.Parse(w.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return domainerrors.NewValidationError("webhook URL must be an absolute http or https URL")
+	}
+
+	for _, eventType := range w.EventTypes {
+		if eventType == "" {
+			return domainerrors.NewValidationError("webhook event types cannot be empty")
+		}
+	}
+
+	if len(w.Secret) < minWebhookSecretLength {
+		return domainerrors.NewValidationError("webhook secret must be at least 16 characters long")
+	}
+
+	return nil
+}
+
+// Wants reports whether the webhook subscribes to the event type
+func (w *Webhook) Wants(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, wanted := range w.EventTypes {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is the delivery of one stored event to one webhook
+type WebhookDelivery struct {
+	ID             int
+	WebhookID      int
+	TenantID       string // the tenant of the webhook
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookAttempt records one attempt at a delivery, for the delivery log
+type WebhookAttempt struct {
+	DeliveryID  int
+	Attempt     int
+	StatusCode  int // 0 when no response was received
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// Succeeded reports whether the receiver accepted the delivery
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// RecordAttempt applies the outcome of an attempt. A failed attempt schedules the next one after
+// the given backoff, or marks the delivery dead once it has had maxAttempts.
+func (d *WebhookDelivery) RecordAttempt(attempt WebhookAttempt, maxAttempts int, backoff time.Duration) {
+	d.Attempts++
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+
+	switch {
+	case attempt.Succeeded():
+		d.Status = WebhookDeliveryDelivered
+		deliveredAt := attempt.AttemptedAt
+		d.DeliveredAt = &deliveredAt
+	case d.Attempts >= maxAttempts:
+		d.Status = WebhookDeliveryDead
+	default:
+		d.Status = WebhookDeliveryPending
+		d.NextAttemptAt = attempt.AttemptedAt.Add(backoff)
+	}
+}
+
+// Redeliver queues the delivery again with a fresh set of attempts
+func (d *WebhookDelivery) Redeliver(now time.Time) {
+	d.Status = WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+}
@@ -2,84 +2,148 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
 )
 
-// Config holds all configuration for the application
+// Config holds all configuration for the application. Every setting can be given in the config
+// file under its section and key, in the environment variable of its env tag and as a command
+// line flag named after its section and key, such as -server.port. The settings tagged reload
+// can be changed without a restart, see Watcher.
 type Config struct {
-	// Server configuration
-	ServerHost string
-	ServerPort int
-	
-	// Database configuration
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
-	
 	// Application configuration
-	Environment string
-	LogLevel    string
+	Environment string `yaml:"environment" toml:"environment" env:"ENVIRONMENT"`
+
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Logging  LoggingConfig  `yaml:"logging" toml:"logging"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	GraphQL  GraphQLConfig  `yaml:"graphql" toml:"graphql"`
+	Broker   BrokerConfig   `yaml:"broker" toml:"broker"`
+	Inbound  InboundConfig  `yaml:"inbound" toml:"inbound"`
+	Imports  ImportsConfig  `yaml:"imports" toml:"imports"`
+}
+
+// ServerConfig configures the HTTP and gRPC servers
+type ServerConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"SERVER_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"SERVER_PORT"`
+	GRPCPort int    `yaml:"grpcPort" toml:"grpcPort" env:"GRPC_PORT"`
+
+	// CORSOrigins are the origins browsers may call the API from, * allowing any
+	CORSOrigins []string `yaml:"corsOrigins" toml:"corsOrigins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+}
+
+// DatabaseConfig configures the PostgreSQL connection
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslMode" toml:"sslMode" env:"DB_SSL_MODE"`
+
+	// RowLevelSecurity turns on the policies keeping the rows of tenants apart
+	RowLevelSecurity bool `yaml:"rowLevelSecurity" toml:"rowLevelSecurity" env:"TENANT_ROW_LEVEL_SECURITY"`
+}
+
+// LoggingConfig configures the application logger
+type LoggingConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" reload:"true"`
+}
+
+// AuthConfig configures how requests are identified. No tenants accepts any tenant.
+type AuthConfig struct {
+	TenantClaim       string   `yaml:"tenantClaim" toml:"tenantClaim" env:"TENANT_CLAIM"`
+	TenantBaseDomains []string `yaml:"tenantBaseDomains" toml:"tenantBaseDomains" env:"TENANT_BASE_DOMAINS"`
+	Tenants           []string `yaml:"tenants" toml:"tenants" env:"TENANTS"`
 }
 
-// Load loads configuration from environment variables
-func Load() (*Config, error) {
-	// Load .env file if it exists
-	_ = godotenv.Load() // Ignore error if .env doesn't exist
-	
-	config := &Config{
-		// Server configuration with defaults
-		ServerHost: getEnv("SERVER_HOST", "localhost"),
-		ServerPort: getEnvAsInt("SERVER_PORT", 8080),
-		
-		// Database configuration with defaults
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnvAsInt("DB_PORT", 5432),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "postgres"),
-		DBName:     getEnv("DB_NAME", "go_cqrs"),
-		DBSSLMode:  getEnv("DB_SSL_MODE", "disable"),
-		
-		// Application configuration with defaults
-		Environment: getEnv("ENVIRONMENT", "development"),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+// GraphQLConfig configures the GraphQL endpoint
+type GraphQLConfig struct {
+	MaxDepth      int  `yaml:"maxDepth" toml:"maxDepth" env:"GRAPHQL_MAX_DEPTH" reload:"true"`
+	MaxComplexity int  `yaml:"maxComplexity" toml:"maxComplexity" env:"GRAPHQL_MAX_COMPLEXITY" reload:"true"`
+	Mutations     bool `yaml:"mutations" toml:"mutations" env:"GRAPHQL_MUTATIONS"`
+}
+
+// BrokerConfig configures the message broker the stored events are published to. No broker type
+// publishes nothing.
+type BrokerConfig struct {
+	Type         string `yaml:"type" toml:"type" env:"BROKER_TYPE"`
+	URL          string `yaml:"url" toml:"url" env:"BROKER_URL" secret:"true"`
+	DefaultTopic string `yaml:"defaultTopic" toml:"defaultTopic" env:"BROKER_DEFAULT_TOPIC"`
+	Topics       string `yaml:"topics" toml:"topics" env:"BROKER_TOPICS"`
+}
+
+// InboundConfig configures the broker the checkout messages are consumed from. No broker type
+// consumes nothing; the URL of the file broker is its spool directory.
+type InboundConfig struct {
+	Type          string `yaml:"type" toml:"type" env:"INBOUND_BROKER_TYPE"`
+	URL           string `yaml:"url" toml:"url" env:"INBOUND_BROKER_URL" secret:"true"`
+	CheckoutTopic string `yaml:"checkoutTopic" toml:"checkoutTopic" env:"CHECKOUT_TOPIC"`
+}
+
+// ImportsConfig configures the workers running the background imports and how many imports
+// wait for one before new ones are turned away
+type ImportsConfig struct {
+	Workers   int `yaml:"workers" toml:"workers" env:"IMPORT_WORKERS"`
+	QueueSize int `yaml:"queueSize" toml:"queueSize" env:"IMPORT_QUEUE_SIZE"`
+}
+
+// Default returns the configuration used for the settings no source sets
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Host:        "localhost",
+			Port:        8080,
+			GRPCPort:    9090,
+			CORSOrigins: []string{"*"},
+		},
+		Database: DatabaseConfig{
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "go_cqrs",
+			SSLMode:  "disable",
+		},
+		Logging: LoggingConfig{
+			Level: "info",
+		},
+		Auth: AuthConfig{
+			TenantClaim: "tenant_id",
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 1000,
+			Mutations:     true,
+		},
+		Broker: BrokerConfig{
+			DefaultTopic: "domain-events",
+		},
+		Inbound: InboundConfig{
+			CheckoutTopic: "checkout",
+		},
+		Imports: ImportsConfig{
+			Workers:   2,
+			QueueSize: 16,
+		},
 	}
-	
-	return config, nil
 }
 
 // DatabaseURL returns the formatted database connection string
 func (c *Config) DatabaseURL() string {
 	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName, c.DBSSLMode,
+		c.Database.Host, c.Database.Port, c.Database.User, c.Database.Password, c.Database.Name, c.Database.SSLMode,
 	)
 }
 
 // ServerAddress returns the formatted server address
 func (c *Config) ServerAddress() string {
-	return fmt.Sprintf("%s:%d", c.ServerHost, c.ServerPort)
-}
-
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
-// getEnvAsInt gets an environment variable as an integer or returns a default value
-func getEnvAsInt(key string, defaultValue int) int {
-	if valueStr, exists := os.LookupEnv(key); exists {
-		if value, err := strconv.Atoi(valueStr); err == nil {
-			return value
-		}
-	}
-	return defaultValue
+// GRPCAddress returns the formatted address of the gRPC server
+func (c *Config) GRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.GRPCPort)
 }
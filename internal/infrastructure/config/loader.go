@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file to read when no -config flag is given
+const FileEnv = "CONFIG_FILE"
+
+// redacted replaces the values of secret settings in printed configurations
+const redacted = "REDACTED"
+
+// Loader reads the configuration from its sources, in increasing order of precedence: the
+// defaults, the YAML or TOML config file, the environment, including a .env file, and the
+// command line flags. A secret can be read from the file named by its variable suffixed with
+// _FILE instead, such as DB_PASSWORD_FILE.
+type Loader struct {
+	file  string
+	flags map[string]string // the values of the flags given, by setting key
+}
+
+// NewLoader creates a loader reading the config file and the settings from flags it registers on the flag set.
+// The flags must be parsed before Load is called.
+func NewLoader(flags *flag.FlagSet) *Loader {
+	l := &Loader{flags: make(map[string]string)}
+	flags.StringVar(&l.file, "config", "", "YAML or TOML config file, $"+FileEnv+" when not given")
+	for _, s := range settings(Default()) {
+		key := s.key
+		usage := "sets " + key
+		if s.env != "" {
+			usage += ", overriding $" + s.env
+		}
+		flags.Func(key, usage, func(value string) error {
+			l.flags[key] = value
+			return nil
+		})
+	}
+	return l
+}
+
+// Parse creates a loader taking args as its command line flags
+func Parse(args []string) (*Loader, error) {
+	flags := flag.NewFlagSet("go-cqrs", flag.ContinueOnError)
+	loader := NewLoader(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// Load reads the configuration with args as its command line flags
+func Load(args []string) (*Config, error) {
+	loader, err := Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return loader.Load()
+}
+
+// File returns the path of the config file read, or an empty string when there is none
+func (l *Loader) File() string {
+	if l.file != "" {
+		return l.file
+	}
+	return os.Getenv(FileEnv)
+}
+
+// Load reads the configuration and validates it, reporting every problem found at once
+func (l *Loader) Load() (*Config, error) {
+	// Load .env file if it exists
+	_ = godotenv.Load() // Ignore error if .env doesn't exist
+
+	cfg := Default()
+	var problems Errors
+
+	if file := l.File(); file != "" {
+		if err := readFile(file, cfg); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	for _, s := range settings(cfg) {
+		if err := s.fromEnv(); err != nil {
+			problems = append(problems, err)
+		}
+		if value, ok := l.flags[s.key]; ok {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Errorf("flag -%s: %w", s.key, err))
+			}
+		}
+	}
+
+	problems = append(problems, cfg.problems()...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return cfg, nil
+}
+
+// readFile decodes a config file over cfg, rejecting keys that are not settings
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil // An empty file sets nothing
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration with the values of its secret settings replaced
+func (c *Config) Redacted() *Config {
+	clone := *c
+	for _, s := range settings(&clone) {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(redacted)
+		}
+	}
+	return &clone
+}
+
+// setting is a single value of a configuration together with the names it is read under
+type setting struct {
+	key        string // section.key in the config file, also the name of its flag
+	env        string
+	secret     bool
+	reloadable bool
+	value      reflect.Value
+}
+
+// settings lists the settings of cfg in the order they are declared
+func settings(cfg *Config) []setting {
+	var result []setting
+
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("yaml")
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			result = append(result, setting{
+				key:        key,
+				env:        field.Tag.Get("env"),
+				secret:     field.Tag.Get("secret") == "true",
+				reloadable: field.Tag.Get("reload") == "true",
+				value:      v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+
+	return result
+}
+
+// fromEnv sets the setting from its environment variable, or from the file its _FILE variable names
+func (s setting) fromEnv() error {
+	if s.env == "" {
+		return nil
+	}
+
+	value, ok := os.LookupEnv(s.env)
+	if path, fromFile := os.LookupEnv(s.env + "_FILE"); fromFile {
+		if ok {
+			return fmt.Errorf("%s and %s_FILE are both set", s.env, s.env)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", s.env, err)
+		}
+		value, ok = strings.TrimRight(string(data), "\r\n"), true
+	}
+	if !ok {
+		return nil
+	}
+
+	if err := s.set(value); err != nil {
+		return fmt.Errorf("%s: %w", s.env, err)
+	}
+	return nil
+}
+
+// set parses a value given as text into the setting. Lists are comma separated.
+func (s setting) set(value string) error {
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		s.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		s.value.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
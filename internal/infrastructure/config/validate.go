@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Errors lists every problem found in a configuration
+type Errors []error
+
+// Error lists the problems one per line
+func (e Errors) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, err := range e {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the problems, for errors.Is and errors.As
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Validate checks the configuration, returning Errors with every problem found
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// problems lists what is wrong with the configuration, naming settings by their section and key
+func (c *Config) problems() Errors {
+	var problems Errors
+	fail := func(key, format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(key+": "+format, args...))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return
+			}
+		}
+		fail(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+	port := func(key string, value int) {
+		if value < 1 || value > 65535 {
+			fail(key, "%d is not a port between 1 and 65535", value)
+		}
+	}
+	required := func(key, value string) {
+		if strings.TrimSpace(value) == "" {
+			fail(key, "is required")
+		}
+	}
+
+	required("environment", c.Environment)
+
+	port("server.port", c.Server.Port)
+	port("server.grpcPort", c.Server.GRPCPort)
+	if c.Server.Port == c.Server.GRPCPort {
+		fail("server.grpcPort", "must differ from server.port")
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "" || u.Path != "") {
+			fail("server.corsOrigins", "%q is not * or an origin such as https://example.com", origin)
+		}
+	}
+
+	required("database.host", c.Database.Host)
+	port("database.port", c.Database.Port)
+	required("database.user", c.Database.User)
+	required("database.name", c.Database.Name)
+	oneOf("database.sslMode", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+
+	if c.GraphQL.MaxDepth < 1 {
+		fail("graphql.maxDepth", "must be at least 1")
+	}
+	if c.GraphQL.MaxComplexity < 1 {
+		fail("graphql.maxComplexity", "must be at least 1")
+	}
+
+	oneOf("broker.type", c.Broker.Type, "", "nats", "kafka", "memory")
+	if c.Broker.Type == "nats" || c.Broker.Type == "kafka" {
+		required("broker.url", c.Broker.URL)
+	}
+	if c.Broker.Type != "" {
+		required("broker.defaultTopic", c.Broker.DefaultTopic)
+	}
+	for _, pair := range strings.Split(c.Broker.Topics, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		if eventType, topic, ok := strings.Cut(pair, "="); !ok || strings.TrimSpace(eventType) == "" || strings.TrimSpace(topic) == "" {
+			fail("broker.topics", "%q is not an event type=topic pair", pair)
+		}
+	}
+
+	oneOf("inbound.type", c.Inbound.Type, "", "nats", "file", "memory")
+	if c.Inbound.Type == "nats" || c.Inbound.Type == "file" {
+		required("inbound.url", c.Inbound.URL)
+	}
+	if c.Inbound.Type != "" {
+		required("inbound.checkoutTopic", c.Inbound.CheckoutTopic)
+	}
+
+	if c.Imports.Workers < 1 {
+		fail("imports.workers", "must be at least 1")
+	}
+	if c.Imports.QueueSize < 0 {
+		fail("imports.queueSize", "must not be negative")
+	}
+
+	return problems
+}
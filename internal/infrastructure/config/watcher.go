@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go-cqrs/internal/infrastructure/logger"
+)
+
+// Change is a setting whose value differs between two configurations
+type Change struct {
+	Key string
+	// Old and New are the values as text, secret values being redacted
+	Old, New string
+	// Reloadable tells whether the change can be applied without a restart
+	Reloadable bool
+}
+
+// Diff lists the settings whose values differ from old to new, in the order they are declared
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	before, after := settings(old), settings(new)
+	for i, s := range before {
+		if reflect.DeepEqual(s.value.Interface(), after[i].value.Interface()) {
+			continue
+		}
+		change := Change{Key: s.key, Old: fmt.Sprint(s.value.Interface()), New: fmt.Sprint(after[i].value.Interface()), Reloadable: s.reloadable}
+		if s.secret {
+			change.Old, change.New = redacted, redacted
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Watcher reloads the configuration when the process receives SIGHUP or the config file
+// changes. A reloaded configuration is only applied when it is valid and every setting it
+// changes is reloadable; otherwise the running configuration is kept and the reload logged.
+type Watcher struct {
+	loader   *Loader
+	log      logger.Logger
+	interval time.Duration // how often the config file is checked for changes
+	current  atomic.Pointer[Config]
+
+	mu       sync.Mutex // serializes reloads
+	appliers []func(cfg *Config)
+}
+
+// NewWatcher creates a watcher reloading the configuration with the loader it was loaded with
+func NewWatcher(loader *Loader, cfg *Config, log logger.Logger, interval time.Duration) *Watcher {
+	w := &Watcher{loader: loader, log: log, interval: interval}
+	w.current.Store(cfg)
+	return w
+}
+
+// OnReload registers a function applying the reloadable settings of a reloaded configuration.
+// It must be called before Run.
+func (w *Watcher) OnReload(apply func(cfg *Config)) {
+	w.appliers = append(w.appliers, apply)
+}
+
+// Current returns the configuration last applied
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Reload loads the configuration again and applies it, returning why it was rejected if it was
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cfg, err := w.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	changes := Diff(w.Current(), cfg)
+	var rejected Errors
+	for _, change := range changes {
+		if !change.Reloadable {
+			rejected = append(rejected, fmt.Errorf("%s: cannot be changed without a restart", change.Key))
+		}
+	}
+	if len(rejected) > 0 {
+		return rejected
+	}
+	if len(changes) == 0 {
+		w.log.Info("Configuration reloaded without changes")
+		return nil
+	}
+
+	w.current.Store(cfg)
+	for _, apply := range w.appliers {
+		apply(cfg)
+	}
+	for _, change := range changes {
+		w.log.Info("Configuration changed",
+			logger.String("setting", change.Key), logger.String("old", change.Old), logger.String("new", change.New))
+	}
+	return nil
+}
+
+// Run reloads the configuration on SIGHUP, and when the modification time of the config file
+// changes, until the context is done
+func (w *Watcher) Run(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// The file is polled rather than watched, which also notices it being replaced
+	var poll <-chan time.Time
+	file := w.loader.File()
+	modified := modTime(file)
+	if file != "" {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			w.reload("signal")
+		case <-poll:
+			if m := modTime(file); !m.Equal(modified) {
+				modified = m
+				w.reload("file change")
+			}
+		}
+	}
+}
+
+// reload reloads the configuration, logging the reloads that were rejected
+func (w *Watcher) reload(trigger string) {
+	if err := w.Reload(); err != nil {
+		w.log.Error("Configuration reload rejected", logger.String("trigger", trigger), logger.Error(err))
+	}
+}
+
+// modTime returns the modification time of a file, the zero time when it cannot be read
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -1,17 +1,33 @@
 package container
 
 import (
+	"fmt"
+	"go-cqrs/internal/adapters/batch"
+	"go-cqrs/internal/adapters/cqrs/bus"
 	"go-cqrs/internal/adapters/cqrs/commands"
 	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/exports"
+	"go-cqrs/internal/adapters/gql"
+	grpcserver "go-cqrs/internal/adapters/grpc/server"
 	"go-cqrs/internal/adapters/http/controllers"
+	"go-cqrs/internal/adapters/http/middleware"
 	"go-cqrs/internal/adapters/http/router"
+	"go-cqrs/internal/adapters/imports"
+	"go-cqrs/internal/adapters/integration"
+	"go-cqrs/internal/adapters/webhooks"
 	"go-cqrs/internal/application/ports"
 	"go-cqrs/internal/application/services"
+	"go-cqrs/internal/domain/events"
 	"go-cqrs/internal/infrastructure/config"
 	"go-cqrs/internal/infrastructure/database"
 	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/messaging"
 	event_store "go-cqrs/internal/infrastructure/messaging/events"
 	"go-cqrs/internal/infrastructure/repositories"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
 )
 
 // Container holds all application dependencies
@@ -21,43 +37,95 @@ type Container struct {
 	Logger logger.Logger
 
 	// Repositories
-	OrderRepository    ports.OrderRepository
-	CustomerRepository ports.CustomerRepository
+	OrderRepository     ports.OrderRepository
+	CustomerRepository  ports.CustomerRepository
+	ProductRepository   ports.ProductRepository
+	InventoryRepository ports.InventoryRepository
+	WebhookRepository   ports.WebhookRepository
+	InboxRepository     ports.InboxRepository
 
 	// Use Cases
-	OrderUseCase    ports.OrderUseCase
-	CustomerUseCase ports.CustomerUseCase
+	OrderUseCase     ports.OrderUseCase
+	CustomerUseCase  ports.CustomerUseCase
+	ProductUseCase   ports.ProductUseCase
+	InventoryUseCase ports.InventoryUseCase
+	WebhookUseCase   ports.WebhookUseCase
 
 	// Event Stores
-	OrderEventStore    event_store.EventStore
-	CustomerEventStore event_store.EventStore
+	OrderEventStore     event_store.EventStore
+	CustomerEventStore  event_store.EventStore
+	ProductEventStore   event_store.EventStore
+	InventoryEventStore event_store.EventStore
+
+	// Event Dispatcher
+	EventDispatcher *event_store.Dispatcher
+
+	// EventFeed pushes newly stored events to stream subscribers
+	EventFeed *event_store.Feed
+
+	// WebhookWorker delivers stored events to the webhooks subscribed to them
+	WebhookWorker *webhooks.Worker
+
+	// EventRelay publishes stored events to the message broker; nil when no broker is configured
+	Publisher  messaging.Publisher
+	EventRelay *messaging.Relay
+
+	// CheckoutConsumer drives order commands from the checkout topic of the inbound subscriber;
+	// the subscriber is nil when no inbound broker is configured
+	InboundSubscriber messaging.Subscriber
+	CheckoutConsumer  *integration.Consumer
 
 	// Command Handlers
-	OrderCommandHandler    *commands.OrderCommandHandler
-	CustomerCommandHandler *commands.CustomerCommandHandler
+	OrderCommandHandler     *commands.OrderCommandHandler
+	CustomerCommandHandler  *commands.CustomerCommandHandler
+	ProductCommandHandler   *commands.ProductCommandHandler
+	InventoryCommandHandler *commands.InventoryCommandHandler
+
+	// Command and Query Buses
+	CommandBus *bus.CommandBus
+	QueryBus   *bus.QueryBus
+	QueryCache *bus.QueryCache
 
 	// Query Handlers
-	OrderQueryHandler    *queries.OrderQueryHandler
-	CustomerQueryHandler *queries.CustomerQueryHandler
+	OrderQueryHandler         *queries.OrderQueryHandler
+	CustomerQueryHandler      *queries.CustomerQueryHandler
+	CustomerOrderQueryHandler *queries.CustomerOrderQueryHandler
+	ProductQueryHandler       *queries.ProductQueryHandler
+	InventoryQueryHandler     *queries.InventoryQueryHandler
+
+	// Bulk Import, Export and Batch Services
+	ImportJobs    *imports.JobStore
+	ImportRunner  *imports.JobRunner
+	Exporter      *exports.Exporter
+	BatchExecutor *batch.Executor
+
+	// GraphQL executor over the customer and order queries and commands
+	GraphQLExecutor *gql.Executor
 
 	// Controllers
-	OrderController    controllers.OrderController
-	CustomerController controllers.CustomerController
+	OrderController     controllers.OrderController
+	CustomerController  controllers.CustomerController
+	ProductController   controllers.ProductController
+	InventoryController controllers.InventoryController
+	ImportController    controllers.ImportController
+	ExportController    controllers.ExportController
+	BatchController     controllers.BatchController
+	GraphQLController   controllers.GraphQLController
+	EventController     controllers.EventStreamController
+	WebhookController   controllers.WebhookController
 
-	// Router
+	// Router, and the origins browsers may call it from
 	Router router.Router
-}
+	Cors   *middleware.CorsPolicy
 
-// NewContainer creates a new dependency injection container
-func NewContainer() (*Container, error) {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		return nil, err
-	}
+	// GRPCServer serves the customer and order services over gRPC
+	GRPCServer *grpc.Server
+}
 
+// NewContainer creates a new dependency injection container from a loaded configuration
+func NewContainer(cfg *config.Config) (*Container, error) {
 	// Initialize logger
-	log := logger.NewZapLogger(logger.LogLevel(cfg.LogLevel), cfg.Environment == "production")
+	log := logger.NewZapLogger(logger.LogLevel(cfg.Logging.Level), cfg.Environment == "production")
 	log.Info("Initializing application container",
 		logger.String("environment", cfg.Environment),
 		logger.String("server_address", cfg.ServerAddress()))
@@ -75,29 +143,133 @@ func NewContainer() (*Container, error) {
 		return nil, err
 	}
 	c.DB = db
-	log.Info("Connected to database", logger.String("host", cfg.DBHost), logger.String("database", cfg.DBName))
+	log.Info("Connected to database", logger.String("host", cfg.Database.Host), logger.String("database", cfg.Database.Name))
 
 	// Initialize tables
 	if err = c.DB.SetupDatabaseTables(); err != nil {
 		return nil, err
 	}
+	if err = c.DB.SetupRowLevelSecurity(cfg.Database.RowLevelSecurity); err != nil {
+		return nil, err
+	}
 
 	// Initialize repositories
 	c.OrderRepository = repositories.NewOrderRepository(c.DB.DB)
 	c.CustomerRepository = repositories.NewCustomerRepository(c.DB.DB)
+	c.ProductRepository = repositories.NewProductRepository(c.DB.DB)
+	c.InventoryRepository = repositories.NewInventoryRepository(c.DB.DB)
+	c.WebhookRepository = repositories.NewWebhookRepository(c.DB.DB)
+	c.InboxRepository = repositories.NewInboxRepository(c.DB.DB)
 
 	// Initialize use cases
+	c.InventoryUseCase = services.NewInventoryService(
+		c.InventoryRepository,
+		c.DB,
+	)
 	c.OrderUseCase = services.NewOrderService(
 		c.OrderRepository,
 		c.CustomerRepository,
+		c.ProductRepository,
+		c.InventoryUseCase,
+		c.DB,
 	)
 	c.CustomerUseCase = services.NewCustomerService(
 		c.CustomerRepository,
+		c.OrderRepository,
+		c.DB,
+	)
+	c.ProductUseCase = services.NewProductService(
+		c.ProductRepository,
 	)
+	c.WebhookUseCase = services.NewWebhookService(
+		c.WebhookRepository,
+	)
+
+	// Initialize event dispatcher and its subscribers. Stock is reserved by the order service,
+	// in the transaction that changes the order, rather than by an order event subscriber.
+	c.EventDispatcher = event_store.NewDispatcher(c.Logger)
+
+	// Cached query results are dropped when the events changing them are committed. Products belong
+	// to no tenant, so their results are cached once for every tenant.
+	c.QueryCache = bus.NewQueryCache(10000, 5*time.Minute)
+	c.QueryCache.ShareAcrossTenants(queries.SharedQueries...)
+	cacheInvalidator := c.QueryCache.InvalidateOn(queries.StaleQueries)
+
+	// The event feed polls the events table and is woken early when events are committed
+	c.EventFeed = event_store.NewFeed(event_store.NewPostgresEventLog(c.DB.DB), c.Logger, time.Second, 256)
+	feedNotifier := c.EventFeed.NotifyOnCommit()
+	for _, eventType := range []string{
+		events.CustomerCreatedEventType,
+		events.CustomerUpdatedEventType,
+		events.CustomerDeletedEventType,
+		events.CustomerAddressAddedEventType,
+		events.CustomerAddressUpdatedEventType,
+		events.CustomerAddressRemovedEventType,
+		events.CustomerMergedEventType,
+		events.OrderCreatedEventType,
+		events.OrderUpdatedEventType,
+		events.OrderDeletedEventType,
+		events.CustomerAssignedToOrderEventType,
+		events.OrderLineAddedEventType,
+		events.OrderLineChangedEventType,
+		events.OrderLineRemovedEventType,
+		events.ProductCreatedEventType,
+		events.ProductUpdatedEventType,
+		events.ProductDeactivatedEventType,
+	} {
+		c.EventDispatcher.Subscribe(eventType, cacheInvalidator)
+		c.EventDispatcher.Subscribe(eventType, feedNotifier)
+	}
+
+	// The webhook worker tails the events table from its saved position. Failed deliveries are
+	// retried for about four hours before they are dead-lettered.
+	c.WebhookWorker = webhooks.NewWorker(
+		c.WebhookRepository,
+		event_store.NewPostgresEventLog(c.DB.DB),
+		event_store.NewPostgresCursorStore(c.DB.DB),
+		c.DB,
+		webhooks.NewSender(10*time.Second),
+		webhooks.RetryPolicy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 6 * time.Hour},
+		c.Logger,
+		time.Second,
+		time.Minute,
+	)
+
+	// The event relay tails the events table from its saved position and publishes to the broker
+	if cfg.Broker.Type != "" {
+		topics, err := messaging.ParseTopicMap(cfg.Broker.DefaultTopic, cfg.Broker.Topics)
+		if err != nil {
+			return nil, err
+		}
+		if c.Publisher, err = newPublisher(cfg); err != nil {
+			log.Error("Failed to connect to the message broker", logger.Error(err))
+			return nil, err
+		}
+		c.EventRelay = messaging.NewRelay(
+			event_store.NewPostgresEventLog(c.DB.DB),
+			event_store.NewPostgresCursorStore(c.DB.DB),
+			c.Publisher,
+			topics,
+			c.Logger,
+			time.Second,
+		)
+		log.Info("Publishing events to the message broker", logger.String("broker", cfg.Broker.Type))
+	}
 
 	// Initialize event stores
-	c.OrderEventStore = event_store.NewPostgresEventStore(c.DB.DB, "order", c.Logger)
-	c.CustomerEventStore = event_store.NewPostgresEventStore(c.DB.DB, "customer", c.Logger)
+	c.OrderEventStore = event_store.NewDispatchingEventStore(
+		event_store.NewPostgresEventStore(c.DB.DB, "order", c.Logger),
+		c.EventDispatcher,
+	)
+	c.CustomerEventStore = event_store.NewDispatchingEventStore(
+		event_store.NewPostgresEventStore(c.DB.DB, "customer", c.Logger),
+		c.EventDispatcher,
+	)
+	c.ProductEventStore = event_store.NewDispatchingEventStore(
+		event_store.NewPostgresEventStore(c.DB.DB, "product", c.Logger),
+		c.EventDispatcher,
+	)
+	c.InventoryEventStore = event_store.NewPostgresEventStore(c.DB.DB, "inventory", c.Logger)
 
 	// Initialize command handlers
 	c.OrderCommandHandler = commands.NewOrderCommandHandler(
@@ -106,38 +278,214 @@ func NewContainer() (*Container, error) {
 	)
 	c.CustomerCommandHandler = commands.NewCustomerCommandHandler(
 		c.CustomerEventStore,
+		c.OrderEventStore,
 		c.CustomerUseCase,
 	)
+	c.ProductCommandHandler = commands.NewProductCommandHandler(
+		c.ProductEventStore,
+		c.ProductUseCase,
+	)
+	c.InventoryCommandHandler = commands.NewInventoryCommandHandler(
+		c.InventoryEventStore,
+		c.InventoryUseCase,
+	)
+
+	// Initialize command bus. Retries wrap the transaction so every attempt starts afresh.
+	c.CommandBus = bus.NewCommandBus()
+	c.CommandBus.Use(
+		bus.LoggingMiddleware(c.Logger),
+		bus.MetricsMiddleware(),
+		bus.RetryMiddleware(3, 50*time.Millisecond, database.IsTransient),
+		bus.TransactionMiddleware(c.DB),
+	)
+	c.OrderCommandHandler.Register(c.CommandBus)
+	c.CustomerCommandHandler.Register(c.CommandBus)
+	c.ProductCommandHandler.Register(c.CommandBus)
+	c.InventoryCommandHandler.Register(c.CommandBus)
 
 	// Initialize query handlers
 	c.OrderQueryHandler = queries.NewOrderQueryHandler(
 		c.OrderRepository,
+		c.OrderEventStore,
 	)
 	c.CustomerQueryHandler = queries.NewCustomerQueryHandler(
 		c.CustomerRepository,
+		c.CustomerEventStore,
 	)
+	c.CustomerOrderQueryHandler = queries.NewCustomerOrderQueryHandler(
+		c.CustomerRepository,
+		c.OrderRepository,
+	)
+	c.ProductQueryHandler = queries.NewProductQueryHandler(
+		c.ProductRepository,
+	)
+	c.InventoryQueryHandler = queries.NewInventoryQueryHandler(
+		c.InventoryUseCase,
+	)
+
+	// Initialize query bus
+	c.QueryBus = bus.NewQueryBus()
+	c.QueryBus.Use(bus.CachingMiddleware(c.QueryCache, queries.CachedQueries...))
+	if cfg.Database.RowLevelSecurity {
+		// Queries only see the rows of their tenant within transactions
+		c.QueryBus.Use(bus.SnapshotMiddleware(c.DB))
+	}
+	c.OrderQueryHandler.Register(c.QueryBus)
+	c.CustomerQueryHandler.Register(c.QueryBus)
+	c.CustomerOrderQueryHandler.Register(c.QueryBus)
+	c.ProductQueryHandler.Register(c.QueryBus)
+	c.InventoryQueryHandler.Register(c.QueryBus)
+
+	// Initialize the consumer of checkout messages
+	if cfg.Inbound.Type != "" {
+		if c.InboundSubscriber, err = newSubscriber(cfg); err != nil {
+			log.Error("Failed to connect to the inbound message broker", logger.Error(err))
+			return nil, err
+		}
+		c.CheckoutConsumer = integration.NewConsumer("checkout", c.CommandBus, c.InboxRepository, c.DB, c.Logger, 5)
+		integration.MapCheckoutMessages(c.CheckoutConsumer)
+	}
+
+	// Initialize bulk import, export and batch services
+	c.ImportJobs = imports.NewJobStore()
+	c.ImportRunner = imports.NewJobRunner(c.ImportJobs, c.Config.Imports.Workers, c.Config.Imports.QueueSize)
+	c.Exporter = exports.NewExporter(
+		c.CustomerRepository,
+		c.OrderRepository,
+		c.DB,
+	)
+	c.BatchExecutor = batch.NewExecutor(c.DB)
+	batch.RegisterCommands(c.BatchExecutor, c.CommandBus)
+
+	// Initialize GraphQL executor
+	schema, err := gql.NewSchema(c.CommandBus, c.QueryBus, cfg.GraphQL.Mutations)
+	if err != nil {
+		log.Error("Failed to build GraphQL schema", logger.Error(err))
+		return nil, err
+	}
+	c.GraphQLExecutor = gql.NewExecutor(schema, c.QueryBus, gql.Limits{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+	})
 
 	// Initialize controllers
 	c.OrderController = *controllers.NewOrderController(
-		c.OrderCommandHandler,
-		c.OrderQueryHandler,
+		c.CommandBus,
+		c.QueryBus,
 	)
 	c.CustomerController = *controllers.NewCustomerController(
-		c.CustomerCommandHandler,
-		c.CustomerQueryHandler,
+		c.CommandBus,
+		c.QueryBus,
+	)
+	c.ProductController = *controllers.NewProductController(
+		c.CommandBus,
+		c.QueryBus,
+	)
+	c.InventoryController = *controllers.NewInventoryController(
+		c.CommandBus,
+		c.QueryBus,
+	)
+	c.ImportController = *controllers.NewImportController(
+		c.CommandBus,
+		c.DB,
+		c.ImportJobs,
+		c.ImportRunner,
+	)
+	c.ExportController = *controllers.NewExportController(
+		c.Exporter,
+	)
+	c.BatchController = *controllers.NewBatchController(
+		c.BatchExecutor,
+	)
+	c.GraphQLController = *controllers.NewGraphQLController(
+		c.GraphQLExecutor,
+	)
+	c.EventController = *controllers.NewEventStreamController(
+		c.EventFeed,
+	)
+	c.WebhookController = *controllers.NewWebhookController(
+		c.WebhookUseCase,
 	)
 
+	// Requests are scoped to the tenant named by their header, bearer token or subdomain
+	tenants := middleware.NewTenantResolver(cfg.Auth.TenantClaim, cfg.Auth.TenantBaseDomains, cfg.Auth.Tenants)
+
 	// Initialize router
+	c.Cors = middleware.NewCorsPolicy(cfg.Server.CORSOrigins)
 	c.Router = router.NewRouter(
 		c.CustomerController,
 		c.OrderController,
+		c.ProductController,
+		c.InventoryController,
+		c.ImportController,
+		c.ExportController,
+		c.BatchController,
+		c.GraphQLController,
+		c.EventController,
+		c.WebhookController,
+		tenants,
+		c.Cors,
 	)
 
+	// Initialize gRPC server
+	c.GRPCServer = grpcserver.NewServer(c.CommandBus, c.QueryBus, c.Logger, tenants)
+
 	return c, nil
 }
 
+// ApplyConfig applies the reloadable settings of a reloaded configuration: the log level, the
+// CORS origins and the GraphQL limits. Config keeps the configuration the container was created with.
+func (c *Container) ApplyConfig(cfg *config.Config) {
+	if setter, ok := c.Logger.(logger.LevelSetter); ok {
+		setter.SetLevel(logger.LogLevel(cfg.Logging.Level))
+	}
+	c.Cors.SetOrigins(cfg.Server.CORSOrigins)
+	c.GraphQLExecutor.SetLimits(gql.Limits{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+	})
+}
+
+// newPublisher creates the publisher of the configured broker type
+func newPublisher(cfg *config.Config) (messaging.Publisher, error) {
+	switch cfg.Broker.Type {
+	case "nats":
+		return messaging.NewNATSPublisher(cfg.Broker.URL)
+	case "kafka":
+		return messaging.NewKafkaPublisher(cfg.Broker.URL, 10*time.Second), nil
+	case "memory":
+		return messaging.NewMemoryPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown broker type %q, expected nats, kafka or memory", cfg.Broker.Type)
+	}
+}
+
+// newSubscriber creates the subscriber of the configured inbound broker type
+func newSubscriber(cfg *config.Config) (messaging.Subscriber, error) {
+	switch cfg.Inbound.Type {
+	case "nats":
+		return messaging.NewNATSSubscriber(cfg.Inbound.URL, "go-cqrs-checkout")
+	case "file":
+		return messaging.NewFileSubscriber(cfg.Inbound.URL, time.Second), nil
+	case "memory":
+		return messaging.NewMemoryBroker(time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown inbound broker type %q, expected nats, file or memory", cfg.Inbound.Type)
+	}
+}
+
 // Close closes all resources
 func (c *Container) Close() {
+	if subscriber, ok := c.InboundSubscriber.(io.Closer); ok {
+		if err := subscriber.Close(); err != nil {
+			c.Logger.Error("error closing inbound message broker subscriber", logger.Error(err))
+		}
+	}
+	if c.Publisher != nil {
+		if err := c.Publisher.Close(); err != nil {
+			c.Logger.Error("error closing message broker publisher", logger.Error(err))
+		}
+	}
 	if c.DB != nil {
 		if err := c.DB.Close(); err != nil {
 			c.Logger.Error("error closing database", logger.Error(err))
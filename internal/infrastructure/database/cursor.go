@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// cursorCount numbers cursors so that several can be open in one transaction
+var cursorCount atomic.Int64
+
+// StreamQuery reads the result of a query through a server-side cursor, batchSize rows at a time,
+// so large results are neither held in memory nor paged with LIMIT/OFFSET. scan is called for every
+// row and flush after every batch, once the rows of the batch are closed, with a context through
+// which flush can run further queries in the same transaction.
+// The cursor lives in the transaction carried by ctx, or in a new one.
+func StreamQuery(ctx context.Context, db *sql.DB, batchSize int, query string, args []interface{}, scan func(rows *sql.Rows) error, flush func(ctx context.Context) error) error {
+	return RunInTransaction(ctx, db, func(ctx context.Context) error {
+		conn := Conn(ctx, db)
+		cursor := fmt.Sprintf("stream_cursor_%d", cursorCount.Add(1))
+
+		if _, err := conn.ExecContext(ctx, "DECLARE "+cursor+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+			return fmt.Errorf("failed to declare cursor: %w", err)
+		}
+
+		for {
+			count, err := fetchCursor(ctx, conn, cursor, batchSize, scan)
+			if err != nil {
+				return err
+			}
+
+			if count > 0 {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+			}
+			if count < batchSize {
+				break
+			}
+		}
+
+		if _, err := conn.ExecContext(ctx, "CLOSE "+cursor); err != nil {
+			return fmt.Errorf("failed to close cursor: %w", err)
+		}
+		return nil
+	})
+}
+
+// fetchCursor scans the next batch of rows of a cursor and returns how many there were
+func fetchCursor(ctx context.Context, conn Executor, cursor string, batchSize int, scan func(rows *sql.Rows) error) (int, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", batchSize, cursor))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch from cursor: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
@@ -1,10 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Database represents a database connection
@@ -14,11 +15,12 @@ type Database struct {
 
 // NewDatabase creates a new database connection
 func NewDatabase(connString string) (*Database, error) {
-	// Open database connection
-	db, err := sql.Open("postgres", connString)
+	// Open database connection, scoping its connections to tenants for row level security
+	connector, err := pq.NewConnector(connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
+	db := sql.OpenDB(tenantConnector{connector})
 
 	// Verify connection is working
 	if err = db.Ping(); err != nil {
@@ -32,6 +34,18 @@ func NewDatabase(connString string) (*Database, error) {
 	return &Database{db}, nil
 }
 
+// CustomerEmailIndex is the unique index on the normalized email of the active customers of a tenant
+const CustomerEmailIndex = "customers_tenant_email_active_idx"
+
+// CustomerMergedIntoKey is the foreign key from the merged customers to the customer they were merged into
+const CustomerMergedIntoKey = "customers_merged_into_fkey"
+
+// tenantTables are the tables holding a tenant_id column, guarded by the row level security policies.
+// The products of the catalog are shared by every tenant, and so are the positions of the event log
+// consumers, which read the events of every tenant; each tenant keeps its own stock of the products.
+var tenantTables = []string{"customers", "orders", "events", "webhooks", "webhook_deliveries",
+	"stock_levels", "stock_reservations", "inbox_messages", "dead_letters"}
+
 // SetupDatabaseTables creates database tables if they don't exist
 func (db *Database) SetupDatabaseTables() error {
 	// Create customers table
@@ -39,7 +53,7 @@ func (db *Database) SetupDatabaseTables() error {
 		CREATE TABLE IF NOT EXISTS customers (
 			id SERIAL PRIMARY KEY,
 			name TEXT NOT NULL,
-			email TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -48,6 +62,82 @@ func (db *Database) SetupDatabaseTables() error {
 		return fmt.Errorf("failed to create customers table: %w", err)
 	}
 
+	// Normalize stored emails and make the normalized form unique. Rows that would collide with
+	// another customer are left alone, so creating the index fails until they have been merged.
+	_, err = db.Exec(`
+		UPDATE customers c SET email = lower(btrim(c.email))
+		WHERE c.email <> lower(btrim(c.email))
+			AND NOT EXISTS (
+				SELECT 1 FROM customers d
+				WHERE d.id <> c.id AND lower(btrim(d.email)) = lower(btrim(c.email))
+			)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to normalize customer emails: %w", err)
+	}
+
+	// Add the tombstone left behind when a duplicate customer is merged into another
+	_, err = db.Exec(`
+		ALTER TABLE customers
+			ADD COLUMN IF NOT EXISTS merged_into INTEGER CONSTRAINT ` + CustomerMergedIntoKey + ` REFERENCES customers(id),
+			ADD COLUMN IF NOT EXISTS merged_at TIMESTAMP
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add customer merge columns: %w", err)
+	}
+
+	// Add the tenant owning each customer; customers stored before tenants belong to the default tenant
+	_, err = db.Exec(`ALTER TABLE customers ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default'`)
+	if err != nil {
+		return fmt.Errorf("failed to add tenant column to customers table: %w", err)
+	}
+
+	// Merged customers keep their email for the record, so only active customers must be unique,
+	// and only within their tenant. The partial index replaces the plain unique constraint and the
+	// earlier indexes across all tenants.
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS ` + CustomerEmailIndex + ` ON customers (tenant_id, lower(email)) WHERE merged_into IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to create customer email index, customers with the same email in different case must be merged first: %w", err)
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+		DROP INDEX IF EXISTS customers_email_normalized_idx;
+		DROP INDEX IF EXISTS customers_email_active_idx
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to drop superseded customer email constraints: %w", err)
+	}
+
+	// Add contact details to the customers table
+	_, err = db.Exec(`ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone TEXT`)
+	if err != nil {
+		return fmt.Errorf("failed to add customer contact columns: %w", err)
+	}
+
+	// Create customer addresses table; each customer has at most one default address per type
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS customer_addresses (
+			id SERIAL PRIMARY KEY,
+			customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+			type TEXT NOT NULL CHECK (type IN ('billing', 'shipping')),
+			line1 TEXT NOT NULL,
+			line2 TEXT NOT NULL DEFAULT '',
+			city TEXT NOT NULL,
+			region TEXT NOT NULL DEFAULT '',
+			postal_code TEXT NOT NULL DEFAULT '',
+			country CHAR(2) NOT NULL,
+			is_default BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS customer_addresses_default_idx
+			ON customer_addresses (customer_id, type) WHERE is_default
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create customer addresses table: %w", err)
+	}
+
 	// Create orders table
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS orders (
@@ -63,6 +153,97 @@ func (db *Database) SetupDatabaseTables() error {
 		return fmt.Errorf("failed to create orders table: %w", err)
 	}
 
+	// Index the orders of a customer, newest first, for the customer orders and summary queries
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS orders_customer_idx ON orders (customer_id, created_at DESC, id DESC)`)
+	if err != nil {
+		return fmt.Errorf("failed to create orders customer index: %w", err)
+	}
+
+	// Add the shipping address snapshot to the orders table
+	_, err = db.Exec(`ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB`)
+	if err != nil {
+		return fmt.Errorf("failed to add order shipping address column: %w", err)
+	}
+
+	// Add pricing columns to the orders table
+	_, err = db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD',
+			ADD COLUMN IF NOT EXISTS total_amount BIGINT NOT NULL DEFAULT 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add pricing columns to orders table: %w", err)
+	}
+
+	// Add the tenant owning each order, indexed for the newest first listing of a tenant's orders
+	_, err = db.Exec(`
+		ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		CREATE INDEX IF NOT EXISTS orders_tenant_idx ON orders (tenant_id, created_at DESC, id DESC)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add tenant column to orders table: %w", err)
+	}
+
+	// Create order lines table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS order_lines (
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			line_no INTEGER NOT NULL,
+			sku TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			quantity INTEGER NOT NULL,
+			unit_price BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (order_id, sku)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create order lines table: %w", err)
+	}
+
+	// Create products table for the catalog
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS products (
+			id SERIAL PRIMARY KEY,
+			sku TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			price BIGINT NOT NULL DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'USD',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create products table: %w", err)
+	}
+
+	// Create inventory tables. Reservations are released explicitly before an order is
+	// deleted, so they deliberately have no foreign key that could cascade past the stock levels.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_levels (
+			sku TEXT PRIMARY KEY,
+			on_hand INTEGER NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+			reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create stock levels table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_reservations (
+			order_id INTEGER NOT NULL,
+			sku TEXT NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (order_id, sku)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create stock reservations table: %w", err)
+	}
+
 	// Create events table for event sourcing
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS events (
@@ -77,6 +258,195 @@ func (db *Database) SetupDatabaseTables() error {
 		return fmt.Errorf("failed to create events table: %w", err)
 	}
 
+	// Add audit columns to the events table and backfill them for older rows
+	_, err = db.Exec(`
+		ALTER TABLE events
+			ADD COLUMN IF NOT EXISTS aggregate_type TEXT,
+			ADD COLUMN IF NOT EXISTS aggregate_id TEXT,
+			ADD COLUMN IF NOT EXISTS actor TEXT,
+			ADD COLUMN IF NOT EXISTS request_id TEXT
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add audit columns to events table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		UPDATE events
+		SET aggregate_type = split_part(event_type, '.', 1),
+			aggregate_id = COALESCE(event_data->>'ID', event_data->>'OrderID')
+		WHERE aggregate_id IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill event aggregate IDs: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS events_aggregate_idx ON events (aggregate_type, aggregate_id, id)`)
+	if err != nil {
+		return fmt.Errorf("failed to create events aggregate index: %w", err)
+	}
+
+	// Add the tenant whose request stored each event
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default'`)
+	if err != nil {
+		return fmt.Errorf("failed to add tenant column to events table: %w", err)
+	}
+
+	// Create the positions of the consumers reading the events table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_cursors (
+			name TEXT PRIMARY KEY,
+			position BIGINT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create event cursors table: %w", err)
+	}
+
+	// Create webhook tables
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id SERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT[] NOT NULL DEFAULT '{}',
+			secret TEXT NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create webhooks table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event_id BIGINT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_status_code INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			UNIQUE (webhook_id, event_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook deliveries table: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook deliveries due index: %w", err)
+	}
+
+	// Add the tenant owning each webhook; its deliveries carry it too, for their queries to be
+	// scoped without a join
+	_, err = db.Exec(`
+		ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		CREATE INDEX IF NOT EXISTS webhooks_tenant_idx ON webhooks (tenant_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add tenant columns to webhook tables: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_attempts (
+			id SERIAL PRIMARY KEY,
+			delivery_id INTEGER NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+			attempt INTEGER NOT NULL,
+			status_code INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			duration_ms BIGINT NOT NULL,
+			attempted_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook attempts table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS inbox_messages (
+			consumer TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			message_type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (consumer, message_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create inbox messages table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS dead_letters (
+			id SERIAL PRIMARY KEY,
+			consumer TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			message_id TEXT NOT NULL DEFAULT '',
+			message_type TEXT NOT NULL DEFAULT '',
+			body BYTEA NOT NULL,
+			reason TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letters table: %w", err)
+	}
+
+	// Add the tenant owning the stock of a SKU, and the tenants of the inbound messages, whose IDs
+	// are unique per tenant. The unique indexes replace the primary keys across all tenants.
+	_, err = db.Exec(`
+		ALTER TABLE stock_levels ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		CREATE UNIQUE INDEX IF NOT EXISTS stock_levels_tenant_sku_idx ON stock_levels (tenant_id, sku);
+		ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_pkey;
+		ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		ALTER TABLE inbox_messages ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+		CREATE UNIQUE INDEX IF NOT EXISTS inbox_messages_tenant_idx ON inbox_messages (consumer, tenant_id, message_id);
+		ALTER TABLE inbox_messages DROP CONSTRAINT IF EXISTS inbox_messages_pkey;
+		ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add tenant columns to inventory and inbox tables: %w", err)
+	}
+
+	return nil
+}
+
+// SetupRowLevelSecurity turns the row level security policies of the tenant tables on or off.
+// The policies admit the rows of the tenant set in app.tenant_id, which connections set from the
+// tenant of the context of every statement while the policies are on, and no rows when it is not
+// set. Contexts marked with requestctx.WithAllTenants, those of the background workers, set
+// app.all_tenants instead, which admits every row. The policies only apply to roles that are not
+// superusers and cannot bypass row level security.
+func (db *Database) SetupRowLevelSecurity(enabled bool) error {
+	for _, table := range tenantTables {
+		var err error
+		if enabled {
+			_, err = db.Exec(`
+				DROP POLICY IF EXISTS tenant_isolation ON ` + table + `;
+				CREATE POLICY tenant_isolation ON ` + table + `
+					USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+					WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+				ALTER TABLE ` + table + ` ENABLE ROW LEVEL SECURITY;
+				ALTER TABLE ` + table + ` FORCE ROW LEVEL SECURITY
+			`)
+		} else {
+			_, err = db.Exec(`ALTER TABLE ` + table + ` DISABLE ROW LEVEL SECURITY`)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set up row level security on %s table: %w", table, err)
+		}
+	}
+
+	scopeToTenant.Store(enabled)
 	return nil
 }
 
@@ -102,6 +472,16 @@ func (db *Database) WithTransaction(fn func(*sql.Tx) error) error {
 	return err
 }
 
+// WithinTransaction executes fn within a transaction carried by the context passed to fn
+func (db *Database) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTransaction(ctx, db.DB, fn)
+}
+
+// WithinSnapshot executes fn within a read-only transaction seeing a single snapshot of the database
+func (db *Database) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInSnapshot(ctx, db.DB, fn)
+}
+
 // Close closes the database connection
 func (db *Database) Close() error {
 	return db.DB.Close()
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+// scopeToTenant makes connections set app.tenant_id to the tenant of the context of every
+// statement, for the row level security policies to see; see SetupRowLevelSecurity
+var scopeToTenant atomic.Bool
+
+// tenantConnector creates connections scoped to the tenant of the context they are used with
+type tenantConnector struct {
+	driver.Connector
+}
+
+// Connect opens a connection scoped to no tenant yet
+func (c tenantConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantConn{Conn: conn}, nil
+}
+
+// tenantConn sets the session settings read by the row level security policies before every
+// statement and transaction run outside of a transaction, when the tenant of their context
+// differs from the one the connection was last scoped to. A connection scoped to no tenant sees
+// no rows of the tenant tables, unless its context is allowed to read every tenant.
+type tenantConn struct {
+	driver.Conn
+	scope string // the tenant the connection is scoped to, * for every tenant, empty for none
+	inTx  bool
+}
+
+// scopeTo sets the session settings of the connection for the tenant of ctx
+func (c *tenantConn) scopeTo(ctx context.Context) error {
+	if !scopeToTenant.Load() || c.inTx {
+		return nil
+	}
+
+	scope, tenant, all := "", "", "off"
+	if requestctx.HasTenant(ctx) {
+		tenant = requestctx.Tenant(ctx)
+		scope = tenant
+	} else if requestctx.AllTenants(ctx) {
+		scope, all = "*", "on"
+	}
+	if scope == c.scope {
+		return nil
+	}
+
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return driver.ErrSkip
+	}
+	_, err := execer.ExecContext(ctx,
+		"SELECT set_config('app.tenant_id', $1, false), set_config('app.all_tenants', $2, false)",
+		[]driver.NamedValue{{Ordinal: 1, Value: tenant}, {Ordinal: 2, Value: all}})
+	if err != nil {
+		return err
+	}
+	c.scope = scope
+	return nil
+}
+
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.scopeTo(ctx); err != nil {
+		return nil, err
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *tenantConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.scopeTo(ctx); err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *tenantConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.scopeTo(ctx); err != nil {
+		return nil, err
+	}
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx scopes the connection before the transaction starts, so that rolling it back keeps the scope
+func (c *tenantConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.scopeTo(ctx); err != nil {
+		return nil, err
+	}
+
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &tenantTx{Tx: tx, conn: c}, nil
+}
+
+func (c *tenantConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tenantConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tenantConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// tenantTx lets its connection scope statements again once it ends
+type tenantTx struct {
+	driver.Tx
+	conn *tenantConn
+}
+
+func (t *tenantTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t *tenantTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type contextKey string
+
+const txKey contextKey = "transaction"
+
+// Executor is the subset of *sql.DB and *sql.Tx used by repositories
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// transaction is the transaction carried in a context together with its nesting depth
+type transaction struct {
+	tx          *sql.Tx
+	depth       int
+	afterCommit *[]func() // shared by the transaction and all its savepoints
+}
+
+// Conn returns the transaction carried by ctx, or db when ctx carries none
+func Conn(ctx context.Context, db *sql.DB) Executor {
+	if t, ok := ctx.Value(txKey).(*transaction); ok {
+		return t.tx
+	}
+	return db
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed, or right away when ctx carries none.
+// Nothing runs when the transaction rolls back.
+func AfterCommit(ctx context.Context, fn func()) {
+	t, ok := ctx.Value(txKey).(*transaction)
+	if !ok {
+		fn()
+		return
+	}
+	*t.afterCommit = append(*t.afterCommit, fn)
+}
+
+// RunInTransaction executes fn within a transaction whose handle travels in the context passed to fn.
+// When ctx already carries a transaction, fn runs inside a savepoint of it instead, so a failing
+// nested call only rolls back its own work.
+func RunInTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(txKey).(*transaction); ok {
+		return runInSavepoint(ctx, outer, fn)
+	}
+
+	return runInNewTransaction(ctx, db, nil, fn)
+}
+
+// RunInSnapshot executes fn within a read-only repeatable read transaction, so every query made
+// through the context passed to fn sees the database as it was when the first one ran.
+// When ctx already carries a transaction, fn runs inside it instead.
+func RunInSnapshot(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey).(*transaction); ok {
+		return fn(ctx)
+	}
+
+	return runInNewTransaction(ctx, db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+// runInNewTransaction executes fn within a new transaction, committing it when fn succeeds
+func runInNewTransaction(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	t := &transaction{tx: tx, afterCommit: &[]func(){}}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p) // Re-throw panic after rollback
+		} else if err != nil {
+			tx.Rollback() // err is not nil; rollback
+		} else if err = tx.Commit(); err == nil {
+			for _, callback := range *t.afterCommit {
+				callback()
+			}
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txKey, t))
+	return err
+}
+
+// runInSavepoint executes fn within a savepoint of an already running transaction
+func runInSavepoint(ctx context.Context, outer *transaction, fn func(ctx context.Context) error) (err error) {
+	inner := &transaction{tx: outer.tx, depth: outer.depth + 1, afterCommit: outer.afterCommit}
+	savepoint := fmt.Sprintf("sp_%d", inner.depth)
+
+	if _, err = outer.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			outer.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		} else if err != nil {
+			outer.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+		} else if _, releaseErr := outer.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); releaseErr != nil {
+			err = fmt.Errorf("failed to release savepoint: %w", releaseErr)
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txKey, inner))
+	return err
+}
+
+// IsTransient reports whether err is a serialization failure or a deadlock,
+// after which running the whole transaction again may succeed
+func IsTransient(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
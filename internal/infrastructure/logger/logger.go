@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -23,6 +24,11 @@ const (
 	ErrorLevel LogLevel = "error"
 )
 
+// LevelSetter is implemented by loggers whose level can be changed while they are in use
+type LevelSetter interface {
+	SetLevel(level LogLevel)
+}
+
 // Logger defines the interface for logging
 type Logger interface {
 	Debug(msg string, fields ...Field)
@@ -52,6 +58,11 @@ func Error(err error) Field {
 	return zap.Error(err)
 }
 
+// Duration creates a duration field
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
 // Bool creates a bool field
 func Bool(key string, value bool) Field {
 	return zap.Bool(key, value)
@@ -62,27 +73,16 @@ var (
 	defaultLoggerOnce sync.Once
 )
 
-// ZapLogger is a Zap implementation of the Logger interface
+// ZapLogger is a Zap implementation of the Logger interface. The loggers derived from it with
+// With share its level.
 type ZapLogger struct {
 	logger *zap.Logger
+	level  zap.AtomicLevel
 }
 
 // NewZapLogger creates a new Zap logger
 func NewZapLogger(level LogLevel, isProduction bool) Logger {
-	// Parse the log level
-	var zapLevel zapcore.Level
-	switch strings.ToLower(string(level)) {
-	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
-	case "warn":
-		zapLevel = zapcore.WarnLevel
-	case "error":
-		zapLevel = zapcore.ErrorLevel
-	default:
-		zapLevel = zapcore.InfoLevel
-	}
+	zapLevel := zap.NewAtomicLevelAt(parseLevel(level))
 
 	// Set up the encoder config
 	encoderConfig := zap.NewProductionEncoderConfig()
@@ -106,7 +106,28 @@ func NewZapLogger(level LogLevel, isProduction bool) Logger {
 	core := zapcore.NewCore(encoder, output, zapLevel)
 	zapLogger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.ErrorLevel))
 
-	return &ZapLogger{logger: zapLogger}
+	return &ZapLogger{logger: zapLogger, level: zapLevel}
+}
+
+// parseLevel returns the Zap level of a log level, info for unknown levels
+func parseLevel(level LogLevel) zapcore.Level {
+	switch strings.ToLower(string(level)) {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// SetLevel changes the level of the logger and of every logger derived from it
+func (l *ZapLogger) SetLevel(level LogLevel) {
+	l.level.SetLevel(parseLevel(level))
 }
 
 // Debug logs a debug message
@@ -136,7 +157,7 @@ func (l *ZapLogger) Fatal(msg string, fields ...Field) {
 
 // With returns a logger with attached fields
 func (l *ZapLogger) With(fields ...Field) Logger {
-	return &ZapLogger{logger: l.logger.With(fields...)}
+	return &ZapLogger{logger: l.logger.With(fields...), level: l.level}
 }
 
 // Sync flushes the log buffer
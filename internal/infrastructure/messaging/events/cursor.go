@@ -0,0 +1,131 @@
+package event_store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-cqrs/internal/infrastructure/database"
+)
+
+// EventCursor tracks how far a consumer has read the event log. IDs are taken when events are
+// inserted, so a transaction committing after a later one leaves a gap for a while; the cursor keeps
+// such gaps open, and reads them again, until every transaction that could fill them has ended.
+type EventCursor struct {
+	position int64
+	// gaps maps the missing IDs below the position to the first transaction started after they
+	// were seen, 0 until the log has been asked
+	gaps map[int64]uint64
+}
+
+// NewEventCursor creates a cursor positioned after the given event ID
+func NewEventCursor(position int64) *EventCursor {
+	return &EventCursor{position: position, gaps: make(map[int64]uint64)}
+}
+
+// Position returns the ID of the last event read
+func (c *EventCursor) Position() int64 {
+	return c.position
+}
+
+// Checkpoint returns the position to save: every event up to it has been consumed. Events after it
+// may have been consumed too, and are read again by a cursor resuming from the checkpoint.
+func (c *EventCursor) Checkpoint() int64 {
+	checkpoint := c.position
+	for id := range c.gaps {
+		if id <= checkpoint {
+			checkpoint = id - 1
+		}
+	}
+	return checkpoint
+}
+
+// Next reads the events to consume next: those of the open gaps committed since the last call,
+// then up to limit events stored after the position. more reports whether the limit was reached.
+func (c *EventCursor) Next(ctx context.Context, log EventLog, limit int) (ready []StoredEvent, more bool, err error) {
+	if len(c.gaps) > 0 {
+		// The horizon is taken after the read that found the gaps, so the transactions that took
+		// their IDs were running by then, and have ended once the oldest one running is past it
+		horizon, err := log.Horizon(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		ids := make([]int64, 0, len(c.gaps))
+		for id, next := range c.gaps {
+			if next == 0 {
+				c.gaps[id] = horizon.Next
+			}
+			ids = append(ids, id)
+		}
+
+		late, err := log.ReadEventsByID(ctx, ids)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, event := range late {
+			delete(c.gaps, event.ID)
+		}
+		ready = append(ready, late...)
+
+		// The gaps still open were rolled back
+		for id, next := range c.gaps {
+			if horizon.Oldest >= next {
+				delete(c.gaps, id)
+			}
+		}
+	}
+
+	stored, err := log.ReadEvents(ctx, c.position, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, event := range stored {
+		for id := c.position + 1; id < event.ID; id++ {
+			c.gaps[id] = 0
+		}
+		c.position = event.ID
+	}
+	return append(ready, stored...), len(stored) == limit, nil
+}
+
+// CursorStore persists the positions of event log consumers by name
+type CursorStore interface {
+	// LoadPosition returns the saved position of a consumer, and false when it has none yet
+	LoadPosition(ctx context.Context, name string) (int64, bool, error)
+	SavePosition(ctx context.Context, name string, position int64) error
+}
+
+// PostgresCursorStore keeps consumer positions in the event_cursors table
+type PostgresCursorStore struct {
+	db *sql.DB
+}
+
+// NewPostgresCursorStore creates a new PostgreSQL-based cursor store
+func NewPostgresCursorStore(db *sql.DB) CursorStore {
+	return &PostgresCursorStore{db: db}
+}
+
+// LoadPosition retrieves the saved position of a consumer
+func (s *PostgresCursorStore) LoadPosition(ctx context.Context, name string) (int64, bool, error) {
+	var position int64
+	err := database.Conn(ctx, s.db).QueryRowContext(ctx,
+		`SELECT position FROM event_cursors WHERE name = $1`, name).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load event cursor: %w", err)
+	}
+	return position, true, nil
+}
+
+// SavePosition stores the position of a consumer, as part of the caller's transaction if any
+func (s *PostgresCursorStore) SavePosition(ctx context.Context, name string, position int64) error {
+	_, err := database.Conn(ctx, s.db).ExecContext(ctx,
+		`INSERT INTO event_cursors (name, position, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		 ON CONFLICT (name) DO UPDATE SET position = EXCLUDED.position, updated_at = EXCLUDED.updated_at`,
+		name, position)
+	if err != nil {
+		return fmt.Errorf("failed to save event cursor: %w", err)
+	}
+	return nil
+}
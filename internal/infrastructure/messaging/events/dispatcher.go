@@ -0,0 +1,84 @@
+package event_store
+
+import (
+	"context"
+	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/logger"
+	"sync"
+)
+
+// EventHandler reacts to domain events after they have been stored.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event events.Event) error
+}
+
+// EventHandlerFunc adapts a function to the EventHandler interface.
+type EventHandlerFunc func(ctx context.Context, event events.Event) error
+
+// HandleEvent calls f(ctx, event).
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, event events.Event) error {
+	return f(ctx, event)
+}
+
+// Dispatcher delivers events to the handlers subscribed to their type.
+type Dispatcher struct {
+	logger   logger.Logger
+	handlers map[string][]EventHandler
+	mu       sync.RWMutex
+}
+
+// NewDispatcher creates a new event dispatcher.
+func NewDispatcher(logger logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		logger:   logger,
+		handlers: make(map[string][]EventHandler),
+	}
+}
+
+// Subscribe registers a handler for an event type.
+func (d *Dispatcher) Subscribe(eventType string, handler EventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// Dispatch delivers an event to its handlers in the order they subscribed.
+// Handler errors are logged and do not stop the remaining handlers.
+func (d *Dispatcher) Dispatch(ctx context.Context, event events.Event) {
+	d.mu.RLock()
+	handlers := d.handlers[event.EventType()]
+	d.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler.HandleEvent(ctx, event); err != nil {
+			d.logger.Error("Event handler failed",
+				logger.String("event_type", event.EventType()),
+				logger.String("aggregate_id", event.AggregateID()),
+				logger.Error(err))
+		}
+	}
+}
+
+// DispatchingEventStore is an EventStore that dispatches every event it stores.
+type DispatchingEventStore struct {
+	EventStore
+	dispatcher *Dispatcher
+}
+
+// NewDispatchingEventStore wraps an event store so that stored events reach the dispatcher.
+func NewDispatchingEventStore(store EventStore, dispatcher *Dispatcher) EventStore {
+	return &DispatchingEventStore{
+		EventStore: store,
+		dispatcher: dispatcher,
+	}
+}
+
+// StoreEvent stores an event and then dispatches it.
+func (s *DispatchingEventStore) StoreEvent(ctx context.Context, event events.Event) error {
+	if err := s.EventStore.StoreEvent(ctx, event); err != nil {
+		return err
+	}
+
+	s.dispatcher.Dispatch(ctx, event)
+	return nil
+}
@@ -2,38 +2,76 @@ package event_store
 
 import (
 	"context"
+	"encoding/json"
 	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/requestctx"
 	"sync"
+	"time"
 )
 
 // EventStore is an interface for storing and retrieving events.
 type EventStore interface {
 	StoreEvent(ctx context.Context, event events.Event) error
 	GetEvents(ctx context.Context, eventType string) ([]events.Event, error)
+	GetAggregateEvents(ctx context.Context, aggregateID string) ([]StoredEvent, error)
+}
+
+// StoredEvent is an event together with the metadata recorded when it was stored.
+type StoredEvent struct {
+	ID            int64
+	EventType     string
+	AggregateType string
+	AggregateID   string
+	TenantID      string
+	Actor         string
+	RequestID     string
+	OccurredAt    time.Time
+	Data          json.RawMessage
 }
 
 // InMemoryEventStore is an in-memory implementation of the EventStore interface.
 type InMemoryEventStore struct {
 	storeType string
 	events    []events.Event
+	records   []StoredEvent
 	mu        sync.RWMutex
 }
 
 // StoreEvent stores an event in the event store.
 func (s *InMemoryEventStore) StoreEvent(ctx context.Context, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.events = append(s.events, event)
+	s.records = append(s.records, StoredEvent{
+		ID:            int64(len(s.records) + 1),
+		EventType:     event.EventType(),
+		AggregateType: s.storeType,
+		AggregateID:   event.AggregateID(),
+		TenantID:      requestctx.Tenant(ctx),
+		Actor:         requestctx.Actor(ctx),
+		RequestID:     requestctx.RequestID(ctx),
+		OccurredAt:    event.OccurredAt(),
+		Data:          data,
+	})
 	return nil
 }
 
-// GetEvents returns events filtered by type.
+// GetEvents returns the events of the tenant of ctx filtered by type.
 func (s *InMemoryEventStore) GetEvents(ctx context.Context, eventType string) ([]events.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	tenant := requestctx.Tenant(ctx)
 	var filteredEvents []events.Event
-	for _, event := range s.events {
+	for i, event := range s.events {
+		if s.records[i].TenantID != tenant {
+			continue
+		}
 		if eventType == "" || event.EventType() == eventType {
 			filteredEvents = append(filteredEvents, event)
 		}
@@ -42,6 +80,22 @@ func (s *InMemoryEventStore) GetEvents(ctx context.Context, eventType string) ([
 	return filteredEvents, nil
 }
 
+// GetAggregateEvents returns the events recorded for an aggregate of the tenant of ctx in the order they were stored.
+func (s *InMemoryEventStore) GetAggregateEvents(ctx context.Context, aggregateID string) ([]StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenant := requestctx.Tenant(ctx)
+	var records []StoredEvent
+	for _, record := range s.records {
+		if record.AggregateID == aggregateID && record.TenantID == tenant {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
 // NewInMemoryEventStore creates a new in-memory event store.
 func NewInMemoryEventStore(eventType string) EventStore {
 	return &InMemoryEventStore{
@@ -0,0 +1,254 @@
+package event_store
+
+import (
+	"context"
+	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/logger"
+	"sync"
+	"time"
+)
+
+// feedBatchSize is the number of events read from the log at a time
+const feedBatchSize = 500
+
+// EventLog reads the stored events of every type in the order they were stored
+type EventLog interface {
+	// ReadEvents returns up to limit events stored after the given event ID
+	ReadEvents(ctx context.Context, afterID int64, limit int) ([]StoredEvent, error)
+	// ReadEventsByID returns the stored events among the given IDs, oldest first
+	ReadEventsByID(ctx context.Context, ids []int64) ([]StoredEvent, error)
+	// Horizon returns which transactions may still be storing events
+	Horizon(ctx context.Context) (TransactionHorizon, error)
+	// LatestEventID returns the ID of the last stored event, 0 when there is none
+	LatestEventID(ctx context.Context) (int64, error)
+}
+
+// TransactionHorizon tells which transactions had ended when it was taken. Oldest is the oldest
+// transaction still running, or Next when none was; every transaction before it had ended. Next is
+// the first transaction not started yet.
+type TransactionHorizon struct {
+	Oldest uint64
+	Next   uint64
+}
+
+// EventFilter selects the events of a subscription. Empty fields match every event.
+type EventFilter struct {
+	TenantID      string
+	EventTypes    map[string]bool
+	AggregateType string
+	AggregateID   string
+}
+
+// Matches reports whether the event passes the filter
+func (f EventFilter) Matches(event StoredEvent) bool {
+	if f.TenantID != "" && event.TenantID != f.TenantID {
+		return false
+	}
+	if len(f.EventTypes) > 0 && !f.EventTypes[event.EventType] {
+		return false
+	}
+	if f.AggregateType != "" && event.AggregateType != f.AggregateType {
+		return false
+	}
+	return f.AggregateID == "" || event.AggregateID == f.AggregateID
+}
+
+// Subscription receives the events of a feed that pass its filter
+type Subscription struct {
+	filter EventFilter
+	events chan StoredEvent
+}
+
+// Events returns the channel of the subscription. It is closed when the subscriber fell more
+// than its buffer behind, or when the feed stops; subscribers resume from the last event they got.
+func (s *Subscription) Events() <-chan StoredEvent {
+	return s.events
+}
+
+// Feed tails the event log and broadcasts newly stored events to its subscribers. It polls the log,
+// and is woken early by Notify, so that it also sees the events stored by other instances.
+type Feed struct {
+	log          EventLog
+	logger       logger.Logger
+	pollInterval time.Duration
+	bufferSize   int
+	wake         chan struct{}
+
+	// cursor is only used by Run
+	cursor *EventCursor
+
+	mu          sync.Mutex
+	position    int64
+	subscribers map[*Subscription]bool
+	stopped     bool
+}
+
+// NewFeed creates a feed polling the log at the given interval. Every subscriber buffers up to
+// bufferSize events; a subscriber that falls further behind is dropped.
+func NewFeed(log EventLog, logger logger.Logger, pollInterval time.Duration, bufferSize int) *Feed {
+	return &Feed{
+		log:          log,
+		logger:       logger,
+		pollInterval: pollInterval,
+		bufferSize:   bufferSize,
+		wake:         make(chan struct{}, 1),
+		cursor:       NewEventCursor(0),
+		subscribers:  make(map[*Subscription]bool),
+	}
+}
+
+// Notify wakes the feed to read the log without waiting for the next poll
+func (f *Feed) Notify() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// NotifyOnCommit returns an event handler that wakes the feed once the transaction storing the event has committed
+func (f *Feed) NotifyOnCommit() EventHandler {
+	return EventHandlerFunc(func(ctx context.Context, event events.Event) error {
+		database.AfterCommit(ctx, f.Notify)
+		return nil
+	})
+}
+
+// Run tails the log until the context is done, then closes every subscription
+func (f *Feed) Run(ctx context.Context) {
+	defer f.stop()
+
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	// Start at the end of the log; subscribers resuming from older events read them from the log
+	for {
+		position, err := f.log.LatestEventID(ctx)
+		if err == nil {
+			f.cursor = NewEventCursor(position)
+			f.mu.Lock()
+			f.position = position
+			f.mu.Unlock()
+			break
+		}
+		f.logger.Error("Failed to read the event log position", logger.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-f.wake:
+		}
+		if err := f.poll(ctx); err != nil && ctx.Err() == nil {
+			f.logger.Error("Failed to read the event log", logger.Error(err))
+		}
+	}
+}
+
+// poll broadcasts the events stored since the last poll
+func (f *Feed) poll(ctx context.Context) error {
+	for {
+		ready, more, err := f.cursor.Next(ctx, f.log, feedBatchSize)
+		if err != nil {
+			return err
+		}
+
+		f.mu.Lock()
+		for _, event := range ready {
+			f.broadcast(event)
+		}
+		f.position = f.cursor.Position()
+		f.mu.Unlock()
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+// broadcast hands an event to the subscribers it matches. The caller holds the lock.
+func (f *Feed) broadcast(event StoredEvent) {
+	for subscription := range f.subscribers {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			// The subscriber fell behind; it resumes from the log when it reconnects
+			delete(f.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the events stored from now on. It returns the ID of the
+// last event the feed has seen, up to which a resuming subscriber reads the log with Replay. Events
+// with lower IDs whose transactions commit later still reach the subscription.
+func (f *Feed) Subscribe(filter EventFilter) (*Subscription, int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subscription := &Subscription{filter: filter, events: make(chan StoredEvent, f.bufferSize)}
+	if f.stopped {
+		close(subscription.events)
+		return subscription, f.position
+	}
+	f.subscribers[subscription] = true
+	return subscription, f.position
+}
+
+// Unsubscribe removes a subscriber
+func (f *Feed) Unsubscribe(subscription *Subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subscribers[subscription] {
+		delete(f.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Replay calls fn for the stored events after afterID up to and including upTo that pass the filter
+func (f *Feed) Replay(ctx context.Context, afterID, upTo int64, filter EventFilter, fn func(StoredEvent) error) error {
+	for afterID < upTo {
+		stored, err := f.log.ReadEvents(ctx, afterID, feedBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(stored) == 0 {
+			return nil
+		}
+		for _, event := range stored {
+			if event.ID > upTo {
+				return nil
+			}
+			if filter.Matches(event) {
+				if err := fn(event); err != nil {
+					return err
+				}
+			}
+			afterID = event.ID
+		}
+	}
+	return nil
+}
+
+// stop closes every subscription and turns away new subscribers
+func (f *Feed) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopped = true
+	for subscription := range f.subscribers {
+		delete(f.subscribers, subscription)
+		close(subscription.events)
+	}
+}
@@ -6,7 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/database"
 	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/requestctx"
+
+	"github.com/lib/pq"
 )
 
 // PostgresEventStore is a PostgreSQL implementation of the EventStore interface
@@ -33,10 +37,12 @@ func (s *PostgresEventStore) StoreEvent(ctx context.Context, event events.Event)
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	// Insert event into database
-	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO events (event_type, occurred_at, event_data) VALUES ($1, $2, $3)`,
-		event.EventType(), event.OccurredAt(), eventData)
+	// Insert event into database together with who caused it and for which tenant, as part of the caller's transaction if any
+	_, err = database.Conn(ctx, s.db).ExecContext(ctx,
+		`INSERT INTO events (event_type, occurred_at, event_data, aggregate_type, aggregate_id, actor, request_id, tenant_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		event.EventType(), event.OccurredAt(), eventData,
+		s.name, event.AggregateID(), requestctx.Actor(ctx), requestctx.RequestID(ctx), requestctx.Tenant(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to store event: %w", err)
 	}
@@ -48,12 +54,12 @@ func (s *PostgresEventStore) StoreEvent(ctx context.Context, event events.Event)
 	return nil
 }
 
-// GetEvents retrieves events by type from the event store
+// GetEvents retrieves the events of the tenant of ctx by type from the event store
 func (s *PostgresEventStore) GetEvents(ctx context.Context, eventType string) ([]events.Event, error) {
 	// Query events from database
-	rows, err := s.db.QueryContext(ctx,
-		`SELECT event_type, event_data FROM events WHERE event_type = $1 ORDER BY occurred_at ASC`,
-		eventType)
+	rows, err := database.Conn(ctx, s.db).QueryContext(ctx,
+		`SELECT event_type, event_data FROM events WHERE event_type = $1 AND tenant_id = $2 ORDER BY occurred_at ASC`,
+		eventType, requestctx.Tenant(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
@@ -88,6 +94,110 @@ func (s *PostgresEventStore) GetEvents(ctx context.Context, eventType string) ([
 	return events, nil
 }
 
+// GetAggregateEvents retrieves the events recorded for an aggregate of this store's type and the tenant of ctx, oldest first
+func (s *PostgresEventStore) GetAggregateEvents(ctx context.Context, aggregateID string) ([]StoredEvent, error) {
+	rows, err := database.Conn(ctx, s.db).QueryContext(ctx,
+		`SELECT id, event_type, aggregate_type, aggregate_id, tenant_id, COALESCE(actor, ''), COALESCE(request_id, ''), occurred_at, event_data
+		 FROM events WHERE aggregate_type = $1 AND aggregate_id = $2 AND tenant_id = $3 ORDER BY id ASC`,
+		s.name, aggregateID, requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregate events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []StoredEvent
+	for rows.Next() {
+		var record StoredEvent
+		var eventData []byte
+
+		if err := rows.Scan(&record.ID, &record.EventType, &record.AggregateType, &record.AggregateID, &record.TenantID,
+			&record.Actor, &record.RequestID, &record.OccurredAt, &eventData); err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+		record.Data = eventData
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event rows: %w", err)
+	}
+
+	return records, nil
+}
+
+// PostgresEventLog reads the events table across all event stores
+type PostgresEventLog struct {
+	db *sql.DB
+}
+
+// NewPostgresEventLog creates a new PostgreSQL-based event log
+func NewPostgresEventLog(db *sql.DB) EventLog {
+	return &PostgresEventLog{db: db}
+}
+
+// ReadEvents retrieves up to limit events of every tenant stored after the given event ID, oldest first
+func (l *PostgresEventLog) ReadEvents(ctx context.Context, afterID int64, limit int) ([]StoredEvent, error) {
+	return l.readEvents(ctx, `id > $1 ORDER BY id ASC LIMIT $2`, afterID, limit)
+}
+
+// ReadEventsByID retrieves the events of every tenant among the given IDs, oldest first
+func (l *PostgresEventLog) ReadEventsByID(ctx context.Context, ids []int64) ([]StoredEvent, error) {
+	return l.readEvents(ctx, `id = ANY($1) ORDER BY id ASC`, pq.Array(ids))
+}
+
+func (l *PostgresEventLog) readEvents(ctx context.Context, condition string, args ...interface{}) ([]StoredEvent, error) {
+	rows, err := l.db.QueryContext(ctx,
+		`SELECT id, event_type, COALESCE(aggregate_type, ''), COALESCE(aggregate_id, ''), tenant_id, COALESCE(actor, ''), COALESCE(request_id, ''), occurred_at, event_data
+		 FROM events WHERE `+condition,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []StoredEvent
+	for rows.Next() {
+		var record StoredEvent
+		var eventData []byte
+
+		if err := rows.Scan(&record.ID, &record.EventType, &record.AggregateType, &record.AggregateID, &record.TenantID,
+			&record.Actor, &record.RequestID, &record.OccurredAt, &eventData); err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+		record.Data = eventData
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event rows: %w", err)
+	}
+
+	return records, nil
+}
+
+// Horizon reads the transaction horizon from a snapshot of the database
+func (l *PostgresEventLog) Horizon(ctx context.Context) (TransactionHorizon, error) {
+	var horizon TransactionHorizon
+	err := l.db.QueryRowContext(ctx,
+		`SELECT pg_snapshot_xmin(snapshot)::text::bigint, pg_snapshot_xmax(snapshot)::text::bigint
+		 FROM (SELECT pg_current_snapshot() AS snapshot) AS current`).Scan(&horizon.Oldest, &horizon.Next)
+	if err != nil {
+		return TransactionHorizon{}, fmt.Errorf("failed to read the transaction horizon: %w", err)
+	}
+	return horizon, nil
+}
+
+// LatestEventID retrieves the ID of the last stored event
+func (l *PostgresEventLog) LatestEventID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := l.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to query the latest event: %w", err)
+	}
+	return id, nil
+}
+
 // deserializeEvent deserializes an event based on its type
 func deserializeEvent(eventType string, data []byte) (events.Event, error) {
 	switch eventType {
@@ -109,6 +219,30 @@ func deserializeEvent(eventType string, data []byte) (events.Event, error) {
 			return nil, err
 		}
 		return &event, nil
+	case events.CustomerAddressAddedEventType:
+		var event events.CustomerAddressAddedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.CustomerAddressUpdatedEventType:
+		var event events.CustomerAddressUpdatedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.CustomerAddressRemovedEventType:
+		var event events.CustomerAddressRemovedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.CustomerMergedEventType:
+		var event events.CustomerMergedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
 	case events.OrderCreatedEventType:
 		var event events.OrderCreatedEvent
 		if err := json.Unmarshal(data, &event); err != nil {
@@ -133,6 +267,48 @@ func deserializeEvent(eventType string, data []byte) (events.Event, error) {
 			return nil, err
 		}
 		return &event, nil
+	case events.OrderLineAddedEventType:
+		var event events.OrderLineAddedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.OrderLineChangedEventType:
+		var event events.OrderLineChangedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.OrderLineRemovedEventType:
+		var event events.OrderLineRemovedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.ProductCreatedEventType:
+		var event events.ProductCreatedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.ProductUpdatedEventType:
+		var event events.ProductUpdatedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.ProductDeactivatedEventType:
+		var event events.ProductDeactivatedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case events.StockLevelSetEventType:
+		var event events.StockLevelSetEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
 	default:
 		return nil, fmt.Errorf("unknown event type: %s", eventType)
 	}
@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSubscriber reads messages from a spool directory, standing in for a broker where there is
+// none. Every message of a topic is a file in the directory named after the topic, handled in file
+// name order and removed once acknowledged. Writers should create files under a name starting
+// with a dot and rename them when complete, as dot files are skipped.
+type FileSubscriber struct {
+	dir          string
+	pollInterval time.Duration
+}
+
+// NewFileSubscriber creates a subscriber polling the spool directory at the given interval
+func NewFileSubscriber(dir string, pollInterval time.Duration) *FileSubscriber {
+	return &FileSubscriber{dir: dir, pollInterval: pollInterval}
+}
+
+// Subscribe hands the files of the topic directory to the handler until the context is done
+func (s *FileSubscriber) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.poll(ctx, topic, handler); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll handles the files of the topic in order, stopping at the first the handler fails
+func (s *FileSubscriber) poll(ctx context.Context, topic string, handler Handler) error {
+	dir := filepath.Join(s.dir, topic)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// ReadDir returns the entries sorted by file name
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := handler(ctx, InboundMessage{Topic: topic, Body: body}); err != nil {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBroker queues messages in memory per topic, standing in for a broker in tests and local runs
+type MemoryBroker struct {
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	queues  map[string][]InboundMessage
+	changed chan struct{}
+}
+
+// NewMemoryBroker creates an empty broker delivering failed messages again after the given delay
+func NewMemoryBroker(retryDelay time.Duration) *MemoryBroker {
+	return &MemoryBroker{
+		retryDelay: retryDelay,
+		queues:     make(map[string][]InboundMessage),
+		changed:    make(chan struct{}),
+	}
+}
+
+// Send queues a message on a topic
+func (b *MemoryBroker) Send(topic string, body []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queues[topic] = append(b.queues[topic], InboundMessage{Topic: topic, Body: body})
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Pending returns the number of messages of a topic not acknowledged yet
+func (b *MemoryBroker) Pending(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queues[topic])
+}
+
+// Subscribe hands the queued messages of the topic to the handler until the context is done
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	for {
+		b.mu.Lock()
+		queue, changed := b.queues[topic], b.changed
+		b.mu.Unlock()
+
+		if len(queue) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-changed:
+				continue
+			}
+		}
+
+		if err := handler(ctx, queue[0]); err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(b.retryDelay):
+				continue
+			}
+		}
+
+		b.mu.Lock()
+		b.queues[topic] = b.queues[topic][1:]
+		b.mu.Unlock()
+	}
+}
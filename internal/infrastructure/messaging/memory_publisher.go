@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryPublisher keeps published messages in memory, for tests and local runs without a broker
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+// NewMemoryPublisher creates an empty in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the messages, or returns the error set with Fail
+func (p *MemoryPublisher) Publish(ctx context.Context, messages []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+// Fail makes every publish return the error until it is called again with nil
+func (p *MemoryPublisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Messages returns the published messages in order
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+// Close does nothing
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,65 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// subjectReplacer keeps keys to a single subject token
+var subjectReplacer = strings.NewReplacer(".", "_", " ", "_", "*", "_", ">", "_")
+
+// NATSPublisher publishes messages to NATS JetStream. A message goes to the subject
+// <topic>.<key>, so consumers can filter by aggregate; the stream capturing the topics must exist.
+// JetStream drops a message whose event ID it saw within the duplicate window of the stream.
+type NATSPublisher struct {
+	conn      *nats.Conn
+	jetStream nats.JetStreamContext
+}
+
+// NewNATSPublisher connects to the NATS server at the given URL
+func NewNATSPublisher(url string) (*NATSPublisher, error) {
+	conn, jetStream, err := connectJetStream(url)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, jetStream: jetStream}, nil
+}
+
+func connectJetStream(url string) (*nats.Conn, nats.JetStreamContext, error) {
+	conn, err := nats.Connect(url, nats.Name("go-cqrs"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	jetStream, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	return conn, jetStream, nil
+}
+
+// Publish sends the messages one by one, waiting for JetStream to store each
+func (p *NATSPublisher) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(message.Topic + "." + subjectReplacer.Replace(message.Key))
+		msg.Data = message.Payload
+		for name, value := range message.Headers {
+			msg.Header.Set(name, value)
+		}
+
+		if _, err := p.jetStream.PublishMsg(msg, nats.MsgId(message.ID), nats.Context(ctx)); err != nil {
+			return fmt.Errorf("failed to publish event %s to NATS: %w", message.ID, err)
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
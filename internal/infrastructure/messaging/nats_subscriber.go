@@ -0,0 +1,50 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSubscriber receives messages from NATS JetStream through a durable consumer, which keeps the
+// position of the subscriber on the server while it is stopped
+type NATSSubscriber struct {
+	conn      *nats.Conn
+	jetStream nats.JetStreamContext
+	durable   string
+}
+
+// NewNATSSubscriber connects to the NATS server at the given URL. The durable name identifies the
+// position of the subscriber in the stream.
+func NewNATSSubscriber(url, durable string) (*NATSSubscriber, error) {
+	conn, jetStream, err := connectJetStream(url)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSSubscriber{conn: conn, jetStream: jetStream, durable: durable}, nil
+}
+
+// Subscribe acknowledges the messages the handler handles and asks for the redelivery of the others
+func (s *NATSSubscriber) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	subscription, err := s.jetStream.Subscribe(topic, func(msg *nats.Msg) {
+		if err := handler(ctx, InboundMessage{Topic: topic, Body: msg.Data}); err != nil {
+			msg.Nak()
+			return
+		}
+		msg.Ack()
+	}, nats.Durable(s.durable), nats.ManualAck())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to NATS subject %s: %w", topic, err)
+	}
+
+	<-ctx.Done()
+	// Draining keeps the durable consumer, unlike unsubscribing
+	return subscription.Drain()
+}
+
+// Close closes the connection
+func (s *NATSSubscriber) Close() error {
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,73 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Message headers carrying the identity of the event
+const (
+	HeaderEventID       = "event-id"
+	HeaderEventType     = "event-type"
+	HeaderAggregateType = "aggregate-type"
+	HeaderAggregateID   = "aggregate-id"
+	HeaderTenantID      = "tenant-id"
+)
+
+// Message is a stored event on its way to a message broker
+type Message struct {
+	// ID is the ID of the event; brokers that deduplicate use it to drop republished messages
+	ID    string
+	Topic string
+	// Key is the aggregate ID of the event. Messages with the same key go to the same partition,
+	// so consumers see the events of an aggregate in order.
+	Key     string
+	Headers map[string]string
+	Payload []byte
+}
+
+// Publisher hands messages to a message broker
+type Publisher interface {
+	// Publish returns once the broker has accepted every message, in order. When it fails, some of
+	// the messages may have been accepted; they are published again, so delivery is at least once.
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// TopicMap routes events to topics by event type
+type TopicMap struct {
+	// Default is the topic of the event types without a topic of their own
+	Default     string
+	ByEventType map[string]string
+}
+
+// Topic returns the topic of an event type
+func (m TopicMap) Topic(eventType string) string {
+	if topic, ok := m.ByEventType[eventType]; ok {
+		return topic
+	}
+	return m.Default
+}
+
+// ParseTopicMap reads a comma-separated list of eventType=topic pairs,
+// e.g. "order.created=orders,order.updated=orders"
+func ParseTopicMap(defaultTopic, spec string) (TopicMap, error) {
+	topics := TopicMap{Default: defaultTopic, ByEventType: make(map[string]string)}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		eventType, topic, ok := strings.Cut(pair, "=")
+		eventType, topic = strings.TrimSpace(eventType), strings.TrimSpace(topic)
+		if !ok || eventType == "" || topic == "" {
+			return TopicMap{}, fmt.Errorf("invalid topic mapping %q, expected eventType=topic", pair)
+		}
+		topics.ByEventType[eventType] = topic
+	}
+	if topics.Default == "" {
+		return TopicMap{}, fmt.Errorf("a default topic is required")
+	}
+	return topics, nil
+}
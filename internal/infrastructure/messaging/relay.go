@@ -0,0 +1,174 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"go-cqrs/internal/infrastructure/logger"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"strconv"
+	"time"
+)
+
+const (
+	// relayCursorName is the name under which the relay saves its position in the event log
+	relayCursorName = "broker"
+	// relayBatchSize is the number of events read from the log and published at a time
+	relayBatchSize = 500
+)
+
+// envelope is the payload of a message, the same document the event stream sends
+type envelope struct {
+	ID            int64           `json:"id"`
+	EventType     string          `json:"eventType"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	TenantID      string          `json:"tenantId"`
+	Actor         string          `json:"actor,omitempty"`
+	RequestID     string          `json:"requestId,omitempty"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Relay publishes the stored events to a message broker in the order they were stored. Its
+// position in the event log is saved once the broker has accepted the events before it, so a
+// failure or a restart publishes the events after the saved position again.
+type Relay struct {
+	log          event_store.EventLog
+	cursors      event_store.CursorStore
+	publisher    Publisher
+	topics       TopicMap
+	logger       logger.Logger
+	pollInterval time.Duration
+
+	// cursor is nil until the saved position is loaded, and again after a failed publish
+	cursor *event_store.EventCursor
+}
+
+// NewRelay creates a relay polling the log at the given interval
+func NewRelay(log event_store.EventLog, cursors event_store.CursorStore, publisher Publisher, topics TopicMap, logger logger.Logger, pollInterval time.Duration) *Relay {
+	return &Relay{
+		log:          log,
+		cursors:      cursors,
+		publisher:    publisher,
+		topics:       topics,
+		logger:       logger,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run publishes events until the context is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Forward(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to publish events to the message broker", logger.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Forward publishes the events stored since the last call and returns how many it published.
+// On first start the relay begins at the end of the log.
+func (r *Relay) Forward(ctx context.Context) (int, error) {
+	if r.cursor == nil {
+		if err := r.loadCursor(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	published := 0
+	for {
+		ready, more, err := r.cursor.Next(ctx, r.log, relayBatchSize)
+		if err != nil {
+			// The cursor may have moved past events that were not read; start again from the saved position
+			r.cursor = nil
+			return published, err
+		}
+
+		if len(ready) > 0 {
+			if err := r.publish(ctx, ready); err != nil {
+				// The cursor moved past events that were not published; start again from the saved position
+				r.cursor = nil
+				return published, err
+			}
+			published += len(ready)
+		}
+
+		if !more {
+			return published, nil
+		}
+	}
+}
+
+func (r *Relay) loadCursor(ctx context.Context) error {
+	position, found, err := r.cursors.LoadPosition(ctx, relayCursorName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if position, err = r.log.LatestEventID(ctx); err != nil {
+			return err
+		}
+		if err := r.cursors.SavePosition(ctx, relayCursorName, position); err != nil {
+			return err
+		}
+	}
+	r.cursor = event_store.NewEventCursor(position)
+	return nil
+}
+
+// publish hands the events to the broker, then saves the checkpoint of the cursor
+func (r *Relay) publish(ctx context.Context, stored []event_store.StoredEvent) error {
+	messages := make([]Message, len(stored))
+	for i, event := range stored {
+		message, err := r.message(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = message
+	}
+
+	if err := r.publisher.Publish(ctx, messages); err != nil {
+		return err
+	}
+	return r.cursors.SavePosition(ctx, relayCursorName, r.cursor.Checkpoint())
+}
+
+func (r *Relay) message(event event_store.StoredEvent) (Message, error) {
+	payload, err := json.Marshal(envelope{
+		ID:            event.ID,
+		EventType:     event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		TenantID:      event.TenantID,
+		Actor:         event.Actor,
+		RequestID:     event.RequestID,
+		OccurredAt:    event.OccurredAt,
+		Data:          event.Data,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	id := strconv.FormatInt(event.ID, 10)
+	return Message{
+		ID:    id,
+		Topic: r.topics.Topic(event.EventType),
+		Key:   event.AggregateID,
+		Headers: map[string]string{
+			HeaderEventID:       id,
+			HeaderEventType:     event.EventType,
+			HeaderAggregateType: event.AggregateType,
+			HeaderAggregateID:   event.AggregateID,
+			HeaderTenantID:      event.TenantID,
+		},
+		Payload: payload,
+	}, nil
+}
@@ -0,0 +1,20 @@
+package messaging
+
+import "context"
+
+// InboundMessage is a message received from a broker topic
+type InboundMessage struct {
+	Topic string
+	Body  []byte
+}
+
+// Handler handles an inbound message. Returning nil acknowledges the message; an error has the
+// broker deliver it again later.
+type Handler func(ctx context.Context, message InboundMessage) error
+
+// Subscriber receives the messages of broker topics
+type Subscriber interface {
+	// Subscribe hands the messages of the topic to the handler one at a time, in order, until the
+	// context is done. A message the handler fails is delivered again before the ones after it.
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+}
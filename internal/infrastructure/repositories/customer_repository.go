@@ -4,7 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"go-cqrs/internal/application/ports"
 	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/requestctx"
+
+	"github.com/lib/pq"
 )
 
 // CustomerRepository implements ports.CustomerRepository
@@ -17,15 +23,18 @@ func NewCustomerRepository(db *sql.DB) *CustomerRepository {
 	return &CustomerRepository{db: db}
 }
 
-// Create inserts a new customer into the database
+// Create inserts a new customer of the tenant of ctx into the database
 func (r *CustomerRepository) Create(ctx context.Context, customer domain.Customer) (int, error) {
 	var customerID int
 
-	err := r.db.QueryRowContext(ctx,
-		"INSERT INTO customers (name, email) VALUES ($1, $2) RETURNING id",
-		customer.Name, customer.Email).Scan(&customerID)
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO customers (name, email, phone, tenant_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		customer.Name, customer.Email, nullableString(customer.Phone), requestctx.Tenant(ctx)).Scan(&customerID)
 
 	if err != nil {
+		if isEmailTaken(err) {
+			return 0, domainerrors.NewConflictError("customer with this email already exists", err)
+		}
 		return 0, errors.New("failed to create customer: " + err.Error())
 	}
 
@@ -34,69 +43,195 @@ func (r *CustomerRepository) Create(ctx context.Context, customer domain.Custome
 
 // GetByID retrieves a customer by their ID
 func (r *CustomerRepository) GetByID(ctx context.Context, id int) (*domain.Customer, error) {
-	var customer domain.Customer
+	return r.getOne(ctx, "failed to get customer: ",
+		"SELECT "+customerColumns+" FROM customers WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
+}
 
-	err := r.db.QueryRowContext(ctx,
-		"SELECT id, name, email FROM customers WHERE id = $1",
-		id).Scan(&customer.ID, &customer.Name, &customer.Email)
+// GetByIDs retrieves the customers with the given IDs in ID order, leaving out the IDs that do not exist
+func (r *CustomerRepository) GetByIDs(ctx context.Context, ids []int) ([]domain.Customer, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	conn := database.Conn(ctx, r.db)
 
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+customerColumns+" FROM customers WHERE id = ANY($1) AND tenant_id = $2 ORDER BY id",
+		pq.Array(int64IDs(ids)), requestctx.Tenant(ctx))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Not found, return nil without error
+		return nil, errors.New("failed to get customers: " + err.Error())
+	}
+	defer rows.Close()
+
+	var customers []domain.Customer
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, errors.New("failed to scan customer row: " + err.Error())
 		}
-		return nil, errors.New("failed to get customer: " + err.Error())
+
+		customers = append(customers, *customer)
 	}
 
-	return &customer, nil
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("error iterating customer rows: " + err.Error())
+	}
+
+	if err := loadCustomerAddresses(ctx, conn, customers); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
 }
 
-// GetByEmail retrieves a customer by their email
-func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
-	var customer domain.Customer
+// GetByEmail retrieves the active customer with the given normalized email
+func (r *CustomerRepository) GetByEmail(ctx context.Context, email domain.Email) (*domain.Customer, error) {
+	return r.getOne(ctx, "failed to get customer by email: ",
+		"SELECT "+customerColumns+" FROM customers WHERE lower(email) = $1 AND merged_into IS NULL AND tenant_id = $2",
+		email.String(), requestctx.Tenant(ctx))
+}
 
-	err := r.db.QueryRowContext(ctx,
-		"SELECT id, name, email FROM customers WHERE email = $1",
-		email).Scan(&customer.ID, &customer.Name, &customer.Email)
+// Update updates an existing customer
+func (r *CustomerRepository) Update(ctx context.Context, customer domain.Customer) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE customers SET name = $1, email = $2, phone = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND tenant_id = $5",
+		customer.Name, customer.Email, nullableString(customer.Phone), customer.ID, requestctx.Tenant(ctx))
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Not found, return nil without error
+		if isEmailTaken(err) {
+			return domainerrors.NewConflictError("email already in use by another customer", err)
 		}
-		return nil, errors.New("failed to get customer by email: " + err.Error())
+		return errors.New("failed to update customer: " + err.Error())
 	}
 
-	return &customer, nil
+	return nil
 }
 
-// Update updates an existing customer
-func (r *CustomerRepository) Update(ctx context.Context, customer domain.Customer) error {
-	_, err := r.db.ExecContext(ctx,
-		"UPDATE customers SET name = $1, email = $2 WHERE id = $3",
-		customer.Name, customer.Email, customer.ID)
+// MarkMerged turns a customer into a tombstone pointing at the customer it was merged into
+func (r *CustomerRepository) MarkMerged(ctx context.Context, id, survivorID int) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE customers SET merged_into = $1, merged_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND tenant_id = $3",
+		survivorID, id, requestctx.Tenant(ctx))
 
 	if err != nil {
-		return errors.New("failed to update customer: " + err.Error())
+		return errors.New("failed to mark customer as merged: " + err.Error())
 	}
 
 	return nil
 }
 
+// SaveAddresses stores the addresses of a customer: new addresses are inserted, known ones
+// updated and the ones that are no longer listed removed. It returns the addresses with their IDs.
+func (r *CustomerRepository) SaveAddresses(ctx context.Context, customerID int, addresses []domain.Address) ([]domain.Address, error) {
+	saved := make([]domain.Address, len(addresses))
+	copy(saved, addresses)
+
+	err := database.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+
+		// Addresses have no tenant of their own, they belong to the tenant of their customer
+		var owned bool
+		if err := conn.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1 AND tenant_id = $2)",
+			customerID, requestctx.Tenant(ctx)).Scan(&owned); err != nil {
+			return err
+		}
+		if !owned {
+			return errors.New("customer not found")
+		}
+
+		keep := make([]int64, 0, len(saved))
+		for _, address := range saved {
+			if address.ID > 0 {
+				keep = append(keep, int64(address.ID))
+			}
+		}
+
+		if _, err := conn.ExecContext(ctx,
+			"DELETE FROM customer_addresses WHERE customer_id = $1 AND NOT (id = ANY($2))",
+			customerID, pq.Array(keep)); err != nil {
+			return err
+		}
+
+		// Clear the defaults first so that moving one never trips the one-default-per-type index
+		if _, err := conn.ExecContext(ctx,
+			"UPDATE customer_addresses SET is_default = FALSE WHERE customer_id = $1 AND is_default",
+			customerID); err != nil {
+			return err
+		}
+
+		for i := range saved {
+			address := &saved[i]
+
+			if address.ID > 0 {
+				result, err := conn.ExecContext(ctx,
+					`UPDATE customer_addresses SET type = $1, line1 = $2, line2 = $3, city = $4, region = $5,
+						postal_code = $6, country = $7, is_default = $8, updated_at = CURRENT_TIMESTAMP
+					 WHERE id = $9 AND customer_id = $10`,
+					address.Type, address.Line1, address.Line2, address.City, address.Region,
+					address.PostalCode, address.Country, address.IsDefault, address.ID, customerID)
+				if err != nil {
+					return err
+				}
+				if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+					return errors.New("address does not belong to the customer")
+				}
+				continue
+			}
+
+			err := conn.QueryRowContext(ctx,
+				`INSERT INTO customer_addresses (customer_id, type, line1, line2, city, region, postal_code, country, is_default)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+				customerID, address.Type, address.Line1, address.Line2, address.City, address.Region,
+				address.PostalCode, address.Country, address.IsDefault).Scan(&address.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("failed to save customer addresses: " + err.Error())
+	}
+
+	return saved, nil
+}
+
 // Delete removes a customer
 func (r *CustomerRepository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM customers WHERE id = $1", id)
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, "DELETE FROM customers WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
 
 	if err != nil {
+		if isMergeTarget(err) {
+			return domainerrors.NewConflictError("customer has other customers merged into it", err)
+		}
 		return errors.New("failed to delete customer: " + err.Error())
 	}
 
 	return nil
 }
 
-// List retrieves customers with pagination
+// HasMergedCustomers reports whether other customers were merged into a customer
+func (r *CustomerRepository) HasMergedCustomers(ctx context.Context, id int) (bool, error) {
+	var merged bool
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM customers WHERE merged_into = $1 AND tenant_id = $2)",
+		id, requestctx.Tenant(ctx)).Scan(&merged)
+
+	if err != nil {
+		return false, errors.New("failed to check merged customers: " + err.Error())
+	}
+
+	return merged, nil
+}
+
+// List retrieves active customers with pagination; merged customers are left out
 func (r *CustomerRepository) List(ctx context.Context, limit, offset int) ([]domain.Customer, error) {
-	rows, err := r.db.QueryContext(ctx,
-		"SELECT id, name, email FROM customers LIMIT $1 OFFSET $2",
-		limit, offset)
+	conn := database.Conn(ctx, r.db)
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+customerColumns+" FROM customers WHERE merged_into IS NULL AND tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3",
+		requestctx.Tenant(ctx), limit, offset)
 
 	if err != nil {
 		return nil, errors.New("failed to list customers: " + err.Error())
@@ -105,19 +240,178 @@ func (r *CustomerRepository) List(ctx context.Context, limit, offset int) ([]dom
 
 	var customers []domain.Customer
 	for rows.Next() {
-		var customer domain.Customer
-
-		err := rows.Scan(&customer.ID, &customer.Name, &customer.Email)
+		customer, err := scanCustomer(rows)
 		if err != nil {
 			return nil, errors.New("failed to scan customer row: " + err.Error())
 		}
 
-		customers = append(customers, customer)
+		customers = append(customers, *customer)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, errors.New("error iterating customer rows: " + err.Error())
 	}
 
+	if err := loadCustomerAddresses(ctx, conn, customers); err != nil {
+		return nil, err
+	}
+
 	return customers, nil
 }
+
+// Stream passes every customer matching the filter to fn in ID order, reading them through a cursor
+func (r *CustomerRepository) Stream(ctx context.Context, filter ports.CustomerFilter, fn func(customer domain.Customer) error) error {
+	var filters queryFilter
+
+	filters.add("tenant_id = $?", requestctx.Tenant(ctx))
+	if !filter.IncludeMerged {
+		filters.addCondition("merged_into IS NULL")
+	}
+	if filter.CreatedFrom != nil {
+		filters.add("created_at >= $?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		filters.add("created_at < $?", *filter.CreatedTo)
+	}
+
+	query := "SELECT " + customerColumns + " FROM customers" + filters.where() + " ORDER BY id"
+
+	var batch []domain.Customer
+	err := database.StreamQuery(ctx, r.db, streamBatchSize, query, filters.args,
+		func(rows *sql.Rows) error {
+			customer, err := scanCustomer(rows)
+			if err != nil {
+				return errors.New("failed to scan customer row: " + err.Error())
+			}
+			batch = append(batch, *customer)
+			return nil
+		},
+		func(ctx context.Context) error {
+			if err := loadCustomerAddresses(ctx, database.Conn(ctx, r.db), batch); err != nil {
+				return err
+			}
+			for _, customer := range batch {
+				if err := fn(customer); err != nil {
+					return err
+				}
+			}
+			batch = batch[:0]
+			return nil
+		})
+	if err != nil {
+		return errors.New("failed to stream customers: " + err.Error())
+	}
+
+	return nil
+}
+
+// customerColumns are the customer columns read by scanCustomer
+const customerColumns = "id, name, email, phone, merged_into"
+
+// scanCustomer reads a customer row selected as customerColumns
+func scanCustomer(row interface {
+	Scan(dest ...interface{}) error
+}) (*domain.Customer, error) {
+	var customer domain.Customer
+	var phone sql.NullString
+	var mergedInto sql.NullInt64
+
+	if err := row.Scan(&customer.ID, &customer.Name, &customer.Email, &phone, &mergedInto); err != nil {
+		return nil, err
+	}
+
+	customer.Phone = phone.String
+	if mergedInto.Valid {
+		survivorID := int(mergedInto.Int64)
+		customer.MergedInto = &survivorID
+	}
+
+	return &customer, nil
+}
+
+// getOne retrieves a single customer selected as customerColumns together with their addresses
+func (r *CustomerRepository) getOne(ctx context.Context, failure, query string, args ...interface{}) (*domain.Customer, error) {
+	conn := database.Conn(ctx, r.db)
+
+	customer, err := scanCustomer(conn.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, return nil without error
+		}
+		return nil, errors.New(failure + err.Error())
+	}
+
+	customers := []domain.Customer{*customer}
+	if err := loadCustomerAddresses(ctx, conn, customers); err != nil {
+		return nil, err
+	}
+
+	return &customers[0], nil
+}
+
+// loadCustomerAddresses fills in the addresses of the given customers with a single query
+func loadCustomerAddresses(ctx context.Context, conn database.Executor, customers []domain.Customer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(customers))
+	index := make(map[int]int, len(customers))
+	for i, customer := range customers {
+		ids[i] = int64(customer.ID)
+		index[customer.ID] = i
+	}
+
+	rows, err := conn.QueryContext(ctx,
+		`SELECT customer_id, id, type, line1, line2, city, region, postal_code, country, is_default
+		 FROM customer_addresses WHERE customer_id = ANY($1) ORDER BY customer_id, id`,
+		pq.Array(ids))
+	if err != nil {
+		return errors.New("failed to load customer addresses: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customerID int
+		var address domain.Address
+
+		err := rows.Scan(&customerID, &address.ID, &address.Type, &address.Line1, &address.Line2,
+			&address.City, &address.Region, &address.PostalCode, &address.Country, &address.IsDefault)
+		if err != nil {
+			return errors.New("failed to scan customer address row: " + err.Error())
+		}
+
+		customer := &customers[index[customerID]]
+		customer.Addresses = append(customer.Addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.New("error iterating customer address rows: " + err.Error())
+	}
+
+	return nil
+}
+
+// isEmailTaken reports whether err is a violation of one of the unique indexes on customer emails
+func isEmailTaken(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		return false
+	}
+	return pqErr.Constraint == database.CustomerEmailIndex
+}
+
+// isMergeTarget reports whether err is a violation of the foreign key of merged customers
+func isMergeTarget(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != foreignKeyViolation {
+		return false
+	}
+	return pqErr.Constraint == database.CustomerMergedIntoKey
+}
+
+// nullableString converts an optional string to a value that stores NULL when empty
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryFilter collects the conditions of a WHERE clause together with their arguments
+type queryFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition, with every $? in it standing for arg
+func (f *queryFilter) add(condition string, arg interface{}) {
+	f.args = append(f.args, arg)
+	f.conditions = append(f.conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(f.args))))
+}
+
+// addCondition appends a condition without arguments
+func (f *queryFilter) addCondition(condition string) {
+	f.conditions = append(f.conditions, condition)
+}
+
+// where returns the WHERE clause, or an empty string when there are no conditions
+func (f *queryFilter) where() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// int64IDs converts IDs for use as a pq.Array argument of = ANY($n)
+func int64IDs(ids []int) []int64 {
+	result := make([]int64, len(ids))
+	for i, id := range ids {
+		result[i] = int64(id)
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+// InboxRepository implements ports.InboxRepository
+type InboxRepository struct {
+	db *sql.DB
+}
+
+// NewInboxRepository creates a new InboxRepository
+func NewInboxRepository(db *sql.DB) *InboxRepository {
+	return &InboxRepository{db: db}
+}
+
+// Record inserts the message into the inbox of the consumer for the tenant of the context unless it is already there
+func (r *InboxRepository) Record(ctx context.Context, consumer, messageID, messageType string, status domain.InboxStatus) (bool, error) {
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO inbox_messages (consumer, message_id, message_type, status, tenant_id) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (consumer, tenant_id, message_id) DO NOTHING`,
+		consumer, messageID, messageType, string(status), requestctx.Tenant(ctx))
+	if err != nil {
+		return false, errors.New("failed to record inbox message: " + err.Error())
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.New("failed to record inbox message: " + err.Error())
+	}
+
+	return inserted == 1, nil
+}
+
+// DeadLetter stores a message the consumer could not handle for the tenant of the context
+func (r *InboxRepository) DeadLetter(ctx context.Context, letter domain.DeadLetter) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO dead_letters (consumer, topic, message_id, message_type, body, reason, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		letter.Consumer, letter.Topic, letter.MessageID, letter.MessageType, letter.Body, letter.Reason, requestctx.Tenant(ctx))
+	if err != nil {
+		return errors.New("failed to store dead letter: " + err.Error())
+	}
+
+	return nil
+}
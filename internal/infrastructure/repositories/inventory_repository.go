@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/requestctx"
+
+	"github.com/lib/pq"
+)
+
+// InventoryRepository implements ports.InventoryRepository. Every tenant keeps its own stock.
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+// NewInventoryRepository creates a new InventoryRepository
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetStockLevel retrieves the stock level of a SKU
+func (r *InventoryRepository) GetStockLevel(ctx context.Context, sku string) (*domain.StockLevel, error) {
+	var level domain.StockLevel
+
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT sku, on_hand, reserved FROM stock_levels WHERE sku = $1 AND tenant_id = $2",
+		sku, requestctx.Tenant(ctx)).Scan(&level.SKU, &level.OnHand, &level.Reserved)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, return nil without error
+		}
+		return nil, errors.New("failed to get stock level: " + err.Error())
+	}
+
+	return &level, nil
+}
+
+// LockStockLevels retrieves the stock levels of the given SKUs with a row lock.
+// SKUs without a stock level are left out.
+func (r *InventoryRepository) LockStockLevels(ctx context.Context, skus []string) (map[string]*domain.StockLevel, error) {
+	levels := make(map[string]*domain.StockLevel, len(skus))
+	if len(skus) == 0 {
+		return levels, nil
+	}
+
+	// Lock in SKU order so concurrent reservations cannot deadlock
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		"SELECT sku, on_hand, reserved FROM stock_levels WHERE sku = ANY($1) AND tenant_id = $2 ORDER BY sku FOR UPDATE",
+		pq.Array(skus), requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, errors.New("failed to lock stock levels: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var level domain.StockLevel
+
+		if err := rows.Scan(&level.SKU, &level.OnHand, &level.Reserved); err != nil {
+			return nil, errors.New("failed to scan stock level row: " + err.Error())
+		}
+
+		levels[level.SKU] = &level
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("error iterating stock level rows: " + err.Error())
+	}
+
+	return levels, nil
+}
+
+// SaveStockLevel stores the on hand and reserved quantities of a SKU
+func (r *InventoryRepository) SaveStockLevel(ctx context.Context, level domain.StockLevel) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO stock_levels (sku, on_hand, reserved, tenant_id) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (tenant_id, sku) DO UPDATE SET on_hand = $2, reserved = $3, updated_at = CURRENT_TIMESTAMP`,
+		level.SKU, level.OnHand, level.Reserved, requestctx.Tenant(ctx))
+
+	if err != nil {
+		return errors.New("failed to save stock level: " + err.Error())
+	}
+
+	return nil
+}
+
+// GetReservations retrieves the reserved quantity per SKU for an order
+func (r *InventoryRepository) GetReservations(ctx context.Context, orderID int) (map[string]int, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		"SELECT sku, quantity FROM stock_reservations WHERE order_id = $1 AND tenant_id = $2",
+		orderID, requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, errors.New("failed to get reservations: " + err.Error())
+	}
+	defer rows.Close()
+
+	reservations := make(map[string]int)
+	for rows.Next() {
+		var sku string
+		var quantity int
+
+		if err := rows.Scan(&sku, &quantity); err != nil {
+			return nil, errors.New("failed to scan reservation row: " + err.Error())
+		}
+
+		reservations[sku] = quantity
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("error iterating reservation rows: " + err.Error())
+	}
+
+	return reservations, nil
+}
+
+// SetReservations replaces the reservations of an order
+func (r *InventoryRepository) SetReservations(ctx context.Context, orderID int, quantities map[string]int) error {
+	return database.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+
+		if _, err := conn.ExecContext(ctx, "DELETE FROM stock_reservations WHERE order_id = $1 AND tenant_id = $2", orderID, requestctx.Tenant(ctx)); err != nil {
+			return errors.New("failed to clear reservations: " + err.Error())
+		}
+
+		for sku, quantity := range quantities {
+			if quantity <= 0 {
+				continue
+			}
+
+			_, err := conn.ExecContext(ctx,
+				"INSERT INTO stock_reservations (order_id, sku, quantity, tenant_id) VALUES ($1, $2, $3, $4)",
+				orderID, sku, quantity, requestctx.Tenant(ctx))
+			if err != nil {
+				return errors.New("failed to store reservation: " + err.Error())
+			}
+		}
+
+		return nil
+	})
+}
@@ -3,10 +3,27 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"go-cqrs/internal/application/ports"
 	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/requestctx"
+
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// foreignKeyViolation is the PostgreSQL error code for a foreign key constraint violation
+const foreignKeyViolation = "23503"
+
+// streamBatchSize is the number of rows fetched at a time by the Stream methods
+const streamBatchSize = 500
+
 // OrderRepository implements ports.OrderRepository
 type OrderRepository struct {
 	db *sql.DB
@@ -17,22 +34,26 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// Create inserts a new order into the database
+// Create inserts a new order of the tenant of ctx and its lines into the database
 func (r *OrderRepository) Create(ctx context.Context, order domain.Order) (int, error) {
 	var orderID int
 
-	query := "INSERT INTO orders (product, quantity) VALUES ($1, $2) RETURNING id"
-	if order.CustomerID != nil {
-		query = "INSERT INTO orders (customer_id, product, quantity) VALUES ($1, $2, $3) RETURNING id"
-		err := r.db.QueryRowContext(ctx, query, *order.CustomerID, order.Product, order.Quantity).Scan(&orderID)
-		if err != nil {
-			return 0, errors.New("failed to create order: " + err.Error())
-		}
-	} else {
-		err := r.db.QueryRowContext(ctx, query, order.Product, order.Quantity).Scan(&orderID)
+	err := database.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+
+		err := conn.QueryRowContext(ctx,
+			`INSERT INTO orders (customer_id, product, quantity, currency, total_amount, shipping_address, tenant_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			nullableID(order.CustomerID), order.Product, order.Quantity, order.Currency, order.Total().Amount,
+			shippingAddressValue{order.ShippingAddress}, requestctx.Tenant(ctx)).Scan(&orderID)
 		if err != nil {
-			return 0, errors.New("failed to create order: " + err.Error())
+			return err
 		}
+
+		return insertOrderLines(ctx, conn, orderID, order.Lines)
+	})
+	if err != nil {
+		return 0, errors.New("failed to create order: " + err.Error())
 	}
 
 	return orderID, nil
@@ -40,74 +61,116 @@ func (r *OrderRepository) Create(ctx context.Context, order domain.Order) (int,
 
 // GetByID retrieves an order by its ID
 func (r *OrderRepository) GetByID(ctx context.Context, id int) (*domain.Order, error) {
-	var order domain.Order
-	var customerID sql.NullInt64
-
-	err := r.db.QueryRowContext(ctx, "SELECT id, customer_id, product, quantity FROM orders WHERE id = $1", id).
-		Scan(&order.ID, &customerID, &order.Product, &order.Quantity)
+	conn := database.Conn(ctx, r.db)
 
+	rows, err := conn.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Not found, return nil without error
-		}
 		return nil, errors.New("failed to get order: " + err.Error())
 	}
+	defer rows.Close()
 
-	if customerID.Valid {
-		custID := int(customerID.Int64)
-		order.CustomerID = &custID
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, nil // Not found, return nil without error
 	}
 
-	return &order, nil
+	if err := loadOrderLines(ctx, conn, orders); err != nil {
+		return nil, err
+	}
+
+	return &orders[0], nil
 }
 
 // GetByCustomerID retrieves all orders for a customer
 func (r *OrderRepository) GetByCustomerID(ctx context.Context, customerID int) ([]domain.Order, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, customer_id, product, quantity FROM orders WHERE customer_id = $1", customerID)
+	conn := database.Conn(ctx, r.db)
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE customer_id = $1 AND tenant_id = $2",
+		customerID, requestctx.Tenant(ctx))
 	if err != nil {
 		return nil, errors.New("failed to get orders by customer: " + err.Error())
 	}
 	defer rows.Close()
 
-	var orders []domain.Order
-	for rows.Next() {
-		var order domain.Order
-		var customerID sql.NullInt64
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
 
-		err := rows.Scan(&order.ID, &customerID, &order.Product, &order.Quantity)
-		if err != nil {
-			return nil, errors.New("failed to scan order row: " + err.Error())
-		}
+	if err := loadOrderLines(ctx, conn, orders); err != nil {
+		return nil, err
+	}
 
-		if customerID.Valid {
-			custID := int(customerID.Int64)
-			order.CustomerID = &custID
-		}
+	return orders, nil
+}
+
+// GetByIDs retrieves the orders with the given IDs in ID order, leaving out the IDs that do not exist
+func (r *OrderRepository) GetByIDs(ctx context.Context, ids []int) ([]domain.Order, error) {
+	return r.getMany(ctx, "SELECT "+orderColumns+" FROM orders WHERE id = ANY($1) AND tenant_id = $2 ORDER BY id", ids, "failed to get orders: ")
+}
+
+// GetByCustomerIDs retrieves the orders of all the given customers, newest first
+func (r *OrderRepository) GetByCustomerIDs(ctx context.Context, customerIDs []int) ([]domain.Order, error) {
+	return r.getMany(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE customer_id = ANY($1) AND tenant_id = $2 ORDER BY customer_id, created_at DESC, id DESC",
+		customerIDs, "failed to get orders by customers: ")
+}
 
-		orders = append(orders, order)
+// getMany retrieves the orders selected as orderColumns by a query over a list of IDs and the tenant of ctx,
+// together with their lines
+func (r *OrderRepository) getMany(ctx context.Context, query string, ids []int, failure string) ([]domain.Order, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
+	conn := database.Conn(ctx, r.db)
 
-	if err = rows.Err(); err != nil {
-		return nil, errors.New("error iterating order rows: " + err.Error())
+	rows, err := conn.QueryContext(ctx, query, pq.Array(int64IDs(ids)), requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, errors.New(failure + err.Error())
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadOrderLines(ctx, conn, orders); err != nil {
+		return nil, err
 	}
 
 	return orders, nil
 }
 
-// Update updates an existing order
+// Update updates an existing order and replaces its lines
 func (r *OrderRepository) Update(ctx context.Context, order domain.Order) error {
-	var err error
+	err := database.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
 
-	if order.CustomerID != nil {
-		_, err = r.db.ExecContext(ctx,
-			"UPDATE orders SET customer_id = $1, product = $2, quantity = $3 WHERE id = $4",
-			*order.CustomerID, order.Product, order.Quantity, order.ID)
-	} else {
-		_, err = r.db.ExecContext(ctx,
-			"UPDATE orders SET customer_id = NULL, product = $1, quantity = $2 WHERE id = $3",
-			order.Product, order.Quantity, order.ID)
-	}
+		result, err := conn.ExecContext(ctx,
+			`UPDATE orders SET customer_id = $1, product = $2, quantity = $3, currency = $4, total_amount = $5,
+				shipping_address = $6, updated_at = CURRENT_TIMESTAMP
+			 WHERE id = $7 AND tenant_id = $8`,
+			nullableID(order.CustomerID), order.Product, order.Quantity, order.Currency, order.Total().Amount,
+			shippingAddressValue{order.ShippingAddress}, order.ID, requestctx.Tenant(ctx))
+		if err != nil {
+			return err
+		}
+		// The lines have no tenant of their own, so they are only replaced for an order of the tenant
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return errors.New("order not found")
+		}
 
+		if _, err := conn.ExecContext(ctx, "DELETE FROM order_lines WHERE order_id = $1", order.ID); err != nil {
+			return err
+		}
+
+		return insertOrderLines(ctx, conn, order.ID, order.Lines)
+	})
 	if err != nil {
 		return errors.New("failed to update order: " + err.Error())
 	}
@@ -117,7 +180,7 @@ func (r *OrderRepository) Update(ctx context.Context, order domain.Order) error
 
 // Delete removes an order
 func (r *OrderRepository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", id)
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, "DELETE FROM orders WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
 	if err != nil {
 		return errors.New("failed to delete order: " + err.Error())
 	}
@@ -127,35 +190,322 @@ func (r *OrderRepository) Delete(ctx context.Context, id int) error {
 
 // List retrieves orders with pagination
 func (r *OrderRepository) List(ctx context.Context, limit, offset int) ([]domain.Order, error) {
-	rows, err := r.db.QueryContext(ctx,
-		"SELECT id, customer_id, product, quantity FROM orders LIMIT $1 OFFSET $2",
-		limit, offset)
+	conn := database.Conn(ctx, r.db)
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE tenant_id = $1 LIMIT $2 OFFSET $3",
+		requestctx.Tenant(ctx), limit, offset)
 	if err != nil {
 		return nil, errors.New("failed to list orders: " + err.Error())
 	}
 	defer rows.Close()
 
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadOrderLines(ctx, conn, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Stream passes every order matching the filter to fn in ID order, reading them through a cursor
+func (r *OrderRepository) Stream(ctx context.Context, filter ports.OrderFilter, fn func(order domain.Order) error) error {
+	filters := orderFilter(ctx, filter)
+	query := "SELECT " + orderColumns + " FROM orders" + filters.where() + " ORDER BY id"
+
+	var batch []domain.Order
+	err := database.StreamQuery(ctx, r.db, streamBatchSize, query, filters.args,
+		func(rows *sql.Rows) error {
+			order, err := scanOrder(rows)
+			if err != nil {
+				return errors.New("failed to scan order row: " + err.Error())
+			}
+			batch = append(batch, *order)
+			return nil
+		},
+		func(ctx context.Context) error {
+			if err := loadOrderLines(ctx, database.Conn(ctx, r.db), batch); err != nil {
+				return err
+			}
+			for _, order := range batch {
+				if err := fn(order); err != nil {
+					return err
+				}
+			}
+			batch = batch[:0]
+			return nil
+		})
+	if err != nil {
+		return errors.New("failed to stream orders: " + err.Error())
+	}
+
+	return nil
+}
+
+// Find returns a page of the orders matching the filter, newest first, with the number of matching orders
+func (r *OrderRepository) Find(ctx context.Context, filter ports.OrderFilter, limit, offset int) ([]domain.Order, int, error) {
+	conn := database.Conn(ctx, r.db)
+	filters := orderFilter(ctx, filter)
+
+	var total int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders"+filters.where(), filters.args...).Scan(&total); err != nil {
+		return nil, 0, errors.New("failed to count orders: " + err.Error())
+	}
+
+	args := append(filters.args, limit, offset)
+	rows, err := conn.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM orders%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+			orderColumns, filters.where(), len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, errors.New("failed to find orders: " + err.Error())
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadOrderLines(ctx, conn, orders); err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+// SummarizeByCustomer aggregates the orders of a customer
+func (r *OrderRepository) SummarizeByCustomer(ctx context.Context, customerID int) (ports.CustomerOrderSummary, error) {
+	var summary ports.CustomerOrderSummary
+	var firstOrderAt, lastOrderAt sql.NullTime
+
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(quantity), 0), MIN(created_at), MAX(created_at)
+		 FROM orders WHERE customer_id = $1 AND tenant_id = $2`,
+		customerID, requestctx.Tenant(ctx)).Scan(&summary.OrderCount, &summary.TotalQuantity, &firstOrderAt, &lastOrderAt)
+	if err != nil {
+		return summary, errors.New("failed to summarize customer orders: " + err.Error())
+	}
+
+	if firstOrderAt.Valid {
+		summary.FirstOrderAt = &firstOrderAt.Time
+	}
+	if lastOrderAt.Valid {
+		summary.LastOrderAt = &lastOrderAt.Time
+	}
+	return summary, nil
+}
+
+// orderFilter builds the conditions selecting the orders of the tenant of ctx that match a filter
+func orderFilter(ctx context.Context, filter ports.OrderFilter) queryFilter {
+	var filters queryFilter
+
+	filters.add("tenant_id = $?", requestctx.Tenant(ctx))
+	if filter.CustomerID != nil {
+		filters.add("customer_id = $?", *filter.CustomerID)
+	}
+	if filter.Product != "" {
+		filters.add("(product = $? OR EXISTS (SELECT 1 FROM order_lines l WHERE l.order_id = orders.id AND l.sku = $?))", filter.Product)
+	}
+	if filter.Currency != "" {
+		filters.add("currency = $?", filter.Currency)
+	}
+	if filter.CreatedFrom != nil {
+		filters.add("created_at >= $?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		filters.add("created_at < $?", *filter.CreatedTo)
+	}
+
+	return filters
+}
+
+// orderColumns are the order columns read by scanOrders
+const orderColumns = "id, customer_id, product, quantity, currency, shipping_address"
+
+// scanOrders reads order rows selected as orderColumns
+func scanOrders(rows *sql.Rows) ([]domain.Order, error) {
 	var orders []domain.Order
 	for rows.Next() {
-		var order domain.Order
-		var customerID sql.NullInt64
-
-		err := rows.Scan(&order.ID, &customerID, &order.Product, &order.Quantity)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, errors.New("failed to scan order row: " + err.Error())
 		}
 
-		if customerID.Valid {
-			custID := int(customerID.Int64)
-			order.CustomerID = &custID
-		}
-
-		orders = append(orders, order)
+		orders = append(orders, *order)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, errors.New("error iterating order rows: " + err.Error())
 	}
 
 	return orders, nil
 }
+
+// scanOrder reads the current order row selected as orderColumns
+func scanOrder(rows *sql.Rows) (*domain.Order, error) {
+	var order domain.Order
+	var customerID sql.NullInt64
+	shippingAddress := shippingAddressValue{}
+
+	if err := rows.Scan(&order.ID, &customerID, &order.Product, &order.Quantity, &order.Currency, &shippingAddress); err != nil {
+		return nil, err
+	}
+
+	if customerID.Valid {
+		custID := int(customerID.Int64)
+		order.CustomerID = &custID
+	}
+	order.ShippingAddress = shippingAddress.address
+
+	return &order, nil
+}
+
+// loadOrderLines fills in the lines of the given orders with a single query.
+// Orders stored before lines existed get a single line built from their product and quantity.
+func loadOrderLines(ctx context.Context, conn database.Executor, orders []domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(orders))
+	index := make(map[int]int, len(orders))
+	for i, order := range orders {
+		ids[i] = int64(order.ID)
+		index[order.ID] = i
+	}
+
+	rows, err := conn.QueryContext(ctx,
+		`SELECT order_id, sku, description, quantity, unit_price FROM order_lines
+		 WHERE order_id = ANY($1) ORDER BY order_id, line_no`,
+		pq.Array(ids))
+	if err != nil {
+		return errors.New("failed to load order lines: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int
+		var line domain.OrderLine
+
+		if err := rows.Scan(&orderID, &line.SKU, &line.Description, &line.Quantity, &line.UnitPrice.Amount); err != nil {
+			return errors.New("failed to scan order line row: " + err.Error())
+		}
+
+		order := &orders[index[orderID]]
+		line.UnitPrice.Currency = order.Currency
+		order.Lines = append(order.Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.New("error iterating order line rows: " + err.Error())
+	}
+
+	for i := range orders {
+		if len(orders[i].Lines) == 0 {
+			orders[i].Lines = []domain.OrderLine{{
+				SKU:         orders[i].Product,
+				Description: orders[i].Product,
+				Quantity:    orders[i].Quantity,
+				UnitPrice:   domain.Money{Currency: orders[i].Currency},
+			}}
+		}
+	}
+
+	return nil
+}
+
+// insertOrderLines stores the lines of an order in their current order
+func insertOrderLines(ctx context.Context, conn database.Executor, orderID int, lines []domain.OrderLine) error {
+	for i, line := range lines {
+		_, err := conn.ExecContext(ctx,
+			`INSERT INTO order_lines (order_id, line_no, sku, description, quantity, unit_price)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			orderID, i+1, line.SKU, line.Description, line.Quantity, line.UnitPrice.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nullableID converts an optional ID to a value that stores NULL when absent
+func nullableID(id *int) sql.NullInt64 {
+	if id == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*id), Valid: true}
+}
+
+// shippingAddressValue stores an order's shipping address snapshot as JSON, or NULL when there is none
+type shippingAddressValue struct {
+	address *domain.Address
+}
+
+// addressSnapshot is the stored JSON form of a shipping address snapshot
+type addressSnapshot struct {
+	AddressID  int    `json:"addressId,omitempty"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	Country    string `json:"country"`
+}
+
+// Value implements driver.Valuer
+func (v shippingAddressValue) Value() (driver.Value, error) {
+	if v.address == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(addressSnapshot{
+		AddressID:  v.address.ID,
+		Line1:      v.address.Line1,
+		Line2:      v.address.Line2,
+		City:       v.address.City,
+		Region:     v.address.Region,
+		PostalCode: v.address.PostalCode,
+		Country:    v.address.Country,
+	})
+}
+
+// Scan implements sql.Scanner
+func (v *shippingAddressValue) Scan(src interface{}) error {
+	v.address = nil
+	if src == nil {
+		return nil
+	}
+
+	var data []byte
+	switch value := src.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return errors.New("unsupported shipping address value")
+	}
+
+	var snapshot addressSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	v.address = &domain.Address{
+		ID:         snapshot.AddressID,
+		Type:       domain.AddressTypeShipping,
+		Line1:      snapshot.Line1,
+		Line2:      snapshot.Line2,
+		City:       snapshot.City,
+		Region:     snapshot.Region,
+		PostalCode: snapshot.PostalCode,
+		Country:    snapshot.Country,
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/database"
+)
+
+// ProductRepository implements ports.ProductRepository. The products form a single catalog
+// shared by every tenant, so its queries are not scoped to the tenant of the context.
+type ProductRepository struct {
+	db *sql.DB
+}
+
+// NewProductRepository creates a new ProductRepository
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// Create inserts a new product into the database
+func (r *ProductRepository) Create(ctx context.Context, product domain.Product) (int, error) {
+	var productID int
+
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO products (sku, name, active, price, currency) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		product.SKU, product.Name, product.Active, product.Price.Amount, product.Price.Currency).Scan(&productID)
+
+	if err != nil {
+		return 0, errors.New("failed to create product: " + err.Error())
+	}
+
+	return productID, nil
+}
+
+// GetByID retrieves a product by its ID
+func (r *ProductRepository) GetByID(ctx context.Context, id int) (*domain.Product, error) {
+	row := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT id, sku, name, active, price, currency FROM products WHERE id = $1", id)
+	return scanProduct(row)
+}
+
+// GetBySKU retrieves a product by its SKU
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	row := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT id, sku, name, active, price, currency FROM products WHERE sku = $1", sku)
+	return scanProduct(row)
+}
+
+// Update updates an existing product
+func (r *ProductRepository) Update(ctx context.Context, product domain.Product) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE products SET name = $1, active = $2, price = $3, currency = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $5",
+		product.Name, product.Active, product.Price.Amount, product.Price.Currency, product.ID)
+
+	if err != nil {
+		return errors.New("failed to update product: " + err.Error())
+	}
+
+	return nil
+}
+
+// List retrieves products with pagination
+func (r *ProductRepository) List(ctx context.Context, limit, offset int) ([]domain.Product, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		"SELECT id, sku, name, active, price, currency FROM products ORDER BY id LIMIT $1 OFFSET $2",
+		limit, offset)
+	if err != nil {
+		return nil, errors.New("failed to list products: " + err.Error())
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		var product domain.Product
+
+		err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Active, &product.Price.Amount, &product.Price.Currency)
+		if err != nil {
+			return nil, errors.New("failed to scan product row: " + err.Error())
+		}
+
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("error iterating product rows: " + err.Error())
+	}
+
+	return products, nil
+}
+
+// scanProduct reads a single product row, returning nil when there is none
+func scanProduct(row *sql.Row) (*domain.Product, error) {
+	var product domain.Product
+
+	err := row.Scan(&product.ID, &product.SKU, &product.Name, &product.Active, &product.Price.Amount, &product.Price.Currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, return nil without error
+		}
+		return nil, errors.New("failed to get product: " + err.Error())
+	}
+
+	return &product, nil
+}
@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/database"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, tenant_id, url, event_types, secret, active, created_at"
+
+const webhookDeliveryColumns = `id, webhook_id, tenant_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, created_at, delivered_at`
+
+// WebhookRepository implements ports.WebhookRepository. Webhooks and their deliveries belong to
+// the tenant of the context they are created in; only the delivery worker reads across tenants.
+// Delivery times are stored in UTC.
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create inserts a new webhook into the database
+func (r *WebhookRepository) Create(ctx context.Context, webhook domain.Webhook) (int, error) {
+	var webhookID int
+
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO webhooks (tenant_id, url, event_types, secret, active) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		requestctx.Tenant(ctx), webhook.URL, pq.Array(eventTypes(webhook.EventTypes)), webhook.Secret, webhook.Active).Scan(&webhookID)
+
+	if err != nil {
+		return 0, errors.New("failed to create webhook: " + err.Error())
+	}
+
+	return webhookID, nil
+}
+
+// GetByID retrieves a webhook by its ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id int) (*domain.Webhook, error) {
+	row := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
+
+	var webhook domain.Webhook
+	err := row.Scan(&webhook.ID, &webhook.TenantID, &webhook.URL, pq.Array(&webhook.EventTypes), &webhook.Secret, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found, return nil without error
+		}
+		return nil, errors.New("failed to get webhook: " + err.Error())
+	}
+
+	return &webhook, nil
+}
+
+// List retrieves every webhook of the tenant in ID order
+func (r *WebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	return r.list(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = $1 ORDER BY id", requestctx.Tenant(ctx))
+}
+
+// ListActive retrieves the active webhooks of every tenant in ID order
+func (r *WebhookRepository) ListActive(ctx context.Context) ([]domain.Webhook, error) {
+	return r.list(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE active ORDER BY id")
+}
+
+func (r *WebhookRepository) list(ctx context.Context, query string, args ...interface{}) ([]domain.Webhook, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.New("failed to list webhooks: " + err.Error())
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := rows.Scan(&webhook.ID, &webhook.TenantID, &webhook.URL, pq.Array(&webhook.EventTypes), &webhook.Secret, &webhook.Active, &webhook.CreatedAt); err != nil {
+			return nil, errors.New("failed to scan webhook: " + err.Error())
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to list webhooks: " + err.Error())
+	}
+
+	return webhooks, nil
+}
+
+// Update updates an existing webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook domain.Webhook) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE webhooks SET url = $1, event_types = $2, secret = $3, active = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $5 AND tenant_id = $6",
+		webhook.URL, pq.Array(eventTypes(webhook.EventTypes)), webhook.Secret, webhook.Active, webhook.ID, requestctx.Tenant(ctx))
+
+	if err != nil {
+		return errors.New("failed to update webhook: " + err.Error())
+	}
+
+	return nil
+}
+
+// Delete removes a webhook; its deliveries and their attempts go with it
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND tenant_id = $2", id, requestctx.Tenant(ctx))
+	if err != nil {
+		return errors.New("failed to delete webhook: " + err.Error())
+	}
+	return nil
+}
+
+// CreateDelivery queues a delivery unless the event was already queued for the webhook
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, tenant_id, event_id, event_type, payload, status, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		delivery.WebhookID, delivery.TenantID, delivery.EventID, delivery.EventType, []byte(delivery.Payload),
+		string(delivery.Status), delivery.NextAttemptAt.UTC())
+
+	if err != nil {
+		return errors.New("failed to create webhook delivery: " + err.Error())
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery of a webhook by its ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, webhookID, id int) (*domain.WebhookDelivery, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 AND id = $2 AND tenant_id = $3",
+		webhookID, id, requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, errors.New("failed to get webhook delivery: " + err.Error())
+	}
+
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// ListDeliveries retrieves a page of the deliveries of a webhook, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int, status domain.WebhookDeliveryStatus, limit, offset int) ([]domain.WebhookDelivery, error) {
+	filter := queryFilter{}
+	filter.add("webhook_id = $?", webhookID)
+	filter.add("tenant_id = $?", requestctx.Tenant(ctx))
+	if status != "" {
+		filter.add("status = $?", string(status))
+	}
+
+	args := append(filter.args, limit, offset)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM webhook_deliveries%s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+			webhookDeliveryColumns, filter.where(), len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, errors.New("failed to list webhook deliveries: " + err.Error())
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// ClaimDueDeliveries postpones up to limit due pending deliveries of active webhooks of every tenant by lease and
+// returns them. Rows claimed by a concurrent worker are skipped.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = $1
+		 WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $2
+				AND webhook_id IN (SELECT id FROM webhooks WHERE active)
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+webhookDeliveryColumns,
+		now.Add(lease), now, limit)
+	if err != nil {
+		return nil, errors.New("failed to claim webhook deliveries: " + err.Error())
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// SaveDelivery stores the state of a delivery of the tenant and logs the attempt that led to it
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	return database.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+
+		var deliveredAt *time.Time
+		if delivery.DeliveredAt != nil {
+			utc := delivery.DeliveredAt.UTC()
+			deliveredAt = &utc
+		}
+		result, err := conn.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3,
+				last_status_code = $4, last_error = $5, delivered_at = $6
+			 WHERE id = $7 AND tenant_id = $8`,
+			string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt.UTC(),
+			delivery.LastStatusCode, delivery.LastError, deliveredAt, delivery.ID, requestctx.Tenant(ctx))
+		if err != nil {
+			return errors.New("failed to save webhook delivery: " + err.Error())
+		}
+		if saved, err := result.RowsAffected(); err == nil && saved == 0 {
+			return errors.New("webhook delivery not found")
+		}
+
+		if attempt == nil {
+			return nil
+		}
+		_, err = conn.ExecContext(ctx,
+			`INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error,
+			attempt.Duration.Milliseconds(), attempt.AttemptedAt.UTC())
+		if err != nil {
+			return errors.New("failed to log webhook attempt: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// ListAttempts retrieves the attempts of a delivery of the tenant, oldest first
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID int) ([]domain.WebhookAttempt, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		`SELECT delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		 FROM webhook_attempts
+		 WHERE delivery_id = (SELECT id FROM webhook_deliveries WHERE id = $1 AND tenant_id = $2)
+		 ORDER BY id`, deliveryID, requestctx.Tenant(ctx))
+	if err != nil {
+		return nil, errors.New("failed to list webhook attempts: " + err.Error())
+	}
+	defer rows.Close()
+
+	var attempts []domain.WebhookAttempt
+	for rows.Next() {
+		var attempt domain.WebhookAttempt
+		var durationMS int64
+		if err := rows.Scan(&attempt.DeliveryID, &attempt.Attempt, &attempt.StatusCode, &attempt.Error, &durationMS, &attempt.AttemptedAt); err != nil {
+			return nil, errors.New("failed to scan webhook attempt: " + err.Error())
+		}
+		attempt.Duration = time.Duration(durationMS) * time.Millisecond
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to list webhook attempts: " + err.Error())
+	}
+
+	return attempts, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		var status string
+		var payload []byte
+		if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.TenantID, &delivery.EventID, &delivery.EventType, &payload, &status,
+			&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+			&delivery.CreatedAt, &delivery.DeliveredAt); err != nil {
+			return nil, errors.New("failed to scan webhook delivery: " + err.Error())
+		}
+		delivery.Status = domain.WebhookDeliveryStatus(status)
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to scan webhook deliveries: " + err.Error())
+	}
+
+	return deliveries, nil
+}
+
+// eventTypes stores a missing list of event types as an empty array
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
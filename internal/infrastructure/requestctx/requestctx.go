@@ -0,0 +1,79 @@
+package requestctx
+
+import (
+	"context"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	actorKey     contextKey = "actor"
+	tenantKey    contextKey = "tenant"
+	allTenantKey contextKey = "all_tenants"
+)
+
+// AnonymousActor is used when a request does not identify who made it
+const AnonymousActor = "anonymous"
+
+// DefaultTenant owns the data of requests that do not name a tenant, and every row stored
+// before tenants existed
+const DefaultTenant = "default"
+
+// WithRequestID returns a copy of ctx carrying the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// WithActor returns a copy of ctx carrying the given actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor stored in ctx, or AnonymousActor
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// WithTenant returns a copy of ctx carrying the given tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// Tenant returns the tenant stored in ctx, or DefaultTenant
+func Tenant(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// HasTenant reports whether ctx carries a tenant. Work outside of any request, like the
+// background workers reading the events of every tenant, carries none.
+func HasTenant(ctx context.Context) bool {
+	tenant, ok := ctx.Value(tenantKey).(string)
+	return ok && tenant != ""
+}
+
+// WithAllTenants returns a copy of ctx allowed to read the rows of every tenant, for the
+// background workers. A tenant stored in ctx takes precedence.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantKey, true)
+}
+
+// AllTenants reports whether ctx is allowed to read the rows of every tenant
+func AllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantKey).(bool)
+	return all
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"go-cqrs/internal/adapters/http/middleware"
+	"go-cqrs/internal/infrastructure/config"
+	"go-cqrs/internal/infrastructure/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestConfigLayersFileEnvironmentAndFlags(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "server:\n  host: 0.0.0.0\n  port: 7000\nlogging:\n  level: warn\nauth:\n  tenants: [acme, globex]\n",
+		"config.toml": "[server]\nhost = \"0.0.0.0\"\nport = 7000\n\n[logging]\nlevel = \"warn\"\n\n[auth]\ntenants = [\"acme\", \"globex\"]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(config.FileEnv, writeFile(t, name, content))
+			t.Setenv("SERVER_PORT", "7001")
+			t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "s3cret\n"))
+
+			cfg, err := config.Load([]string{"-server.port", "7002", "-graphql.mutations=false"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cfg.Server.Host != "0.0.0.0" || cfg.Logging.Level != "warn" {
+				t.Errorf("expected the file to override the defaults, got %+v", cfg)
+			}
+			if cfg.Server.Port != 7002 {
+				t.Errorf("expected the flag to override the environment and the file, got port %d", cfg.Server.Port)
+			}
+			if cfg.GraphQL.Mutations || cfg.GraphQL.MaxDepth != config.Default().GraphQL.MaxDepth {
+				t.Errorf("expected the flag to override its default only, got %+v", cfg.GraphQL)
+			}
+			if strings.Join(cfg.Auth.Tenants, ",") != "acme,globex" {
+				t.Errorf("expected the tenants of the file, got %v", cfg.Auth.Tenants)
+			}
+			if cfg.Database.Password != "s3cret" {
+				t.Errorf("expected the password read from its file, got %q", cfg.Database.Password)
+			}
+			if redacted := cfg.Redacted(); redacted.Database.Password != "REDACTED" || cfg.Database.Password != "s3cret" {
+				t.Errorf("expected a redacted copy, got %q and %q", redacted.Database.Password, cfg.Database.Password)
+			}
+		})
+	}
+}
+
+func TestConfigReportsEveryProblem(t *testing.T) {
+	t.Setenv(config.FileEnv, writeFile(t, "config.yaml", "server:\n  prot: 7000\n"))
+	t.Setenv("SERVER_PORT", "http")
+	t.Setenv("DB_PASSWORD", "postgres")
+	t.Setenv("DB_PASSWORD_FILE", "/run/secrets/db-password")
+
+	_, err := config.Load([]string{"-logging.level", "verbose", "-broker.type", "kafka"})
+
+	var problems config.Errors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected configuration errors, got %v", err)
+	}
+	for _, expected := range []string{"prot", "SERVER_PORT", "DB_PASSWORD_FILE", "logging.level", "broker.url"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected a problem with %s in:\n%v", expected, err)
+		}
+	}
+}
+
+func TestConfigWatcherAppliesOnlyReloadableChanges(t *testing.T) {
+	for _, name := range []string{"LOG_LEVEL", "CORS_ALLOWED_ORIGINS", "DB_HOST"} {
+		t.Setenv(name, "") // Restores the variable after the test
+		os.Unsetenv(name)
+	}
+	path := writeFile(t, "config.yaml", "logging:\n  level: info\n")
+	loader, err := config.Parse([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cors := middleware.NewCorsPolicy(cfg.Server.CORSOrigins)
+	watcher := config.NewWatcher(loader, cfg, logger.NewZapLogger(logger.LogLevel("error"), false), time.Second)
+	var applied []*config.Config
+	watcher.OnReload(func(cfg *config.Config) {
+		cors.SetOrigins(cfg.Server.CORSOrigins)
+		applied = append(applied, cfg)
+	})
+	reload := func(content string) error {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return watcher.Reload()
+	}
+
+	if err := reload("logging:\n  level: debug\nserver:\n  corsOrigins: [https://shop.example.com]\n"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 1 || watcher.Current().Logging.Level != "debug" {
+		t.Fatalf("expected the new log level to be applied, got %d reloads", len(applied))
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/api/customers", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	middleware.CorsMiddleware(cors)(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("expected the origin to be turned away after the reload, got %q", origin)
+	}
+
+	for name, content := range map[string]string{
+		"restart required": "logging:\n  level: debug\nserver:\n  corsOrigins: [https://shop.example.com]\ndatabase:\n  host: db.internal\n",
+		"invalid":          "logging:\n  level: loud\n",
+	} {
+		if err := reload(content); err == nil {
+			t.Errorf("%s: expected the reload to be rejected", name)
+		}
+	}
+	if len(applied) != 1 || watcher.Current().Database.Host != cfg.Database.Host || watcher.Current().Logging.Level != "debug" {
+		t.Errorf("expected the rejected reloads to keep the running configuration, got %+v", watcher.Current())
+	}
+
+	changes := config.Diff(cfg, watcher.Current())
+	if len(changes) != 2 || changes[0].Key != "server.corsOrigins" || changes[1].Key != "logging.level" || !changes[1].Reloadable {
+		t.Errorf("expected the CORS origins and log level to have changed, got %+v", changes)
+	}
+}
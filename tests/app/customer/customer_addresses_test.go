@@ -0,0 +1,75 @@
+package customer
+
+import (
+	"testing"
+
+	"go-cqrs/internal/domain"
+)
+
+func TestCustomerAddressDefaults(t *testing.T) {
+	customer, err := domain.NewCustomer("Ada", "ada@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	home, _ := domain.NewAddress(domain.AddressTypeShipping, "1 Main St", "", "Springfield", "", "12345", "us", false)
+	home.ID = 1
+	office, _ := domain.NewAddress(domain.AddressTypeShipping, "2 Side St", "", "Springfield", "", "12345", "US", true)
+	office.ID = 2
+	billing, _ := domain.NewAddress(domain.AddressTypeBilling, "3 Bank Rd", "", "Springfield", "", "12345", "US", false)
+	billing.ID = 3
+
+	for _, address := range []*domain.Address{home, office, billing} {
+		if err := customer.AddAddress(*address); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if home.Country != "US" {
+		t.Errorf("expected country to be uppercased, got %s", home.Country)
+	}
+	if def := customer.DefaultAddress(domain.AddressTypeShipping); def == nil || def.ID != 2 {
+		t.Errorf("expected the newer default shipping address to win, got %+v", def)
+	}
+	if def := customer.DefaultAddress(domain.AddressTypeBilling); def == nil || def.ID != 3 {
+		t.Errorf("expected the first billing address to become the default, got %+v", def)
+	}
+
+	if err := customer.RemoveAddress(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def := customer.DefaultAddress(domain.AddressTypeShipping); def == nil || def.ID != 1 {
+		t.Errorf("expected the remaining shipping address to take over as default, got %+v", def)
+	}
+
+	if _, err := domain.NewAddress("postal", "1 Main St", "", "Springfield", "", "", "US", false); err == nil {
+		t.Error("expected unknown address type to be rejected")
+	}
+	if _, err := domain.NewAddress(domain.AddressTypeBilling, "1 Main St", "", "Springfield", "", "", "USA", false); err == nil {
+		t.Error("expected a three-letter country code to be rejected")
+	}
+
+	var order domain.Order
+	if err := order.ShipTo(*customer.DefaultAddress(domain.AddressTypeBilling)); err == nil {
+		t.Error("expected a billing address to be rejected as shipping address")
+	}
+}
+
+func TestPhoneNumberE164(t *testing.T) {
+	valid := map[string]string{
+		"+1 (415) 555-0123": "+14155550123",
+		"+49 30 123456":     "+4930123456",
+	}
+	for input, want := range valid {
+		got, err := domain.NormalizePhoneNumber(input)
+		if err != nil || got != want {
+			t.Errorf("NormalizePhoneNumber(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"4155550123", "+0123456", "+1234567890123456", "+1 415 CALL-NOW"} {
+		if _, err := domain.NormalizePhoneNumber(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
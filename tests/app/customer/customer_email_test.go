@@ -0,0 +1,71 @@
+package customer
+
+import (
+	"strings"
+	"testing"
+
+	"go-cqrs/internal/domain"
+)
+
+func TestEmailNormalization(t *testing.T) {
+	valid := map[string]string{
+		" Bob@X.com ":            "bob@x.com",
+		"bob@x.com":              "bob@x.com",
+		"o'neil+tag@Example.org": "o'neil+tag@example.org",
+		"anna@Bücher.example":    "anna@xn--bcher-kva.example",
+	}
+	for input, want := range valid {
+		got, err := domain.NewEmail(input)
+		if err != nil || got.String() != want {
+			t.Errorf("NewEmail(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"bob",
+		"bob@",
+		"@x.com",
+		"bob@localhost",
+		".bob@x.com",
+		"bo..b@x.com",
+		"bob@-x.com",
+		"bob@x..com",
+		"bob@10.0.0.1",
+		strings.Repeat("a", 65) + "@x.com",
+		"bob@" + strings.Repeat("a", 64) + ".com",
+		strings.Repeat("a", 64) + "@" + strings.Repeat(strings.Repeat("b", 60)+".", 4) + "com",
+	}
+	for _, input := range invalid {
+		if _, err := domain.NewEmail(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+
+	first, _ := domain.NewCustomer("Bob", "Bob@X.com")
+	second, _ := domain.NewCustomer("Bob", "bob@x.com")
+	if first.Email != second.Email {
+		t.Errorf("expected emails differing only in case to be equal, got %q and %q", first.Email, second.Email)
+	}
+}
+
+func TestRejectedUpdateLeavesCustomerUnchanged(t *testing.T) {
+	customer, err := domain.NewCustomer("Bob", "bob@x.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := customer.Update("", "robert@x.com"); err == nil {
+		t.Fatal("expected an empty name to be rejected")
+	}
+	if customer.Name != "Bob" || customer.Email.String() != "bob@x.com" {
+		t.Errorf("expected the customer to be unchanged, got %q <%s>", customer.Name, customer.Email)
+	}
+
+	if err := customer.Update("Robert", "Robert@X.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if customer.Name != "Robert" || customer.Email.String() != "robert@x.com" {
+		t.Errorf("expected the update to apply, got %q <%s>", customer.Name, customer.Email)
+	}
+}
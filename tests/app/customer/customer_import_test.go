@@ -0,0 +1,123 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/adapters/imports"
+	"strings"
+	"testing"
+)
+
+// passthroughTransactions runs the work without a database
+type passthroughTransactions struct{}
+
+func (passthroughTransactions) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestImportReportsEveryRow(t *testing.T) {
+	uploads := map[imports.Format]string{
+		imports.FormatCSV:    "name,email\nAda,ada@example.com\nBob,bob@example.com,extra\nCy,\nDee,dee@example.com\n",
+		imports.FormatNDJSON: "{\"name\":\"Ada\",\"email\":\"ada@example.com\"}\n\nnot json\n{\"name\":\"Cy\"}\n{\"name\":\"Dee\",\"email\":\"dee@example.com\"}\n",
+	}
+
+	for format, upload := range uploads {
+		reader, err := imports.NewRowReader(format, strings.NewReader(upload))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+
+		nextID := 0
+		handle := func(ctx context.Context, row *imports.Row) (int, error) {
+			email := row.Fields["email"]
+			if row.JSON != nil && strings.Contains(string(row.JSON), "@") {
+				email = "set"
+			}
+			if email == "" {
+				return 0, errors.New("email is required")
+			}
+			nextID++
+			return nextID, nil
+		}
+
+		report, err := imports.NewImporter(passthroughTransactions{}, 2).Run(context.Background(), reader, handle)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+
+		// A malformed record fails its own row without stopping the import
+		if report.Total != 4 || report.Succeeded != 2 || report.Failed != 2 {
+			t.Fatalf("%s: unexpected report %+v", format, report)
+		}
+		if report.Rows[1].Status != dto.ImportRowFailed || report.Rows[1].Error == "" {
+			t.Errorf("%s: expected row 2 to fail with a reason, got %+v", format, report.Rows[1])
+		}
+		if report.Rows[3].Row != 4 || report.Rows[3].ID != 2 {
+			t.Errorf("%s: expected row 4 to create ID 2, got %+v", format, report.Rows[3])
+		}
+	}
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	if format, err := imports.DetectFormat("text/csv; charset=utf-8", ""); err != nil || format != imports.FormatCSV {
+		t.Errorf("expected csv, got %q, %v", format, err)
+	}
+	if format, err := imports.DetectFormat("application/json", "ndjson"); err != nil || format != imports.FormatNDJSON {
+		t.Errorf("expected the explicit format to win, got %q, %v", format, err)
+	}
+	if _, err := imports.DetectFormat("application/xml", ""); err == nil {
+		t.Error("expected an unsupported content type to be rejected")
+	}
+}
+
+func TestImportRunnerBoundsQueuedJobsAndStopsThemOnShutdown(t *testing.T) {
+	jobs := imports.NewJobStore()
+	runner := imports.NewJobRunner(jobs, 1, 1)
+
+	started := make(chan struct{})
+	blocking := func(ctx context.Context) (*dto.ImportReportDTO, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	queued := func(ctx context.Context) (*dto.ImportReportDTO, error) {
+		return nil, ctx.Err()
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(stopped)
+	}()
+
+	running, err := runner.Submit("customers", blocking)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	waiting, err := runner.Submit("customers", queued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The only worker is busy and the queue is full
+	if _, err := runner.Submit("customers", queued); !errors.Is(err, imports.ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	stop()
+	<-stopped
+
+	for _, id := range []string{running.ID, waiting.ID} {
+		job, ok := jobs.Get(id)
+		if !ok || job.Status != dto.ImportJobFailed || !strings.Contains(job.Error, "shutdown") {
+			t.Errorf("expected job %s to fail on shutdown, got %+v", id, job)
+		}
+	}
+	if _, err := runner.Submit("customers", queued); err == nil {
+		t.Error("expected a stopped runner to refuse new jobs")
+	}
+}
@@ -0,0 +1,89 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/application/services"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+func TestCustomerMergeTombstone(t *testing.T) {
+	survivor, _ := domain.NewCustomer("Bob", "bob@example.com")
+	survivor.ID = 1
+	duplicate, _ := domain.NewCustomer("Bob", "bobby@example.com")
+	duplicate.ID = 2
+
+	if err := survivor.MergeInto(survivor); err == nil {
+		t.Error("expected merging a customer into itself to be rejected")
+	}
+
+	if err := duplicate.MergeInto(survivor); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if duplicate.MergedInto == nil || *duplicate.MergedInto != 1 {
+		t.Fatalf("expected duplicate to point at the survivor, got %v", duplicate.MergedInto)
+	}
+	if err := duplicate.EnsureActive(); err == nil {
+		t.Error("expected a merged customer to be inactive")
+	}
+
+	third, _ := domain.NewCustomer("Robert", "robert@example.com")
+	third.ID = 3
+	if err := third.MergeInto(duplicate); err == nil {
+		t.Error("expected merging into a tombstone to be rejected")
+	}
+	if err := duplicate.MergeInto(third); err == nil {
+		t.Error("expected merging a tombstone again to be rejected")
+	}
+}
+
+// mergedCustomers holds customers, some merged into others, and records deletions
+type mergedCustomers struct {
+	ports.CustomerRepository
+	mergedInto map[int]int
+	deleted    []int
+}
+
+func (r *mergedCustomers) GetByID(ctx context.Context, id int) (*domain.Customer, error) {
+	customer := &domain.Customer{ID: id}
+	if survivorID, ok := r.mergedInto[id]; ok {
+		customer.MergedInto = &survivorID
+	}
+	return customer, nil
+}
+
+func (r *mergedCustomers) HasMergedCustomers(ctx context.Context, id int) (bool, error) {
+	for _, survivorID := range r.mergedInto {
+		if survivorID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *mergedCustomers) Delete(ctx context.Context, id int) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func TestCustomersWithMergedCustomersCannotBeDeleted(t *testing.T) {
+	customers := &mergedCustomers{mergedInto: map[int]int{2: 1}}
+	service := services.NewCustomerService(customers, nil, nil)
+	ctx := context.Background()
+
+	var domainErr *domainerrors.DomainError
+	if err := service.DeleteCustomer(ctx, 1); !errors.As(err, &domainErr) || domainErr.Code != domainerrors.ErrorCodeConflict {
+		t.Fatalf("expected a conflict deleting the survivor of a merge, got %v", err)
+	}
+
+	if err := service.DeleteCustomer(ctx, 2); err != nil {
+		t.Fatalf("unexpected error deleting a tombstone: %v", err)
+	}
+	if len(customers.deleted) != 1 || customers.deleted[0] != 2 {
+		t.Errorf("expected only the tombstone to be deleted, got %v", customers.deleted)
+	}
+}
@@ -0,0 +1,69 @@
+package customer
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/domain"
+	"testing"
+)
+
+// knownCustomers finds the customers with the listed IDs
+type knownCustomers struct {
+	ports.CustomerRepository
+	ids []int
+}
+
+func (r knownCustomers) GetByID(ctx context.Context, id int) (*domain.Customer, error) {
+	for _, known := range r.ids {
+		if known == id {
+			return &domain.Customer{ID: id}, nil
+		}
+	}
+	return nil, nil
+}
+
+// recordedOrderFinds records the filter, limit and offset of every Find
+type recordedOrderFinds struct {
+	ports.OrderRepository
+	filters []ports.OrderFilter
+	pages   [][2]int
+}
+
+func (r *recordedOrderFinds) Find(ctx context.Context, filter ports.OrderFilter, limit, offset int) ([]domain.Order, int, error) {
+	r.filters = append(r.filters, filter)
+	r.pages = append(r.pages, [2]int{limit, offset})
+	return []domain.Order{{ID: 3, CustomerID: filter.CustomerID, Product: "book", Quantity: 1, Currency: "EUR"}}, 12, nil
+}
+
+func TestListCustomerOrdersScopesToCustomer(t *testing.T) {
+	orders := &recordedOrderFinds{}
+	handler := queries.NewCustomerOrderQueryHandler(knownCustomers{ids: []int{5}}, orders)
+
+	otherCustomer := 9
+	page, err := handler.HandleListCustomerOrdersQuery(context.Background(), queries.ListCustomerOrdersQuery{
+		CustomerID: 5,
+		Filter:     ports.OrderFilter{CustomerID: &otherCustomer, Currency: "EUR"},
+		Limit:      10,
+		Offset:     10,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filter := orders.filters[0]
+	if filter.CustomerID == nil || *filter.CustomerID != 5 || filter.Currency != "EUR" {
+		t.Errorf("expected the orders of customer 5 in EUR, got %+v", filter)
+	}
+	if orders.pages[0] != [2]int{10, 10} {
+		t.Errorf("expected the second page of 10, got %v", orders.pages[0])
+	}
+	if page.Total != 12 || len(page.Orders) != 1 || page.Orders[0].ID != 3 {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	_, err = handler.HandleListCustomerOrdersQuery(context.Background(), queries.ListCustomerOrdersQuery{CustomerID: 6, Limit: 10})
+	if err == nil || len(orders.filters) != 1 {
+		t.Error("expected the orders of an unknown customer not to be looked up")
+	}
+}
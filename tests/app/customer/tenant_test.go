@@ -0,0 +1,122 @@
+package customer
+
+import (
+	"context"
+	"encoding/base64"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/middleware"
+	"go-cqrs/internal/domain/events"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func bearerToken(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return "Bearer " + encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestTenantMiddlewareResolvesTenants(t *testing.T) {
+	resolver := middleware.NewTenantResolver("tenant_id", []string{"shop.example.com"}, []string{"acme", "globex", "initech"})
+
+	var resolved string
+	handler := middleware.TenantMiddleware(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved = requestctx.Tenant(r.Context())
+	}))
+
+	tests := []struct {
+		name          string
+		header        string
+		authorization string
+		host          string
+		status        int
+		tenant        string
+	}{
+		{name: "nothing", host: "localhost:8080", status: http.StatusOK, tenant: requestctx.DefaultTenant},
+		{name: "subdomain", host: "Initech.shop.example.com:443", status: http.StatusOK, tenant: "initech"},
+		{name: "token over subdomain", authorization: bearerToken(`{"sub":"u1","tenant_id":"globex"}`), host: "initech.shop.example.com", status: http.StatusOK, tenant: "globex"},
+		{name: "header matching token", header: "Globex", authorization: bearerToken(`{"tenant_id":"globex"}`), status: http.StatusOK, tenant: "globex"},
+		{name: "header naming another tenant than token", header: "acme", authorization: bearerToken(`{"tenant_id":"globex"}`), status: http.StatusForbidden},
+		{name: "header without token claim", header: "acme", authorization: bearerToken(`{"sub":"u1"}`), status: http.StatusOK, tenant: "acme"},
+		{name: "token without claim", authorization: bearerToken(`{"sub":"u1"}`), status: http.StatusOK, tenant: requestctx.DefaultTenant},
+		{name: "malformed", header: "acme/../globex", status: http.StatusBadRequest},
+		{name: "unknown", header: "umbrella", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved = ""
+			req := httptest.NewRequest(http.MethodGet, "/api/customers", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(middleware.TenantHeader, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if resolved != tt.tenant {
+				t.Errorf("expected tenant %q, got %q", tt.tenant, resolved)
+			}
+		})
+	}
+}
+
+func TestQueryCacheKeepsTenantsApart(t *testing.T) {
+	cache := bus.NewQueryCache(10, time.Minute)
+	queryBus := bus.NewQueryBus()
+	queryBus.Use(bus.CachingMiddleware(cache, queries.CachedQueries...))
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetCustomerQuery) (string, error) {
+		return requestctx.Tenant(ctx), nil
+	})
+
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	globex := requestctx.WithTenant(context.Background(), "globex")
+	for _, ctx := range []context.Context{acme, globex, acme} {
+		result, err := bus.Ask[string](ctx, queryBus, queries.GetCustomerQuery{ID: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != requestctx.Tenant(ctx) {
+			t.Errorf("expected the result of %s, got the one of %s", requestctx.Tenant(ctx), result)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected a cached result per tenant, got %d", cache.Len())
+	}
+}
+
+func TestEventStoreScopesEventsToTenants(t *testing.T) {
+	store := event_store.NewInMemoryEventStore("customer")
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	globex := requestctx.WithTenant(context.Background(), "globex")
+
+	if err := store.StoreEvent(acme, events.NewCustomerCreatedEvent("1", "Ann", "ann@example.com", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.StoreEvent(globex, events.NewCustomerCreatedEvent("1", "Bob", "bob@example.com", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err := store.GetAggregateEvents(acme, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].TenantID != "acme" {
+		t.Fatalf("expected the one event of acme, got %+v", history)
+	}
+
+	filter := event_store.EventFilter{TenantID: "globex"}
+	if filter.Matches(history[0]) {
+		t.Error("expected the event of acme to be filtered out of the stream of globex")
+	}
+}
@@ -0,0 +1,109 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-cqrs/internal/adapters/batch"
+	"go-cqrs/internal/adapters/http/dto"
+	"testing"
+)
+
+type createCommand struct {
+	Name string
+}
+
+type assignCommand struct {
+	OrderID    int
+	CustomerID int
+}
+
+func newBatchExecutor(assigned *[]assignCommand) *batch.Executor {
+	executor := batch.NewExecutor(passthroughTransactions{})
+	nextID := 0
+	executor.Register("CreateCommand", batch.Command(func(ctx context.Context, cmd createCommand) (batch.Result, error) {
+		if cmd.Name == "" {
+			return batch.Result{}, errors.New("name is required")
+		}
+		nextID++
+		return batch.Result{ID: nextID}, nil
+	}))
+	executor.Register("AssignCommand", batch.Command(func(ctx context.Context, cmd assignCommand) (batch.Result, error) {
+		*assigned = append(*assigned, cmd)
+		return batch.Result{ID: cmd.OrderID}, nil
+	}))
+	return executor
+}
+
+func batchCommand(id, commandType, payload string) dto.BatchCommandDTO {
+	return dto.BatchCommandDTO{ID: id, Type: commandType, Payload: json.RawMessage(payload)}
+}
+
+func TestBatchResolvesReferences(t *testing.T) {
+	var assigned []assignCommand
+	executor := newBatchExecutor(&assigned)
+
+	response, err := executor.Execute(context.Background(), dto.BatchRequest{Commands: []dto.BatchCommandDTO{
+		batchCommand("customer", "CreateCommand", `{"name": "Ada"}`),
+		batchCommand("order", "Create", `{"name": "order"}`),
+		batchCommand("", "AssignCommand", `{"orderId": {"$ref": "order"}, "customerId": {"$ref": "customer"}}`),
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !response.Succeeded || !response.Atomic {
+		t.Fatalf("expected an atomic batch to succeed, got %+v", response)
+	}
+	if len(assigned) != 1 || assigned[0] != (assignCommand{OrderID: 2, CustomerID: 1}) {
+		t.Errorf("expected order 2 to be assigned to customer 1, got %+v", assigned)
+	}
+}
+
+func TestBatchFailureModes(t *testing.T) {
+	commands := []dto.BatchCommandDTO{
+		batchCommand("customer", "CreateCommand", `{"name": "Ada"}`),
+		batchCommand("order", "CreateCommand", `{}`),
+		batchCommand("", "AssignCommand", `{"orderId": {"$ref": "order"}, "customerId": {"$ref": "customer"}}`),
+		batchCommand("", "CreateCommand", `{"name": "Bob"}`),
+	}
+
+	var assigned []assignCommand
+	atomic := true
+	response, err := newBatchExecutor(&assigned).Execute(context.Background(), dto.BatchRequest{Atomic: &atomic, Commands: commands})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := []string{dto.BatchCommandRolledBack, dto.BatchCommandFailed, dto.BatchCommandSkipped, dto.BatchCommandSkipped}
+	for i, status := range statuses {
+		if response.Results[i].Status != status {
+			t.Errorf("atomic: expected command %d to be %s, got %s", i, status, response.Results[i].Status)
+		}
+	}
+
+	atomic = false
+	response, err = newBatchExecutor(&assigned).Execute(context.Background(), dto.BatchRequest{Atomic: &atomic, Commands: commands})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses = []string{dto.BatchCommandSucceeded, dto.BatchCommandFailed, dto.BatchCommandSkipped, dto.BatchCommandSucceeded}
+	for i, status := range statuses {
+		if response.Results[i].Status != status {
+			t.Errorf("best effort: expected command %d to be %s, got %s", i, status, response.Results[i].Status)
+		}
+	}
+	if len(assigned) != 0 {
+		t.Errorf("expected the assignment depending on a failed command not to run, got %+v", assigned)
+	}
+}
+
+func TestBatchRejectsForwardReferences(t *testing.T) {
+	var assigned []assignCommand
+	_, err := newBatchExecutor(&assigned).Execute(context.Background(), dto.BatchRequest{Commands: []dto.BatchCommandDTO{
+		batchCommand("", "AssignCommand", `{"orderId": {"$ref": "order"}}`),
+		batchCommand("order", "CreateCommand", `{"name": "order"}`),
+	}})
+	if err == nil {
+		t.Error("expected a reference to a later command to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"testing"
+	"time"
+)
+
+type placeOrderCommand struct {
+	Product string
+}
+
+type cancelOrderCommand struct {
+	ID int
+}
+
+func recordingMiddleware(name string, calls *[]string) bus.CommandMiddleware {
+	return func(next bus.CommandHandler) bus.CommandHandler {
+		return func(ctx context.Context, cmd interface{}) (interface{}, error) {
+			*calls = append(*calls, name+":"+bus.CommandName(cmd))
+			return next(ctx, cmd)
+		}
+	}
+}
+
+func TestCommandBusDispatchesThroughMiddleware(t *testing.T) {
+	var calls []string
+	commandBus := bus.NewCommandBus()
+	commandBus.Use(recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+
+	bus.RegisterCommand(commandBus, func(ctx context.Context, cmd placeOrderCommand) (int, error) {
+		calls = append(calls, "handler:"+cmd.Product)
+		return 42, nil
+	})
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd cancelOrderCommand) error {
+		return errors.New("already shipped")
+	})
+
+	id, err := bus.Result[int](commandBus.Dispatch(context.Background(), &placeOrderCommand{Product: "book"}))
+	if err != nil || id != 42 {
+		t.Fatalf("expected order 42, got %d, %v", id, err)
+	}
+
+	expected := []string{"outer:placeOrderCommand", "inner:placeOrderCommand", "handler:book"}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("expected call %d to be %s, got %s", i, expected[i], calls[i])
+		}
+	}
+
+	if _, err := commandBus.Dispatch(context.Background(), cancelOrderCommand{ID: 1}); err == nil || err.Error() != "already shipped" {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+	if _, err := commandBus.Dispatch(context.Background(), struct{}{}); err == nil {
+		t.Error("expected dispatching an unregistered command to fail")
+	}
+}
+
+func TestRetryMiddlewareRetriesTransientErrors(t *testing.T) {
+	transient := errors.New("serialization failure")
+	commandBus := bus.NewCommandBus()
+	commandBus.Use(bus.RetryMiddleware(3, time.Millisecond, func(err error) bool { return errors.Is(err, transient) }))
+
+	attempts := 0
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd cancelOrderCommand) error {
+		attempts++
+		if attempts < 3 {
+			return transient
+		}
+		return nil
+	})
+
+	if _, err := commandBus.Dispatch(context.Background(), cancelOrderCommand{ID: 1}); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
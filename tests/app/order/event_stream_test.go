@@ -0,0 +1,204 @@
+package customer
+
+import (
+	"bufio"
+	"context"
+	"go-cqrs/internal/adapters/http/controllers"
+	"go-cqrs/internal/infrastructure/logger"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryEventLog is an event log kept in memory. Every event is stored by a transaction of its
+// own, committed at once by append, or ended later for those stored by begin.
+type memoryEventLog struct {
+	mu     sync.Mutex
+	events []event_store.StoredEvent
+	// running maps the IDs of the events not committed yet to their transactions
+	running      map[int64]uint64
+	rolledBack   map[int64]bool
+	transactions uint64
+	// started is closed once the feed has read its position
+	started   chan struct{}
+	startOnce sync.Once
+}
+
+func newMemoryEventLog() *memoryEventLog {
+	return &memoryEventLog{running: make(map[int64]uint64), rolledBack: make(map[int64]bool), started: make(chan struct{})}
+}
+
+func (l *memoryEventLog) append(eventType, aggregateID string) {
+	l.begin(eventType, aggregateID)(true)
+}
+
+// begin stores an event in a transaction that commits or rolls back when the returned function is called
+func (l *memoryEventLog) begin(eventType, aggregateID string) (end func(commit bool)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := int64(len(l.events) + 1)
+	l.transactions++
+	l.running[id] = l.transactions
+	l.events = append(l.events, event_store.StoredEvent{
+		ID:            id,
+		EventType:     eventType,
+		AggregateType: "order",
+		AggregateID:   aggregateID,
+		TenantID:      requestctx.DefaultTenant,
+		OccurredAt:    time.Now(),
+		Data:          []byte(`{}`),
+	})
+	return func(commit bool) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.running, id)
+		l.rolledBack[id] = !commit
+	}
+}
+
+// visible reports whether the event has been committed. The caller holds the lock.
+func (l *memoryEventLog) visible(id int64) bool {
+	_, running := l.running[id]
+	return !running && !l.rolledBack[id]
+}
+
+func (l *memoryEventLog) ReadEvents(ctx context.Context, afterID int64, limit int) ([]event_store.StoredEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []event_store.StoredEvent
+	for _, event := range l.events {
+		if l.visible(event.ID) && event.ID > afterID && len(result) < limit {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (l *memoryEventLog) ReadEventsByID(ctx context.Context, ids []int64) ([]event_store.StoredEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []event_store.StoredEvent
+	for _, event := range l.events {
+		for _, id := range ids {
+			if l.visible(event.ID) && event.ID == id {
+				result = append(result, event)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (l *memoryEventLog) Horizon(ctx context.Context) (event_store.TransactionHorizon, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	horizon := event_store.TransactionHorizon{Oldest: l.transactions + 1, Next: l.transactions + 1}
+	for _, transaction := range l.running {
+		if transaction < horizon.Oldest {
+			horizon.Oldest = transaction
+		}
+	}
+	return horizon, nil
+}
+
+func (l *memoryEventLog) LatestEventID(ctx context.Context) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.startOnce.Do(func() { close(l.started) })
+	return int64(len(l.events)), nil
+}
+
+func startFeed(t *testing.T, log *memoryEventLog, bufferSize int) *event_store.Feed {
+	t.Helper()
+	feed := event_store.NewFeed(log, logger.NewZapLogger(logger.LogLevel("error"), false), 10*time.Millisecond, bufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go feed.Run(ctx)
+	<-log.started
+	waitForPosition(feed, int64(len(log.events)))
+	return feed
+}
+
+// waitForPosition waits for the feed to have seen the event
+func waitForPosition(feed *event_store.Feed, id int64) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		subscription, position := feed.Subscribe(event_store.EventFilter{})
+		feed.Unsubscribe(subscription)
+		if position == id {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventStreamResumesAfterLastEventIDAndFilters(t *testing.T) {
+	log := newMemoryEventLog()
+	log.append("OrderCreated", "1")
+	log.append("OrderCreated", "2")
+	log.append("OrderUpdated", "1")
+	feed := startFeed(t, log, 16)
+
+	server := httptest.NewServer(http.HandlerFunc(controllers.NewEventStreamController(feed).StreamEvents))
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"?aggregateId=1", nil)
+	request.Header.Set("Last-Event-ID", "1")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", contentType)
+	}
+
+	log.append("OrderCreated", "2")
+	log.append("OrderLineAdded", "1")
+	feed.Notify()
+
+	// Event 3 is replayed from the log, event 5 arrives live; the events of order 2 are filtered out
+	var ids []string
+	scanner := bufio.NewScanner(response.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if strings.Join(ids, ",") != "3,5" {
+		t.Errorf("expected events 3 and 5, got %v", ids)
+	}
+}
+
+func TestEventFeedDropsSubscribersThatFallBehind(t *testing.T) {
+	log := newMemoryEventLog()
+	feed := startFeed(t, log, 2)
+
+	subscription, _ := feed.Subscribe(event_store.EventFilter{})
+	for i := 0; i < 3; i++ {
+		log.append("OrderCreated", "1")
+	}
+	feed.Notify()
+	waitForPosition(feed, 3)
+
+	received := 0
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-subscription.Events():
+			if !ok {
+				if received != 2 {
+					t.Errorf("expected the buffered events before the subscription closed, got %d", received)
+				}
+				return
+			}
+			received++
+		case <-timeout:
+			t.Fatal("expected the subscription to be closed")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/gql"
+	"go-cqrs/internal/adapters/http/dto"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"strings"
+	"testing"
+)
+
+func newGraphQLExecutor(t *testing.T, commandBus *bus.CommandBus, queryBus *bus.QueryBus, limits gql.Limits) *gql.Executor {
+	t.Helper()
+	schema, err := gql.NewSchema(commandBus, queryBus, true)
+	if err != nil {
+		t.Fatalf("failed to build schema: %v", err)
+	}
+	return gql.NewExecutor(schema, queryBus, limits)
+}
+
+func TestGraphQLBatchesNestedLookups(t *testing.T) {
+	customerIDs := []int{1, 2, 3}
+	var orderBatches, customerBatches int
+
+	queryBus := bus.NewQueryBus()
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.ListCustomersQuery) ([]dto.CustomerDTO, error) {
+		var customers []dto.CustomerDTO
+		for _, id := range customerIDs {
+			customers = append(customers, dto.CustomerDTO{ID: id, Name: "Customer", Email: "customer@example.com", Addresses: []dto.AddressDTO{}})
+		}
+		return customers, nil
+	})
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetOrdersByCustomersQuery) ([]dto.OrderDTO, error) {
+		orderBatches++
+		var orders []dto.OrderDTO
+		for _, id := range query.CustomerIDs {
+			customerID := id
+			orders = append(orders,
+				dto.OrderDTO{ID: id*10 + 1, CustomerID: &customerID, Product: "book", Currency: "EUR", Lines: []dto.OrderLineDTO{}},
+				dto.OrderDTO{ID: id*10 + 2, CustomerID: &customerID, Product: "pen", Currency: "EUR", Lines: []dto.OrderLineDTO{}})
+		}
+		return orders, nil
+	})
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetCustomersQuery) ([]dto.CustomerDTO, error) {
+		customerBatches++
+		var customers []dto.CustomerDTO
+		for _, id := range query.IDs {
+			customers = append(customers, dto.CustomerDTO{ID: id, Name: "Customer", Email: "customer@example.com", Addresses: []dto.AddressDTO{}})
+		}
+		return customers, nil
+	})
+	executor := newGraphQLExecutor(t, bus.NewCommandBus(), queryBus, gql.Limits{MaxDepth: 8, MaxComplexity: 1000})
+
+	result := executor.Execute(context.Background(), dto.GraphQLRequest{
+		Query: `{ customers(limit: 3) { id orders { id customer { id name } } } }`,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	if orderBatches != 1 || customerBatches != 1 {
+		t.Errorf("expected one batch per level, got %d order and %d customer lookups", orderBatches, customerBatches)
+	}
+
+	body, _ := json.Marshal(result.Data)
+	var data struct {
+		Customers []struct {
+			ID     int
+			Orders []struct {
+				ID       int
+				Customer struct{ ID int }
+			}
+		}
+	}
+	json.Unmarshal(body, &data)
+	if len(data.Customers) != 3 || len(data.Customers[1].Orders) != 2 || data.Customers[1].Orders[0].Customer.ID != 2 {
+		t.Errorf("unexpected data %s", body)
+	}
+}
+
+func TestGraphQLRejectsQueriesOverTheLimits(t *testing.T) {
+	executor := newGraphQLExecutor(t, bus.NewCommandBus(), bus.NewQueryBus(), gql.Limits{MaxDepth: 3, MaxComplexity: 100})
+
+	result := executor.Execute(context.Background(), dto.GraphQLRequest{
+		Query: `{ customer(id: 1) { orders { customer { orders { id } } } } }`,
+	})
+	if result.Data != nil || len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "depth") {
+		t.Errorf("expected the depth limit to reject the query, got %v", result.Errors)
+	}
+
+	result = executor.Execute(context.Background(), dto.GraphQLRequest{
+		Query:     `query Page($limit: Int) { customers(limit: $limit) { id name email } }`,
+		Variables: map[string]interface{}{"limit": float64(200)},
+	})
+	if result.Data != nil || len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "complexity") {
+		t.Errorf("expected the complexity limit to reject the query, got %v", result.Errors)
+	}
+}
+
+func TestGraphQLMutationReportsTheDomainErrorCode(t *testing.T) {
+	commandBus := bus.NewCommandBus()
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd commands.DeleteOrderCommand) error {
+		return domainerrors.NewNotFoundError("order", cmd.ID)
+	})
+	executor := newGraphQLExecutor(t, commandBus, bus.NewQueryBus(), gql.Limits{})
+
+	result := executor.Execute(context.Background(), dto.GraphQLRequest{Query: `mutation { deleteOrder(id: 9) }`})
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != string(domainerrors.ErrorCodeNotFound) {
+		t.Errorf("expected a NOT_FOUND error, got %v", result.Errors)
+	}
+}
+
+func TestGraphQLOrderLinesDefaultToTheCatalogPrice(t *testing.T) {
+	var added []commands.AddOrderLineCommand
+	commandBus := bus.NewCommandBus()
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd commands.AddOrderLineCommand) error {
+		added = append(added, cmd)
+		return domainerrors.NewNotFoundError("order", cmd.OrderID)
+	})
+	executor := newGraphQLExecutor(t, commandBus, bus.NewQueryBus(), gql.Limits{})
+
+	for _, line := range []string{`{sku: "BOOK-1", quantity: 2}`, `{sku: "BOOK-1", quantity: 2, unitPrice: 1500}`} {
+		result := executor.Execute(context.Background(), dto.GraphQLRequest{Query: `mutation { addOrderLine(orderId: 1, line: ` + line + `) { id } }`})
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != string(domainerrors.ErrorCodeNotFound) {
+			t.Fatalf("expected the command to be dispatched, got %v", result.Errors)
+		}
+	}
+	if len(added) != 2 || added[0].UnitPrice != 0 || added[1].UnitPrice != 1500 {
+		t.Errorf("expected an omitted unit price to be left for the catalog, got %+v", added)
+	}
+}
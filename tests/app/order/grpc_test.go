@@ -0,0 +1,86 @@
+package customer
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/grpc/pb"
+	grpcserver "go-cqrs/internal/adapters/grpc/server"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/adapters/http/middleware"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"go-cqrs/internal/infrastructure/logger"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newOrderClient(t *testing.T, commandBus *bus.CommandBus, queryBus *bus.QueryBus) pb.OrderServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpcserver.NewServer(commandBus, queryBus, logger.NewZapLogger(logger.LogLevel("error"), false), middleware.TenantResolver{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewOrderServiceClient(conn)
+}
+
+func TestGRPCGetOrderUsesTheQueryBus(t *testing.T) {
+	queryBus := bus.NewQueryBus()
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetOrderQuery) (*dto.OrderDTO, error) {
+		if query.ID != 7 {
+			return nil, domainerrors.NewNotFoundError("order", query.ID)
+		}
+		return &dto.OrderDTO{ID: 7, Product: "book", Currency: "EUR", Total: 3000,
+			Lines: []dto.OrderLineDTO{{SKU: "BOOK-1", Quantity: 2, UnitPrice: 1500, Subtotal: 3000}}}, nil
+	})
+	client := newOrderClient(t, bus.NewCommandBus(), queryBus)
+
+	order, err := client.GetOrder(context.Background(), &pb.GetOrderRequest{Id: 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.GetTotal() != 3000 || len(order.GetLines()) != 1 || order.GetLines()[0].GetSku() != "BOOK-1" {
+		t.Errorf("unexpected order %v", order)
+	}
+
+	_, err = client.GetOrder(context.Background(), &pb.GetOrderRequest{Id: 8})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a missing order, got %v", err)
+	}
+}
+
+func TestGRPCMapsCommandErrors(t *testing.T) {
+	commandBus := bus.NewCommandBus()
+	bus.RegisterCommand(commandBus, func(ctx context.Context, cmd commands.CreateOrderCommand) (int, error) {
+		return 0, domainerrors.NewValidationError("order must have at least one line")
+	})
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd commands.AddOrderLineCommand) error {
+		return domainerrors.NewInsufficientStockError(cmd.SKU, cmd.Quantity, 0)
+	})
+	client := newOrderClient(t, commandBus, bus.NewQueryBus())
+
+	_, err := client.CreateOrder(context.Background(), &pb.CreateOrderRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a validation error, got %v", err)
+	}
+
+	_, err = client.AddOrderLine(context.Background(), &pb.AddOrderLineRequest{OrderId: 1, Line: &pb.OrderLine{Sku: "BOOK-1", Quantity: 3}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for insufficient stock, got %v", err)
+	}
+}
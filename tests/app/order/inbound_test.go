@@ -0,0 +1,167 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/commands"
+	"go-cqrs/internal/adapters/integration"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/messaging"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryInbox keeps the inbox in memory and rolls it back when a transaction fails.
+// Messages are recorded by tenant and ID, as in "default/m-1".
+type memoryInbox struct {
+	handled     map[string]domain.InboxStatus
+	deadLetters []domain.DeadLetter
+}
+
+func (i *memoryInbox) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	handled := make(map[string]domain.InboxStatus, len(i.handled))
+	for id, status := range i.handled {
+		handled[id] = status
+	}
+	deadLetters := i.deadLetters
+
+	if err := fn(ctx); err != nil {
+		i.handled, i.deadLetters = handled, deadLetters
+		return err
+	}
+	return nil
+}
+
+func (i *memoryInbox) Record(ctx context.Context, consumer, messageID, messageType string, status domain.InboxStatus) (bool, error) {
+	key := requestctx.Tenant(ctx) + "/" + messageID
+	if _, ok := i.handled[key]; ok {
+		return false, nil
+	}
+	i.handled[key] = status
+	return true, nil
+}
+
+func (i *memoryInbox) DeadLetter(ctx context.Context, letter domain.DeadLetter) error {
+	i.deadLetters = append(i.deadLetters, letter)
+	return nil
+}
+
+func newCheckoutConsumer(inbox *memoryInbox, createOrder func(cmd commands.CreateOrderCommand) (int, error)) *integration.Consumer {
+	commandBus := bus.NewCommandBus()
+	bus.RegisterCommand(commandBus, func(ctx context.Context, cmd commands.CreateOrderCommand) (int, error) {
+		return createOrder(cmd)
+	})
+	bus.RegisterVoidCommand(commandBus, func(ctx context.Context, cmd commands.AssignCustomerCommand) error {
+		return domainerrors.NewNotFoundError("order", cmd.OrderID)
+	})
+
+	consumer := integration.NewConsumer("checkout", commandBus, inbox, inbox, logger.NewZapLogger(logger.LogLevel("error"), false), 2)
+	integration.MapCheckoutMessages(consumer)
+	return consumer
+}
+
+func TestCheckoutConsumerCreatesOrdersOnce(t *testing.T) {
+	inbox := &memoryInbox{handled: map[string]domain.InboxStatus{}}
+	var created []commands.CreateOrderCommand
+	consumer := newCheckoutConsumer(inbox, func(cmd commands.CreateOrderCommand) (int, error) {
+		created = append(created, cmd)
+		return len(created), nil
+	})
+
+	broker := messaging.NewMemoryBroker(time.Millisecond)
+	placed := `{"id":"m-1","type":"checkout.order_placed","data":{"customerId":4,"currency":"EUR","lines":[{"sku":"BOOK-1","quantity":2,"unitPrice":1250}]}}`
+	broker.Send("checkout", []byte(placed))
+	broker.Send("checkout", []byte(placed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.Run(ctx, broker, "checkout")
+
+	deadline := time.Now().Add(time.Second)
+	for broker.Pending("checkout") > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if len(created) != 1 {
+		t.Fatalf("expected the redelivered message to create one order, got %d", len(created))
+	}
+	if *created[0].CustomerID != 4 || created[0].Currency != "EUR" || created[0].Lines[0].SKU != "BOOK-1" || created[0].Lines[0].Quantity != 2 {
+		t.Errorf("unexpected command %+v", created[0])
+	}
+	if inbox.handled["default/m-1"] != domain.InboxProcessed {
+		t.Errorf("expected the message in the inbox, got %v", inbox.handled)
+	}
+}
+
+func TestCheckoutConsumerDeadLettersPoisonMessages(t *testing.T) {
+	inbox := &memoryInbox{handled: map[string]domain.InboxStatus{}}
+	consumer := newCheckoutConsumer(inbox, func(cmd commands.CreateOrderCommand) (int, error) {
+		return 0, errors.New("connection reset")
+	})
+	ctx := context.Background()
+
+	for _, body := range []string{
+		`not json`,
+		`{"id":"m-2","type":"checkout.cart_abandoned","data":{}}`,
+		`{"id":"m-3","type":"checkout.order_placed","data":{"currency":"EUR","lines":[]}}`,
+		`{"id":"m-4","type":"checkout.customer_assigned","data":{"orderId":9,"customerId":4}}`,
+	} {
+		if err := consumer.Handle(ctx, messaging.InboundMessage{Topic: "checkout", Body: []byte(body)}); err != nil {
+			t.Fatalf("expected %s to be dead-lettered, got %v", body, err)
+		}
+	}
+
+	// A failing command is delivered again until it runs out of attempts
+	failing := messaging.InboundMessage{Topic: "checkout", Body: []byte(`{"id":"m-5","type":"checkout.order_placed","data":{"currency":"EUR","lines":[{"sku":"BOOK-1","quantity":1,"unitPrice":100}]}}`)}
+	if err := consumer.Handle(ctx, failing); err == nil {
+		t.Fatal("expected the first failure to be redelivered")
+	}
+	if _, ok := inbox.handled["default/m-5"]; ok {
+		t.Fatal("expected the failed message to stay out of the inbox")
+	}
+	if err := consumer.Handle(ctx, failing); err != nil {
+		t.Fatalf("expected the second failure to be dead-lettered, got %v", err)
+	}
+
+	reasons := []string{"malformed message", "unknown message type", "an order needs at least one line", "AssignCustomerCommand rejected", "failed 2 times: connection reset"}
+	if len(inbox.deadLetters) != len(reasons) {
+		t.Fatalf("expected %d dead letters, got %+v", len(reasons), inbox.deadLetters)
+	}
+	for i, reason := range reasons {
+		if !strings.Contains(inbox.deadLetters[i].Reason, reason) {
+			t.Errorf("expected dead letter %d to be for %q, got %q", i, reason, inbox.deadLetters[i].Reason)
+		}
+	}
+	if inbox.handled["default/m-4"] != domain.InboxDeadLettered {
+		t.Errorf("expected the rejected message in the inbox, got %v", inbox.handled)
+	}
+}
+
+func TestCheckoutConsumerDeduplicatesMessagesPerTenant(t *testing.T) {
+	inbox := &memoryInbox{handled: map[string]domain.InboxStatus{}}
+	var created []commands.CreateOrderCommand
+	consumer := newCheckoutConsumer(inbox, func(cmd commands.CreateOrderCommand) (int, error) {
+		created = append(created, cmd)
+		return len(created), nil
+	})
+	ctx := context.Background()
+
+	for _, tenant := range []string{"acme", "globex", "acme"} {
+		placed := `{"id":"m-1","type":"checkout.order_placed","tenantid":"` + tenant + `","data":{"currency":"EUR","lines":[{"sku":"BOOK-1","quantity":1}]}}`
+		if err := consumer.Handle(ctx, messaging.InboundMessage{Topic: "checkout", Body: []byte(placed)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(created) != 2 {
+		t.Errorf("expected an order for each tenant, got %d", len(created))
+	}
+	if inbox.handled["acme/m-1"] != domain.InboxProcessed || inbox.handled["globex/m-1"] != domain.InboxProcessed {
+		t.Errorf("expected the message to be recorded for both tenants, got %v", inbox.handled)
+	}
+}
@@ -0,0 +1,140 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/services"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+type fakeInventoryRepository struct {
+	levels       map[string]domain.StockLevel
+	reservations map[int]map[string]int
+}
+
+func (r *fakeInventoryRepository) GetStockLevel(ctx context.Context, sku string) (*domain.StockLevel, error) {
+	level, ok := r.levels[sku]
+	if !ok {
+		return nil, nil
+	}
+	return &level, nil
+}
+
+func (r *fakeInventoryRepository) LockStockLevels(ctx context.Context, skus []string) (map[string]*domain.StockLevel, error) {
+	levels := make(map[string]*domain.StockLevel)
+	for _, sku := range skus {
+		if level, ok := r.levels[sku]; ok {
+			levels[sku] = &level
+		}
+	}
+	return levels, nil
+}
+
+func (r *fakeInventoryRepository) SaveStockLevel(ctx context.Context, level domain.StockLevel) error {
+	r.levels[level.SKU] = level
+	return nil
+}
+
+func (r *fakeInventoryRepository) GetReservations(ctx context.Context, orderID int) (map[string]int, error) {
+	reservations := make(map[string]int)
+	for sku, quantity := range r.reservations[orderID] {
+		reservations[sku] = quantity
+	}
+	return reservations, nil
+}
+
+func (r *fakeInventoryRepository) SetReservations(ctx context.Context, orderID int, quantities map[string]int) error {
+	r.reservations[orderID] = quantities
+	return nil
+}
+
+// snapshotTransactions restores the repository state when the work fails, like a rollback
+type snapshotTransactions struct {
+	repo *fakeInventoryRepository
+}
+
+func (m snapshotTransactions) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	levels := make(map[string]domain.StockLevel)
+	for sku, level := range m.repo.levels {
+		levels[sku] = level
+	}
+	reservations := make(map[int]map[string]int)
+	for id, quantities := range m.repo.reservations {
+		reservations[id] = quantities
+	}
+
+	if err := fn(ctx); err != nil {
+		m.repo.levels = levels
+		m.repo.reservations = reservations
+		return err
+	}
+	return nil
+}
+
+func TestReserveStockIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeInventoryRepository{
+		levels:       map[string]domain.StockLevel{"BOOK-1": {SKU: "BOOK-1", OnHand: 5}},
+		reservations: make(map[int]map[string]int),
+	}
+	inventory := services.NewInventoryService(repo, snapshotTransactions{repo: repo})
+
+	for i := 0; i < 2; i++ {
+		if err := inventory.ReserveStock(ctx, 1, map[string]int{"BOOK-1": 3}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if reserved := repo.levels["BOOK-1"].Reserved; reserved != 3 {
+		t.Errorf("expected 3 reserved after a repeated reservation, got %d", reserved)
+	}
+
+	err := inventory.ReserveStock(ctx, 2, map[string]int{"BOOK-1": 3})
+	var domainErr *domainerrors.DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != domainerrors.ErrorCodeInsufficientStock {
+		t.Fatalf("expected insufficient stock error, got %v", err)
+	}
+
+	if err := inventory.ReserveStock(ctx, 1, map[string]int{"BOOK-1": 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inventory.ReserveStock(ctx, 2, map[string]int{"BOOK-1": 4}); err != nil {
+		t.Fatalf("expected reduced reservation to free stock, got %v", err)
+	}
+
+	if err := inventory.ReleaseStock(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if level := repo.levels["BOOK-1"]; level.Reserved != 4 || level.Available() != 1 {
+		t.Errorf("expected 4 reserved and 1 available, got %d and %d", level.Reserved, level.Available())
+	}
+}
+
+func TestSKUsWithoutStockLevelAreNotTracked(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeInventoryRepository{
+		levels:       make(map[string]domain.StockLevel),
+		reservations: make(map[int]map[string]int),
+	}
+	inventory := services.NewInventoryService(repo, snapshotTransactions{repo: repo})
+
+	if err := inventory.ReserveStock(ctx, 1, map[string]int{"BOOK-1": 100}); err != nil {
+		t.Fatalf("expected any quantity of an untracked SKU to be orderable, got %v", err)
+	}
+	if len(repo.levels) != 0 || len(repo.reservations[1]) != 0 {
+		t.Errorf("expected nothing to be reserved, got %v and %v", repo.levels, repo.reservations[1])
+	}
+
+	// Once it has a stock level the SKU is tracked
+	if _, err := inventory.SetStockLevel(ctx, dto.SetStockLevelRequest{SKU: "BOOK-1", OnHand: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := inventory.ReserveStock(ctx, 2, map[string]int{"BOOK-1": 3})
+	var domainErr *domainerrors.DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != domainerrors.ErrorCodeInsufficientStock {
+		t.Fatalf("expected insufficient stock error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package customer
+
+import (
+	"encoding/json"
+	"go-cqrs/internal/adapters/http/controllers"
+	"go-cqrs/internal/adapters/http/middleware"
+	"go-cqrs/internal/adapters/http/openapi"
+	"go-cqrs/internal/adapters/http/router"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newDocumentedRouter() *router.MuxRouter {
+	return router.NewRouter(controllers.CustomerController{}, controllers.OrderController{}, controllers.ProductController{},
+		controllers.InventoryController{}, controllers.ImportController{}, controllers.ExportController{}, controllers.BatchController{},
+		controllers.GraphQLController{}, controllers.EventStreamController{}, controllers.WebhookController{},
+		middleware.TenantResolver{}, middleware.NewCorsPolicy([]string{"*"})).(*router.MuxRouter)
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	problems, err := openapi.Undocumented(newDocumentedRouter().Router)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
+
+func TestOpenAPIDocumentResolves(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newDocumentedRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the document, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(recorder.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expected OpenAPI 3.1.0, got %s", doc.OpenAPI)
+	}
+
+	// Every reference names a component schema
+	body := recorder.Body.String()
+	for _, part := range strings.Split(body, `"$ref": "#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("unresolved reference to %s", name)
+		}
+	}
+
+	line := doc.Components.Schemas["OrderLine"]
+	if line == nil || line.Properties["quantity"].Minimum == nil || *line.Properties["quantity"].Minimum != 1 {
+		t.Errorf("expected the domain constraint on the line quantity, got %+v", line)
+	}
+	if get := doc.Paths["/api/orders/{id}"]["get"]; get == nil || get.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("expected the order ID to be an integer path parameter, got %+v", get)
+	}
+}
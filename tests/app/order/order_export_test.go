@@ -0,0 +1,80 @@
+package customer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go-cqrs/internal/adapters/exports"
+	"testing"
+)
+
+var exportColumns = []exports.Column{
+	{Name: "id", Type: exports.Int64Column},
+	{Name: "customerId", Type: exports.Int64Column, Optional: true},
+	{Name: "product", Type: exports.StringColumn},
+}
+
+var exportRecords = []exports.Record{
+	{Values: []interface{}{int64(1), int64(7), "BOOK-1"}},
+	{Values: []interface{}{int64(2), nil, "PEN, blue"}},
+}
+
+func TestCSVExport(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := exports.NewRecordWriter(exports.FormatCSV, &out, exportColumns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, record := range exportRecords {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "id,customerId,product\n1,7,BOOK-1\n2,,\"PEN, blue\"\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestParquetExportLayout(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := exports.NewRecordWriter(exports.FormatParquet, &out, exportColumns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, record := range exportRecords {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Write(exports.Record{Values: []interface{}{nil, nil, "X"}}); err == nil {
+		t.Error("expected a missing required value to be rejected")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := out.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("expected the file to start and end with the Parquet magic bytes")
+	}
+
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLength <= 0 || footerLength > len(data)-12 {
+		t.Fatalf("invalid footer length %d for a file of %d bytes", footerLength, len(data))
+	}
+	footer := data[len(data)-8-footerLength : len(data)-8]
+	for _, name := range []string{"customerId", "product", "go-cqrs"} {
+		if !bytes.Contains(footer, []byte(name)) {
+			t.Errorf("expected the footer to mention %q", name)
+		}
+	}
+
+	// The product column holds plain length-prefixed values
+	if !bytes.Contains(data, append([]byte{9, 0, 0, 0}, "PEN, blue"...)) {
+		t.Error("expected the product values to be stored with plain encoding")
+	}
+}
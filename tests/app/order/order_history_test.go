@@ -0,0 +1,57 @@
+package customer
+
+import (
+	"context"
+	"testing"
+
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/domain/events"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+)
+
+func TestOrderHistoryDiffsSuccessiveStates(t *testing.T) {
+	store := event_store.NewInMemoryEventStore("order")
+	ctx := requestctx.WithRequestID(requestctx.WithActor(context.Background(), "support"), "req-1")
+
+	customerID := "7"
+	store.StoreEvent(ctx, events.NewOrderCreatedEvent("1", "book", 1, "USD", 0, nil))
+	store.StoreEvent(ctx, events.NewOrderUpdatedEvent("1", "book", 3, "USD", 0, nil, &customerID))
+	store.StoreEvent(ctx, events.NewOrderCreatedEvent("2", "pen", 1, "USD", 0, nil))
+
+	handler := queries.NewOrderQueryHandler(nil, store)
+	history, err := handler.HandleGetOrderHistoryQuery(ctx, queries.GetOrderHistoryQuery{ID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(history.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(history.Entries))
+	}
+
+	first := history.Entries[0]
+	if first.Actor != "support" || first.RequestID != "req-1" {
+		t.Errorf("unexpected metadata: actor=%q requestId=%q", first.Actor, first.RequestID)
+	}
+	if len(first.Changes) != 4 {
+		t.Errorf("expected currency, product, quantity and total to be set, got %+v", first.Changes)
+	}
+
+	second := history.Entries[1]
+	if len(second.Changes) != 2 {
+		t.Fatalf("expected customerId and quantity to change, got %+v", second.Changes)
+	}
+	if second.Changes[0].Field != "customerId" || second.Changes[0].To != "7" {
+		t.Errorf("unexpected customer change: %+v", second.Changes[0])
+	}
+	if second.Changes[1].Field != "quantity" || second.Changes[1].From != float64(1) || second.Changes[1].To != float64(3) {
+		t.Errorf("unexpected quantity change: %+v", second.Changes[1])
+	}
+}
+
+func TestOrderHistoryNotFound(t *testing.T) {
+	handler := queries.NewOrderQueryHandler(nil, event_store.NewInMemoryEventStore("order"))
+	if _, err := handler.HandleGetOrderHistoryQuery(context.Background(), queries.GetOrderHistoryQuery{ID: 42}); err == nil {
+		t.Fatal("expected an error for an order without events")
+	}
+}
@@ -0,0 +1,177 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/application/services"
+	"go-cqrs/internal/domain"
+	domainerrors "go-cqrs/internal/domain/errors"
+)
+
+func TestOrderLineInvariants(t *testing.T) {
+	book, _ := domain.NewOrderLine("BOOK-1", "Book", 2, domain.Money{Amount: 1500, Currency: "EUR"})
+	pen, _ := domain.NewOrderLine("PEN-1", "Pen", 3, domain.Money{Amount: 200, Currency: "EUR"})
+
+	order, err := domain.NewOrderWithLines([]domain.OrderLine{book, pen})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Product != "BOOK-1" || order.Quantity != 5 {
+		t.Errorf("expected single-product fields BOOK-1/5, got %s/%d", order.Product, order.Quantity)
+	}
+
+	if total := order.Total(); total.Amount != 3600 || total.Currency != "EUR" {
+		t.Errorf("expected total 3600 EUR, got %v", total)
+	}
+
+	if err := order.AddLine(book); err == nil {
+		t.Error("expected duplicate SKU to be rejected")
+	}
+
+	dollarPen := domain.OrderLine{SKU: "PEN-2", Quantity: 1, UnitPrice: domain.Money{Amount: 100, Currency: "USD"}}
+	if err := order.AddLine(dollarPen); err == nil {
+		t.Error("expected a line in another currency to be rejected")
+	}
+
+	if err := order.RemoveLine("PEN-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := order.RemoveLine("BOOK-1"); err == nil {
+		t.Error("expected removing the last line to be rejected")
+	}
+
+	lines := make([]domain.OrderLine, domain.MaxOrderLines+1)
+	for i := range lines {
+		lines[i] = domain.OrderLine{
+			SKU:       string(rune('A'+i%26)) + string(rune('a'+i/26)),
+			Quantity:  1,
+			UnitPrice: domain.Money{Currency: "USD"},
+		}
+	}
+	if _, err := domain.NewOrderWithLines(lines); err == nil {
+		t.Error("expected too many lines to be rejected")
+	}
+}
+
+func TestMoney(t *testing.T) {
+	if _, err := domain.NewMoney(100, "XYZ"); err == nil {
+		t.Error("expected unknown currency to be rejected")
+	}
+
+	price, err := domain.NewMoney(1999, "usd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if price.String() != "19.99 USD" {
+		t.Errorf("unexpected format: %s", price)
+	}
+
+	yen, _ := domain.NewMoney(500, "JPY")
+	if _, err := price.Add(yen); err == nil {
+		t.Error("expected adding different currencies to fail")
+	}
+	if yen.String() != "500 JPY" {
+		t.Errorf("unexpected format: %s", yen)
+	}
+
+	if _, err := price.Multiply(1 << 62); err == nil {
+		t.Error("expected overflow to be rejected")
+	}
+}
+
+// catalogProducts is a product catalog keyed by SKU
+type catalogProducts struct {
+	ports.ProductRepository
+	products map[string]domain.Product
+}
+
+func (r catalogProducts) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	if product, ok := r.products[sku]; ok {
+		return &product, nil
+	}
+	return nil, nil
+}
+
+// memoryOrders keeps orders in memory
+type memoryOrders struct {
+	ports.OrderRepository
+	orders []domain.Order
+}
+
+func (r *memoryOrders) Create(ctx context.Context, order domain.Order) (int, error) {
+	order.ID = len(r.orders) + 1
+	r.orders = append(r.orders, order)
+	return order.ID, nil
+}
+
+func (r *memoryOrders) GetByID(ctx context.Context, id int) (*domain.Order, error) {
+	if id < 1 || id > len(r.orders) {
+		return nil, nil
+	}
+	order := r.orders[id-1]
+	return &order, nil
+}
+
+func (r *memoryOrders) Update(ctx context.Context, order domain.Order) error {
+	r.orders[order.ID-1] = order
+	return nil
+}
+
+// unlimitedStock reserves any quantity
+type unlimitedStock struct {
+	ports.InventoryUseCase
+}
+
+func (unlimitedStock) ReserveStock(ctx context.Context, orderID int, quantities map[string]int) error {
+	return nil
+}
+
+// passthroughTransactions runs the work without a database
+type passthroughTransactions struct{}
+
+func (passthroughTransactions) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestOrderLinesAreOrderedAtTheCatalogPrice(t *testing.T) {
+	products := catalogProducts{products: map[string]domain.Product{
+		"BOOK-1": {SKU: "BOOK-1", Name: "Book", Active: true, Price: domain.Money{Amount: 1500, Currency: "EUR"}},
+	}}
+	orders := &memoryOrders{}
+	service := services.NewOrderService(orders, nil, products, unlimitedStock{}, passthroughTransactions{})
+	ctx := context.Background()
+
+	created, err := service.CreateOrder(ctx, dto.CreateOrderRequest{Currency: "EUR", Lines: []dto.OrderLineDTO{{SKU: "BOOK-1", Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Lines[0].UnitPrice != 1500 || created.Total != 3000 {
+		t.Errorf("expected the line to be priced from the catalog, got %+v", created.Lines[0])
+	}
+
+	for name, request := range map[string]dto.CreateOrderRequest{
+		"other price":    {Currency: "EUR", Lines: []dto.OrderLineDTO{{SKU: "BOOK-1", Quantity: 2, UnitPrice: 1}}},
+		"other currency": {Currency: "USD", Lines: []dto.OrderLineDTO{{SKU: "BOOK-1", Quantity: 2, UnitPrice: 1500}}},
+	} {
+		var domainErr *domainerrors.DomainError
+		if _, err := service.CreateOrder(ctx, request); !errors.As(err, &domainErr) || domainErr.Code != domainerrors.ErrorCodeValidation {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+	if err := service.ChangeOrderLine(ctx, created.ID, dto.OrderLineDTO{SKU: "BOOK-1", Quantity: 3, UnitPrice: 900}); err == nil {
+		t.Error("expected a changed line at another price to be rejected")
+	}
+
+	// Lines keep the price they were ordered at when the catalog price changes
+	products.products["BOOK-1"] = domain.Product{SKU: "BOOK-1", Name: "Book", Active: true, Price: domain.Money{Amount: 1800, Currency: "EUR"}}
+	if err := service.ChangeOrderLine(ctx, created.ID, dto.OrderLineDTO{SKU: "BOOK-1", Quantity: 3, UnitPrice: 1500}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order, _ := orders.GetByID(ctx, created.ID); order.Lines[0].Quantity != 3 || order.Lines[0].UnitPrice.Amount != 1500 {
+		t.Errorf("expected the line to keep its price, got %+v", order.Lines[0])
+	}
+}
@@ -0,0 +1,78 @@
+package customer
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/controllers"
+	"go-cqrs/internal/adapters/http/dto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func getOrderView(t *testing.T, target, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	queryBus := bus.NewQueryBus()
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetOrderQuery) (*dto.OrderDTO, error) {
+		return &dto.OrderDTO{
+			ID:       query.ID,
+			Product:  "book",
+			Quantity: 2,
+			Currency: "EUR",
+			Total:    3000,
+			Lines:    []dto.OrderLineDTO{{SKU: "BOOK-1", Quantity: 2, UnitPrice: 1500, Subtotal: 3000}},
+		}, nil
+	})
+	controller := controllers.NewOrderController(bus.NewCommandBus(), queryBus)
+
+	request := mux.SetURLVars(httptest.NewRequest(http.MethodGet, target, nil), map[string]string{"id": "7"})
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	controller.GetOrder(recorder, request)
+	return recorder
+}
+
+func TestOrderViewUsesJSONFieldNames(t *testing.T) {
+	recorder := getOrderView(t, "/api/orders/7", "")
+
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || !strings.Contains(body, `"id":7`) || !strings.Contains(body, `"unitPrice":1500`) {
+		t.Errorf("expected the order view with JSON field names, got %d %s", recorder.Code, body)
+	}
+}
+
+func TestOrderViewProjectsFields(t *testing.T) {
+	recorder := getOrderView(t, "/api/orders/7?fields=total,id,lines.sku", "application/json")
+
+	if body := strings.TrimSpace(recorder.Body.String()); body != `{"total":3000,"id":7,"lines":[{"sku":"BOOK-1"}]}` {
+		t.Errorf("expected only the listed fields in order, got %s", body)
+	}
+
+	recorder = getOrderView(t, "/api/orders/7?fields=id,CustomerID", "")
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown field to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestOrderViewNegotiatesContentType(t *testing.T) {
+	recorder := getOrderView(t, "/api/orders/7?fields=id,product,lines.sku", "text/csv, application/json;q=0.5")
+
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected CSV, got %s", contentType)
+	}
+	if body := recorder.Body.String(); body != "id,product,lines.sku\n7,book,\"[\"\"BOOK-1\"\"]\"\n" {
+		t.Errorf("unexpected CSV body %q", body)
+	}
+
+	recorder = getOrderView(t, "/api/orders/7", "application/xml")
+	if recorder.Code != http.StatusNotAcceptable {
+		t.Errorf("expected an unsupported media type to be refused, got %d", recorder.Code)
+	}
+}
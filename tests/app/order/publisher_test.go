@@ -0,0 +1,156 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-cqrs/internal/infrastructure/logger"
+	"go-cqrs/internal/infrastructure/messaging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelayPublishesEventsAtLeastOnceInOrder(t *testing.T) {
+	topics, err := messaging.ParseTopicMap("domain-events", "order.created=orders, order.updated=orders")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log := newMemoryEventLog()
+	log.append("order.created", "1")
+	cursors := memoryCursors{}
+	publisher := messaging.NewMemoryPublisher()
+	relay := messaging.NewRelay(log, cursors, publisher, topics, logger.NewZapLogger(logger.LogLevel("error"), false), time.Second)
+	ctx := context.Background()
+
+	// The relay starts at the end of the log
+	if published, err := relay.Forward(ctx); err != nil || published != 0 {
+		t.Fatalf("expected nothing to publish, got %d: %v", published, err)
+	}
+
+	log.append("order.created", "2")
+	log.append("order.line_added", "2")
+	publisher.Fail(errors.New("broker unavailable"))
+	if _, err := relay.Forward(ctx); err == nil {
+		t.Fatal("expected the publish to fail")
+	}
+	if cursors["broker"] != 1 {
+		t.Fatalf("expected the cursor to stay at 1, got %d", cursors["broker"])
+	}
+
+	publisher.Fail(nil)
+	log.append("order.updated", "2")
+	if published, err := relay.Forward(ctx); err != nil || published != 3 {
+		t.Fatalf("expected the three events to be published, got %d: %v", published, err)
+	}
+
+	messages := publisher.Messages()
+	expectedTopics := []string{"orders", "domain-events", "orders"}
+	for i, message := range messages {
+		if message.ID != []string{"2", "3", "4"}[i] || message.Topic != expectedTopics[i] || message.Key != "2" {
+			t.Errorf("unexpected message %d: %+v", i, message)
+		}
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(messages[0].Payload, &payload); err != nil || payload["eventType"] != "order.created" {
+		t.Errorf("expected the event as payload, got %s", messages[0].Payload)
+	}
+	if cursors["broker"] != 4 {
+		t.Errorf("expected the cursor at 4, got %d", cursors["broker"])
+	}
+}
+
+func TestRelayWaitsForTransactionsCommittingAfterLaterOnes(t *testing.T) {
+	log := newMemoryEventLog()
+	cursors := memoryCursors{}
+	publisher := messaging.NewMemoryPublisher()
+	relay := messaging.NewRelay(log, cursors, publisher, messaging.TopicMap{Default: "domain-events"}, logger.NewZapLogger(logger.LogLevel("error"), false), time.Second)
+	ctx := context.Background()
+	if _, err := relay.Forward(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Event 1 belongs to a slow transaction still running when event 2 is committed
+	endSlow := log.begin("order.created", "1")
+	log.append("order.created", "2")
+	for i := 0; i < 2; i++ {
+		if published, err := relay.Forward(ctx); err != nil || published != 1-i {
+			t.Fatalf("expected %d events to be published, got %d: %v", 1-i, published, err)
+		}
+	}
+	if cursors["broker"] != 0 {
+		t.Fatalf("expected the cursor to stay before the running transaction, got %d", cursors["broker"])
+	}
+
+	endSlow(true)
+	if published, err := relay.Forward(ctx); err != nil || published != 1 {
+		t.Fatalf("expected the late event to be published, got %d: %v", published, err)
+	}
+	if cursors["broker"] != 2 {
+		t.Errorf("expected the cursor at 2, got %d", cursors["broker"])
+	}
+
+	// A rolled back transaction leaves a gap the relay stops waiting for
+	endRolledBack := log.begin("order.created", "3")
+	log.append("order.created", "4")
+	relay.Forward(ctx)
+	endRolledBack(false)
+	relay.Forward(ctx)
+	log.append("order.created", "5")
+	if published, err := relay.Forward(ctx); err != nil || published != 1 {
+		t.Fatalf("expected the next event to be published, got %d: %v", published, err)
+	}
+	if cursors["broker"] != 5 {
+		t.Errorf("expected the cursor at 5, got %d", cursors["broker"])
+	}
+
+	var ids []string
+	for _, message := range publisher.Messages() {
+		ids = append(ids, message.ID)
+	}
+	if strings.Join(ids, ",") != "2,1,4,5" {
+		t.Errorf("expected every committed event to be published once, got %v", ids)
+	}
+}
+
+func TestKafkaPublisherProducesKeyedRecordsPerTopic(t *testing.T) {
+	var requests []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+		var body struct {
+			Records []struct {
+				Key   string          `json:"key"`
+				Value json.RawMessage `json:"value"`
+			} `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, record := range body.Records {
+			requests = append(requests, r.URL.Path+" "+record.Key+" "+string(record.Value))
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	defer proxy.Close()
+
+	err := messaging.NewKafkaPublisher(proxy.URL, time.Second).Publish(context.Background(), []messaging.Message{
+		{ID: "1", Topic: "orders", Key: "7", Payload: []byte(`{"id":1}`)},
+		{ID: "2", Topic: "orders", Key: "8", Payload: []byte(`{"id":2}`)},
+		{ID: "3", Topic: "customers", Key: "7", Payload: []byte(`{"id":3}`)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{`/topics/orders 7 {"id":1}`, `/topics/orders 8 {"id":2}`, `/topics/customers 7 {"id":3}`}
+	if len(requests) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], requests[i])
+		}
+	}
+}
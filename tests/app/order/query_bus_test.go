@@ -0,0 +1,102 @@
+package customer
+
+import (
+	"context"
+	"go-cqrs/internal/adapters/cqrs/bus"
+	"go-cqrs/internal/adapters/cqrs/queries"
+	"go-cqrs/internal/adapters/http/dto"
+	"go-cqrs/internal/domain/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"testing"
+	"time"
+)
+
+func newCachedOrderBus(cache *bus.QueryCache, lookups *int) *bus.QueryBus {
+	queryBus := bus.NewQueryBus()
+	queryBus.Use(bus.CachingMiddleware(cache, queries.CachedQueries...))
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetOrderQuery) (*dto.OrderDTO, error) {
+		*lookups++
+		return &dto.OrderDTO{ID: query.ID, Product: "book"}, nil
+	})
+	return queryBus
+}
+
+func TestQueryBusCachesUntilInvalidated(t *testing.T) {
+	cache := bus.NewQueryCache(10, time.Minute)
+	lookups := 0
+	queryBus := newCachedOrderBus(cache, &lookups)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		order, err := bus.Ask[*dto.OrderDTO](ctx, queryBus, queries.GetOrderQuery{ID: 7})
+		if err != nil || order.ID != 7 {
+			t.Fatalf("expected order 7, got %+v, %v", order, err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected repeated queries to be answered from the cache, got %d lookups", lookups)
+	}
+
+	// An event of another order leaves the cached result alone, one of the same order drops it
+	invalidator := cache.InvalidateOn(queries.StaleQueries)
+	invalidator.HandleEvent(ctx, events.NewOrderDeletedEvent("8"))
+	bus.Ask[*dto.OrderDTO](ctx, queryBus, queries.GetOrderQuery{ID: 7})
+	if lookups != 1 {
+		t.Errorf("expected an unrelated event to keep the cached order, got %d lookups", lookups)
+	}
+
+	invalidator.HandleEvent(ctx, events.NewOrderDeletedEvent("7"))
+	bus.Ask[*dto.OrderDTO](ctx, queryBus, queries.GetOrderQuery{ID: 7})
+	if lookups != 2 {
+		t.Errorf("expected the order event to drop the cached order, got %d lookups", lookups)
+	}
+}
+
+func TestProductEventsDropTheCachedProductForEveryTenant(t *testing.T) {
+	cache := bus.NewQueryCache(10, time.Minute)
+	cache.ShareAcrossTenants(queries.SharedQueries...)
+	lookups := 0
+	queryBus := bus.NewQueryBus()
+	queryBus.Use(bus.CachingMiddleware(cache, queries.CachedQueries...))
+	bus.RegisterQuery(queryBus, func(ctx context.Context, query queries.GetProductQuery) (*dto.ProductDTO, error) {
+		lookups++
+		return &dto.ProductDTO{ID: query.ID, SKU: "BOOK-1"}, nil
+	})
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	globex := requestctx.WithTenant(context.Background(), "globex")
+
+	bus.Ask[*dto.ProductDTO](acme, queryBus, queries.GetProductQuery{ID: 3})
+	bus.Ask[*dto.ProductDTO](globex, queryBus, queries.GetProductQuery{ID: 3})
+	if lookups != 1 {
+		t.Errorf("expected the tenants to share the cached product, got %d lookups", lookups)
+	}
+
+	// A product changed by one tenant is read again by the others
+	cache.InvalidateOn(queries.StaleQueries).HandleEvent(acme, events.NewProductUpdatedEvent("3", "Book", 1800, "EUR"))
+	bus.Ask[*dto.ProductDTO](globex, queryBus, queries.GetProductQuery{ID: 3})
+	if lookups != 2 {
+		t.Errorf("expected the product event to drop the cached product for every tenant, got %d lookups", lookups)
+	}
+}
+
+func TestQueryCacheEvictsAndExpires(t *testing.T) {
+	cache := bus.NewQueryCache(2, time.Minute)
+	cache.Set(queries.GetOrderQuery{ID: 1}, "first")
+	cache.Set(queries.GetOrderQuery{ID: 2}, "second")
+	cache.Get(queries.GetOrderQuery{ID: 1})
+	cache.Set(queries.GetOrderQuery{ID: 3}, "third")
+
+	if _, ok := cache.Get(queries.GetOrderQuery{ID: 2}); ok {
+		t.Error("expected the least recently used result to be evicted")
+	}
+	if _, ok := cache.Get(queries.GetOrderQuery{ID: 1}); !ok {
+		t.Error("expected the recently used result to be kept")
+	}
+
+	expiring := bus.NewQueryCache(2, time.Millisecond)
+	expiring.Set(queries.GetOrderQuery{ID: 1}, "first")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := expiring.Get(queries.GetOrderQuery{ID: 1}); ok {
+		t.Error("expected the result to expire after its TTL")
+	}
+}
@@ -0,0 +1,247 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"go-cqrs/internal/adapters/webhooks"
+	"go-cqrs/internal/application/ports"
+	"go-cqrs/internal/application/services"
+	"go-cqrs/internal/domain"
+	"go-cqrs/internal/infrastructure/logger"
+	event_store "go-cqrs/internal/infrastructure/messaging/events"
+	"go-cqrs/internal/infrastructure/requestctx"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const webhookSecret = "0123456789abcdef"
+
+// memoryWebhooks keeps webhooks, deliveries and attempts in memory, scoped to tenants like the database
+type memoryWebhooks struct {
+	ports.WebhookRepository
+	mu         sync.Mutex
+	webhooks   []domain.Webhook
+	deliveries []domain.WebhookDelivery
+	attempts   []domain.WebhookAttempt
+}
+
+func (r *memoryWebhooks) ListActive(ctx context.Context) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Webhook(nil), r.webhooks...), nil
+}
+
+func (r *memoryWebhooks) GetByID(ctx context.Context, id int) (*domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id && webhook.TenantID == requestctx.Tenant(ctx) {
+			return &webhook, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWebhooks) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.ID = len(r.deliveries) + 1
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *memoryWebhooks) GetDelivery(ctx context.Context, webhookID, id int) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID && delivery.ID == id && delivery.TenantID == requestctx.Tenant(ctx) {
+			return &delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWebhooks) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []domain.WebhookDelivery
+	for i, delivery := range r.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(time.Now()) && len(due) < limit {
+			due = append(due, delivery)
+			r.deliveries[i].NextAttemptAt = time.Now().Add(lease)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryWebhooks) SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID-1] = delivery
+	if attempt != nil {
+		r.attempts = append(r.attempts, *attempt)
+	}
+	return nil
+}
+
+func (r *memoryWebhooks) delivery(id int) domain.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveries[id-1]
+}
+
+// memoryCursors keeps cursor positions in memory
+type memoryCursors map[string]int64
+
+func (c memoryCursors) LoadPosition(ctx context.Context, name string) (int64, bool, error) {
+	position, ok := c[name]
+	return position, ok, nil
+}
+
+func (c memoryCursors) SavePosition(ctx context.Context, name string, position int64) error {
+	c[name] = position
+	return nil
+}
+
+// signedReceiver answers deliveries with the given status codes in turn, repeating the last one,
+// and fails the test on a delivery with a bad signature
+func signedReceiver(t *testing.T, statuses ...int) *httptest.Server {
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhooks.Verify(webhookSecret, r.Header.Get(webhooks.HeaderTimestamp), body, r.Header.Get(webhooks.HeaderSignature)) {
+			t.Errorf("delivery %s has a bad signature", r.Header.Get(webhooks.HeaderDeliveryID))
+		}
+		mu.Lock()
+		status := statuses[min(received, len(statuses)-1)]
+		received++
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newWebhookWorker(repository *memoryWebhooks, log *memoryEventLog, cursors memoryCursors, maxAttempts int) *webhooks.Worker {
+	return webhooks.NewWorker(repository, log, cursors, passthroughTransactions{}, webhooks.NewSender(time.Second),
+		webhooks.RetryPolicy{MaxAttempts: maxAttempts}, logger.NewZapLogger(logger.LogLevel("error"), false),
+		time.Second, time.Minute)
+}
+
+func TestWebhookWorkerSignsAndRetriesDeliveries(t *testing.T) {
+	receiver := signedReceiver(t, http.StatusInternalServerError, http.StatusOK)
+	repository := &memoryWebhooks{webhooks: []domain.Webhook{
+		{ID: 1, TenantID: requestctx.DefaultTenant, URL: receiver.URL, EventTypes: []string{"order.created"}, Secret: webhookSecret, Active: true},
+	}}
+	log := newMemoryEventLog()
+	log.append("order.created", "1")
+	cursors := memoryCursors{}
+	worker := newWebhookWorker(repository, log, cursors, 5)
+	ctx := context.Background()
+
+	// The worker starts at the end of the log, so only the events stored afterwards are delivered
+	if err := worker.Enqueue(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log.append("order.created", "2")
+	log.append("order.updated", "2")
+	if err := worker.Enqueue(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repository.deliveries) != 1 || repository.deliveries[0].EventID != 2 || cursors["webhooks"] != 3 {
+		t.Fatalf("expected a delivery of event 2 and the cursor at 3, got %+v and %d", repository.deliveries, cursors["webhooks"])
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(repository.deliveries[0].Payload, &payload); err != nil || payload["aggregateId"] != "2" {
+		t.Fatalf("expected the event as payload, got %s", repository.deliveries[0].Payload)
+	}
+
+	for _, expected := range []domain.WebhookDeliveryStatus{domain.WebhookDeliveryPending, domain.WebhookDeliveryDelivered} {
+		if attempted, err := worker.Deliver(ctx); err != nil || attempted != 1 {
+			t.Fatalf("expected one attempt, got %d: %v", attempted, err)
+		}
+		if status := repository.delivery(1).Status; status != expected {
+			t.Fatalf("expected the delivery to be %s, got %s", expected, status)
+		}
+	}
+	if len(repository.attempts) != 2 || repository.attempts[0].StatusCode != http.StatusInternalServerError || repository.attempts[1].Attempt != 2 {
+		t.Errorf("expected the failed and the successful attempt in the log, got %+v", repository.attempts)
+	}
+}
+
+func TestDeadWebhookDeliveriesCanBeRedelivered(t *testing.T) {
+	receiver := signedReceiver(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusNoContent)
+	repository := &memoryWebhooks{
+		webhooks: []domain.Webhook{{ID: 1, TenantID: requestctx.DefaultTenant, URL: receiver.URL, Secret: webhookSecret, Active: true}},
+		deliveries: []domain.WebhookDelivery{
+			{ID: 1, WebhookID: 1, TenantID: requestctx.DefaultTenant, EventID: 7, EventType: "order.created", Payload: []byte(`{}`), Status: domain.WebhookDeliveryPending},
+		},
+	}
+	worker := newWebhookWorker(repository, newMemoryEventLog(), memoryCursors{}, 2)
+	ctx := context.Background()
+
+	worker.Deliver(ctx)
+	worker.Deliver(ctx)
+	if delivery := repository.delivery(1); delivery.Status != domain.WebhookDeliveryDead || delivery.LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the delivery to be dead after two attempts, got %+v", delivery)
+	}
+	if attempted, _ := worker.Deliver(ctx); attempted != 0 {
+		t.Fatalf("expected dead deliveries to be left alone, got %d attempts", attempted)
+	}
+
+	if err := services.NewWebhookService(repository).RedeliverWebhookDelivery(ctx, 1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempted, _ := worker.Deliver(ctx); attempted != 1 {
+		t.Fatalf("expected the redelivery to be attempted, got %d attempts", attempted)
+	}
+	if delivery := repository.delivery(1); delivery.Status != domain.WebhookDeliveryDelivered || delivery.Attempts != 1 {
+		t.Errorf("expected the redelivery to succeed on its first attempt, got %+v", delivery)
+	}
+}
+
+func TestWebhooksOnlyReceiveTheEventsOfTheirTenant(t *testing.T) {
+	receiver := signedReceiver(t, http.StatusOK)
+	repository := &memoryWebhooks{webhooks: []domain.Webhook{
+		{ID: 1, TenantID: "globex", URL: receiver.URL, Secret: webhookSecret, Active: true},
+	}}
+	log := newMemoryEventLog()
+	cursors := memoryCursors{"webhooks": 0}
+	worker := newWebhookWorker(repository, log, cursors, 5)
+	ctx := context.Background()
+
+	// The events of the log belong to the default tenant, which has no webhooks
+	log.append("customer.created", "1")
+	log.append("order.created", "2")
+	if err := worker.Enqueue(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repository.deliveries) != 0 || cursors["webhooks"] != 2 {
+		t.Fatalf("expected no deliveries to the webhook of another tenant, got %+v", repository.deliveries)
+	}
+
+	log.mu.Lock()
+	log.events = append(log.events, event_store.StoredEvent{ID: 3, EventType: "order.created", AggregateType: "order",
+		AggregateID: "3", TenantID: "globex", OccurredAt: time.Now(), Data: []byte(`{}`)})
+	log.mu.Unlock()
+	if err := worker.Enqueue(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repository.deliveries) != 1 || repository.deliveries[0].EventID != 3 || repository.deliveries[0].TenantID != "globex" {
+		t.Fatalf("expected the event of globex to be delivered to its webhook, got %+v", repository.deliveries)
+	}
+
+	// Another tenant can neither read the delivery nor the webhook
+	acme := requestctx.WithTenant(ctx, "acme")
+	if _, err := services.NewWebhookService(repository).GetWebhookDelivery(acme, 1, 1); err == nil {
+		t.Error("expected the delivery of globex to be hidden from acme")
+	}
+	if attempted, err := worker.Deliver(ctx); err != nil || attempted != 1 || repository.delivery(1).Status != domain.WebhookDeliveryDelivered {
+		t.Errorf("expected the delivery to be attempted for globex, got %d attempts: %v", attempted, err)
+	}
+}